@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+	"io"
+)
+
+// Cat - cat [file...]: выводит файлы подряд, без аргументов или "-" - stdin.
+type Cat struct{}
+
+func (cd *Cat) Run(ctx *Context, args []string) int {
+	_, args, err := parseOptions(args, "", "")
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "cat: %v\nusage: cat [file ...]\n", err)
+		return 1
+	}
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	status := 0
+	for _, name := range args {
+		in, err := openInput(ctx, name)
+		if err != nil {
+			pathError(ctx.Stderr, "cat", name, err)
+			status = 1
+			continue
+		}
+
+		_, err = io.Copy(ctx.Stdout, in)
+		in.Close()
+		if err != nil {
+			pathError(ctx.Stderr, "cat", name, err)
+			return 1
+		}
+	}
+
+	return status
+}
@@ -2,44 +2,41 @@ package command
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 )
 
 type ChangeDir struct{}
 
-func (cd *ChangeDir) Run(args []string, vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+func (cd *ChangeDir) Run(ctx *Context, args []string) int {
 
 	var target string
 
 	if len(args) == 0 {
-		target = vars["HOME"]
+		target = ctx.Vars["HOME"]
 	} else {
 		target = args[0]
 	}
 
-	if !filepath.IsAbs(target) {
-		target = filepath.Clean(filepath.Join(vars["PWD"], target))
-	}
+	target = filepath.Clean(ctx.Path(target))
 
 	if !filepath.IsAbs(target) {
-		fmt.Fprintln(stderr, "cd: cant enter", target)
+		fmt.Fprintln(ctx.Stderr, "cd: cant enter", target)
 		return 1
 	}
 
 	info, err := os.Stat(target)
 	if os.IsNotExist(err) {
-		fmt.Fprintln(stderr, "cd: no such file or directory", target)
+		fmt.Fprintln(ctx.Stderr, "cd: no such file or directory", target)
 		return 1
 	}
 
 	if !info.IsDir() {
-		fmt.Fprintln(stderr, "cd: no a directory", target)
+		fmt.Fprintln(ctx.Stderr, "cd: no a directory", target)
 		return 1
 	}
 
-	os.Chdir(target)
-	vars["PWD"] = target
+	// каталог процесса не меняется: он общий для всех шеллов процесса
+	ctx.Vars["PWD"] = target
 	return 0
 }
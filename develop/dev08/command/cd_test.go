@@ -56,7 +56,7 @@ func TestCd(t *testing.T) {
 
 	for idx, test := range tests {
 		cd := &ChangeDir{}
-		exitCode := cd.Run(test.args, test.vars, strings.NewReader(""), io.Discard, io.Discard)
+		exitCode := cd.Run(NewContext(test.vars, strings.NewReader(""), io.Discard, io.Discard), test.args)
 		if exitCode != 0 {
 			if test.exitCode != exitCode {
 				t.Logf("for %v expected exit(%d), got %d", idx, test.exitCode, exitCode)
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"syscall"
+)
+
+// Context - окружение, в котором выполняется команда. Команды не трогают
+// состояние процесса (текущий каталог, переменные окружения), поэтому в
+// одном процессе могут работать несколько шеллов. Отмена контекста
+// (Ctrl-C, закрытие сессии) прерывает долгие команды: tail -f, nc, wait.
+type Context struct {
+	context.Context
+
+	Dir    string            // текущий каталог шелла
+	Vars   map[string]string // переменные шелла: cd меняет PWD, export - список переменных
+	Env    map[string]string // окружение запускаемых процессов
+	Paths  *PathCache        // кэш поиска программ по PATH, nil - искать каждый раз
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Interrupted - код возврата команды, прерванной отменой контекста,
+// как у процесса, завершенного SIGINT.
+const Interrupted = 128 + int(syscall.SIGINT)
+
+// Path считает относительный путь от текущего каталога шелла.
+func (ctx *Context) Path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(ctx.Dir, name)
+}
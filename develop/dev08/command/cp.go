@@ -0,0 +1,138 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errIsDir = errors.New("is a directory (not copied, use -r)")
+
+// Copy - cp [-r] src... dst: если dst - каталог, файлы копируются в него.
+// С -r каталоги копируются целиком, символические ссылки внутри них
+// копируются как ссылки. Права файлов сохраняются.
+type Copy struct{}
+
+func (cd *Copy) Run(ctx *Context, args []string) int {
+	opts, args, err := parseOptions(args, "rR", "")
+	if err == nil && len(args) < 2 {
+		err = fmt.Errorf("missing destination operand")
+	}
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "cp: %v\nusage: cp [-r] source ... target\n", err)
+		return 1
+	}
+	_, r := opts['r']
+	_, R := opts['R']
+
+	return transfer(ctx, "cp", args, func(src, dst string) error {
+		return copyPath(src, dst, r || R, true)
+	})
+}
+
+// transfer выполняет op для каждого источника cp и mv: src dst или
+// src... dir.
+func transfer(ctx *Context, name string, args []string, op func(src, dst string) error) int {
+	srcs, target := args[:len(args)-1], args[len(args)-1]
+	dst := ctx.Path(target)
+
+	info, err := os.Stat(dst)
+	isDir := err == nil && info.IsDir()
+	if len(srcs) > 1 && !isDir {
+		fmt.Fprintf(ctx.Stderr, "%s: %s: not a directory\n", name, target)
+		return 1
+	}
+
+	status := 0
+	for _, src := range srcs {
+		path := filepath.Clean(ctx.Path(src))
+		to := dst
+		if isDir {
+			to = filepath.Join(dst, filepath.Base(path))
+		}
+
+		if err := op(path, to); err != nil {
+			pathError(ctx.Stderr, name, src, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// copyPath копирует файл или, с recursive, каталог. Ссылка, переданная
+// в аргументах (top), копируется как файл, на который она указывает.
+func copyPath(src, dst string, recursive, top bool) error {
+	stat := os.Lstat
+	if top {
+		stat = os.Stat
+	}
+	info, err := stat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.IsDir():
+		if !recursive {
+			return errIsDir
+		}
+		if rel, err := filepath.Rel(src, dst); err == nil && (rel == "." || !strings.HasPrefix(rel, "..")) {
+			return fmt.Errorf("cannot copy a directory into itself")
+		}
+		return copyDir(src, dst, info.Mode())
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		os.Remove(dst)
+		return os.Symlink(link, dst)
+	}
+
+	return copyFile(src, dst, info)
+}
+
+func copyDir(src, dst string, mode os.FileMode) error {
+	if err := os.Mkdir(dst, mode.Perm()|0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), true, false); err != nil {
+			return err
+		}
+	}
+
+	// права выставляются в конце: каталог мог быть только для чтения
+	return os.Chmod(dst, mode.Perm())
+}
+
+func copyFile(src, dst string, info os.FileInfo) error {
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(info, dstInfo) {
+		return fmt.Errorf("is the same file as %s", dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCopyMove(t *testing.T) {
+	tests := []struct {
+		cmd      Runner
+		args     []string
+		files    []string
+		exitCode int
+	}{
+		{&Copy{}, []string{"a"}, []string{"a", "d/", "d/f", "d/s/", "d/s/g"}, 1},
+		{&Copy{}, []string{"a", "b"}, []string{"a", "b", "d/", "d/f", "d/s/", "d/s/g"}, 0},
+		{&Copy{}, []string{"a", "d"}, []string{"a", "d/", "d/a", "d/f", "d/s/", "d/s/g"}, 0},
+		{&Copy{}, []string{"a", "d/f", "d/s"}, []string{"a", "d/", "d/f", "d/s/", "d/s/a", "d/s/f", "d/s/g"}, 0},
+		{&Copy{}, []string{"a", "d/f", "b"}, []string{"a", "d/", "d/f", "d/s/", "d/s/g"}, 1},
+		{&Copy{}, []string{"d", "e"}, []string{"a", "d/", "d/f", "d/s/", "d/s/g"}, 1},
+		{&Copy{}, []string{"-r", "d", "e"}, []string{"a", "d/", "d/f", "d/s/", "d/s/g", "e/", "e/f", "e/s/", "e/s/g"}, 0},
+		{&Copy{}, []string{"-R", "d/s", "."}, []string{"a", "d/", "d/f", "d/s/", "d/s/g", "s/", "s/g"}, 0},
+		{&Copy{}, []string{"-r", "d", "d/s"}, []string{"a", "d/", "d/f", "d/s/", "d/s/g"}, 1},
+		{&Copy{}, []string{"a", "."}, []string{"a", "d/", "d/f", "d/s/", "d/s/g"}, 1},
+		{&Move{}, []string{"a", "b"}, []string{"b", "d/", "d/f", "d/s/", "d/s/g"}, 0},
+		{&Move{}, []string{"a", "d/s"}, []string{"d/", "d/f", "d/s/", "d/s/a", "d/s/g"}, 0},
+		{&Move{}, []string{"d", "e"}, []string{"a", "e/", "e/f", "e/s/", "e/s/g"}, 0},
+		{&Move{}, []string{"a", "d/f", "x"}, []string{"a", "d/", "d/f", "d/s/", "d/s/g"}, 1},
+		{&Move{}, []string{"missing", "x"}, []string{"a", "d/", "d/f", "d/s/", "d/s/g"}, 1},
+	}
+
+	for idx, test := range tests {
+		root := t.TempDir()
+		WriteFiles(t, root, map[string]string{"a": "A", "d/f": "F", "d/s/g": "G"})
+
+		if code := test.cmd.Run(NewContext(map[string]string{"PWD": root}, nil, io.Discard, io.Discard), test.args); code != test.exitCode {
+			t.Errorf("for %d expected exit(%d), got %d", idx, test.exitCode, code)
+		}
+		if files := ListFiles(t, root); !reflect.DeepEqual(files, test.files) {
+			t.Errorf("for %d expected files %v, got %v", idx, test.files, files)
+		}
+	}
+}
+
+func TestCopyContent(t *testing.T) {
+	root := t.TempDir()
+	WriteFiles(t, root, map[string]string{"d/f": "content", "b": "old content"})
+	os.Chmod(filepath.Join(root, "d", "f"), 0750)
+	os.Symlink("f", filepath.Join(root, "d", "link"))
+
+	vars := map[string]string{"PWD": root}
+	if code := (&Copy{}).Run(NewContext(vars, nil, io.Discard, io.Discard), []string{"-r", "d", "e"}); code != 0 {
+		t.Fatalf("expected exit(0), got %d", code)
+	}
+	if code := (&Copy{}).Run(NewContext(vars, nil, io.Discard, io.Discard), []string{"d/link", "b"}); code != 0 {
+		t.Fatalf("expected exit(0), got %d", code)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(root, "e", "f")); string(data) != "content" {
+		t.Errorf("unexpected copied content %q", data)
+	}
+	if info, err := os.Stat(filepath.Join(root, "e", "f")); err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("mode is not preserved: %v %v", info, err)
+	}
+	// ссылка внутри каталога копируется как ссылка, в аргументах - как файл
+	if link, err := os.Readlink(filepath.Join(root, "e", "link")); err != nil || link != "f" {
+		t.Errorf("expected link to f, got %q %v", link, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "b")); string(data) != "content" {
+		t.Errorf("unexpected overwritten content %q", data)
+	}
+}
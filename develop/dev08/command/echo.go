@@ -2,18 +2,17 @@ package command
 
 import (
 	"fmt"
-	"io"
 )
 
 type Echo struct{}
 
-func (cd *Echo) Run(args []string, vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+func (cd *Echo) Run(ctx *Context, args []string) int {
 
 	for idx, arg := range args {
 		if idx != 0 {
-			fmt.Fprint(stdout, " ")
+			fmt.Fprint(ctx.Stdout, " ")
 		}
-		fmt.Fprint(stdout, arg)
+		fmt.Fprint(ctx.Stdout, arg)
 	}
 
 	return 0
@@ -4,32 +4,71 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 )
 
+// Exec запускает внешнюю программу с окружением ctx.Env: программа
+// ищется по PATH из него (через кэш ctx.Paths) и запускается в каталоге
+// ctx.Dir. При отмене контекста процесс убивается.
 type Exec struct{}
 
-func (cd *Exec) Run(args []string, vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+func (cd *Exec) Run(ctx *Context, args []string) int {
+	cmd, code := cd.Command(ctx, args)
+	if cmd == nil {
+		return code
+	}
+	if err := cmd.Start(); err != nil {
+		return StartError(err, ctx.Stderr)
+	}
+	return Wait(cmd, ctx.Stderr)
+}
 
+// Command готовит запуск программы, но не запускает ее: шелл задает
+// группу процессов и запускает ее сам. Если программа не найдена,
+// возвращает nil и код возврата.
+func (cd *Exec) Command(ctx *Context, args []string) (*exec.Cmd, int) {
 	if len(args) == 0 {
-		fmt.Fprintln(stderr, "exec: empty call")
-		return 1
+		fmt.Fprintln(ctx.Stderr, "exec: empty call")
+		return nil, 1
 	}
 
-	path, err := exec.LookPath(args[0])
+	path, err := ctx.Paths.Lookup(args[0], ctx.Env["PATH"], ctx.Dir)
 	if err != nil {
-		fmt.Fprintln(stderr, "exec: command not found: ", err)
-		return 1
+		fmt.Fprintln(ctx.Stderr, "exec: command not found: ", err)
+		return nil, 127
 	}
 
-	cmd := exec.Command(path, args[1:]...)
-	cmd.Stdin = stdin
-	cmd.Stdout = stdout
-	cmd.Stderr = stderr
-	// fmt.Println("RUN", path, stdin, stdout)
-	err = cmd.Run()
-	// fmt.Println("EXIT", path)
+	cmd := exec.CommandContext(ctx, path, args[1:]...)
+	cmd.Dir = ctx.Dir
+	cmd.Env = []string{}
+	for name, value := range ctx.Env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	sort.Strings(cmd.Env)
+	cmd.Stdin = ctx.Stdin
+	cmd.Stdout = ctx.Stdout
+	cmd.Stderr = ctx.Stderr
+	return cmd, 0
+}
+
+// StartError сообщает об ошибке запуска и возвращает код возврата 126.
+func StartError(err error, stderr io.Writer) int {
+	fmt.Fprintln(stderr, "exec: command error: ", err)
+	return 126
+}
+
+// Wait ждет завершения запущенного процесса и возвращает код возврата.
+// Для процесса, убитого сигналом N, код - 128+N, как в sh.
+func Wait(cmd *exec.Cmd, stderr io.Writer) int {
+	err := cmd.Wait()
 	if err != nil {
 		if exit, ok := err.(*exec.ExitError); ok {
+			if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+				return 128 + int(status.Signal())
+			}
 			return exit.ExitCode()
 		}
 
@@ -39,3 +78,25 @@ func (cd *Exec) Run(args []string, vars map[string]string, stdin io.Reader, stdo
 
 	return 0
 }
+
+// LookPath ищет программу в каталогах pathList, относительные пути
+// считаются от cwd. Имя с / ищется без PATH.
+func LookPath(file, pathList, cwd string) (string, error) {
+	if strings.Contains(file, "/") {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(cwd, file)
+		}
+		return exec.LookPath(file)
+	}
+
+	for _, dir := range filepath.SplitList(pathList) {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(cwd, dir)
+		}
+		if path, err := exec.LookPath(filepath.Join(dir, file)); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// parseOptions разбирает флаги вида -la до первого аргумента или "--".
+// valued - флаги со значением: -n 5 или -n5. Одиночный "-" - аргумент.
+func parseOptions(args []string, flags, valued string) (map[byte]string, []string, error) {
+	opts := map[byte]string{}
+
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' {
+		arg := args[0]
+		args = args[1:]
+		if arg == "--" {
+			break
+		}
+
+		for i := 1; i < len(arg); i++ {
+			c := arg[i]
+			switch {
+			case strings.IndexByte(flags, c) >= 0:
+				opts[c] = ""
+			case strings.IndexByte(valued, c) >= 0:
+				value := arg[i+1:]
+				if value == "" {
+					if len(args) == 0 {
+						return nil, nil, fmt.Errorf("option requires an argument -- %c", c)
+					}
+					value, args = args[0], args[1:]
+				}
+				opts[c] = value
+				i = len(arg)
+			default:
+				return nil, nil, fmt.Errorf("invalid option -- %c", c)
+			}
+		}
+	}
+
+	return opts, args, nil
+}
+
+// pathError печатает ошибку файла с путем в том виде, в котором его
+// передал пользователь.
+func pathError(stderr io.Writer, cmd, name string, err error) {
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	} else if errors.As(err, &linkErr) {
+		err = linkErr.Err
+	}
+	fmt.Fprintf(stderr, "%s: %s: %v\n", cmd, name, err)
+}
+
+// openInput открывает файл для чтения, "-" - stdin.
+func openInput(ctx *Context, name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(ctx.Stdin), nil
+	}
+
+	f, err := os.Open(ctx.Path(name))
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return f, nil
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type Runner interface {
+	Run(ctx *Context, args []string) int
+}
+
+// NewContext создает контекст команды с текущим каталогом vars["PWD"].
+func NewContext(vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) *Context {
+	return &Context{Context: context.Background(), Dir: vars["PWD"], Vars: vars, Env: vars, Stdin: stdin, Stdout: stdout, Stderr: stderr}
+}
+
+// WriteFiles создает файлы в root: имя, оканчивающееся на /, - каталог.
+func WriteFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ListFiles возвращает пути всех файлов и каталогов в root, каталоги с /.
+func ListFiles(t *testing.T, root string) []string {
+	files := []string{}
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if info.IsDir() {
+			rel += "/"
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		args []string
+		opts map[byte]string
+		rest []string
+		err  bool
+	}{
+		{[]string{}, map[byte]string{}, []string{}, false},
+		{[]string{"-la", "x"}, map[byte]string{'l': "", 'a': ""}, []string{"x"}, false},
+		{[]string{"-l", "-n", "5", "x", "-a"}, map[byte]string{'l': "", 'n': "5"}, []string{"x", "-a"}, false},
+		{[]string{"-ln5"}, map[byte]string{'l': "", 'n': "5"}, []string{}, false},
+		{[]string{"--", "-l"}, map[byte]string{}, []string{"-l"}, false},
+		{[]string{"-", "-l"}, map[byte]string{}, []string{"-", "-l"}, false},
+		{[]string{"-x"}, nil, nil, true},
+		{[]string{"-n"}, nil, nil, true},
+	}
+
+	for _, test := range tests {
+		opts, rest, err := parseOptions(test.args, "la", "n")
+		if (err != nil) != test.err {
+			t.Errorf("%v: unexpected error %v", test.args, err)
+			continue
+		}
+		if !test.err && (!reflect.DeepEqual(opts, test.opts) || !reflect.DeepEqual(rest, test.rest)) {
+			t.Errorf("%v: expected %v %v, got %v %v", test.args, test.opts, test.rest, opts, rest)
+		}
+	}
+}
+
+func TestCat(t *testing.T) {
+	root := t.TempDir()
+	WriteFiles(t, root, map[string]string{"a": "first\n", "dir/b": "second"})
+
+	tests := []struct {
+		args     []string
+		stdout   string
+		exitCode int
+	}{
+		{[]string{}, "input", 0},
+		{[]string{"a"}, "first\n", 0},
+		{[]string{"a", "-", "dir/b"}, "first\ninputsecond", 0},
+		{[]string{filepath.Join(root, "dir", "b")}, "second", 0},
+		{[]string{"missing", "a"}, "first\n", 1},
+		{[]string{"dir"}, "", 1},
+	}
+
+	for _, test := range tests {
+		stdout := &bytes.Buffer{}
+		code := (&Cat{}).Run(NewContext(map[string]string{"PWD": root}, strings.NewReader("input"), stdout, io.Discard), test.args)
+		if code != test.exitCode || stdout.String() != test.stdout {
+			t.Errorf("%v: expected exit(%d) %q, got exit(%d) %q", test.args, test.exitCode, test.stdout, code, stdout.String())
+		}
+	}
+}
+
+func TestMakeDirRemove(t *testing.T) {
+	tests := []struct {
+		cmd      Runner
+		args     []string
+		files    []string
+		exitCode int
+	}{
+		{&MakeDir{}, []string{}, []string{"a", "d/", "d/f"}, 1},
+		{&MakeDir{}, []string{"x", "y"}, []string{"a", "d/", "d/f", "x/", "y/"}, 0},
+		{&MakeDir{}, []string{"d"}, []string{"a", "d/", "d/f"}, 1},
+		{&MakeDir{}, []string{"x/y"}, []string{"a", "d/", "d/f"}, 1},
+		{&MakeDir{}, []string{"-p", "d", "x/y"}, []string{"a", "d/", "d/f", "x/", "x/y/"}, 0},
+		{&Remove{}, []string{}, []string{"a", "d/", "d/f"}, 1},
+		{&Remove{}, []string{"a"}, []string{"d/", "d/f"}, 0},
+		{&Remove{}, []string{"d", "a"}, []string{"d/", "d/f"}, 1},
+		{&Remove{}, []string{"missing"}, []string{"a", "d/", "d/f"}, 1},
+		{&Remove{}, []string{"-f", "missing", "a"}, []string{"d/", "d/f"}, 0},
+		{&Remove{}, []string{"-f"}, []string{"a", "d/", "d/f"}, 0},
+		{&Remove{}, []string{"-r", "d"}, []string{"a"}, 0},
+		{&Remove{}, []string{"-rf", "d/..", ".", "/"}, []string{"a", "d/", "d/f"}, 1},
+		{&Remove{}, []string{"-R", "d/f", "d/"}, []string{"a"}, 0},
+	}
+
+	for idx, test := range tests {
+		root := t.TempDir()
+		WriteFiles(t, root, map[string]string{"a": "", "d/f": ""})
+
+		if code := test.cmd.Run(NewContext(map[string]string{"PWD": root}, nil, io.Discard, io.Discard), test.args); code != test.exitCode {
+			t.Errorf("for %d expected exit(%d), got %d", idx, test.exitCode, code)
+		}
+		if files := ListFiles(t, root); !reflect.DeepEqual(files, test.files) {
+			t.Errorf("for %d expected files %v, got %v", idx, test.files, files)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package command
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PathCache запоминает, где найдены программы (hash в sh), чтобы не
+// обходить PATH при каждом запуске. Кэш сбрасывается, когда меняется PATH,
+// а запомненный путь, который перестал быть исполняемым, ищется заново.
+// Один кэш может использоваться конвейером из нескольких горутин.
+type PathCache struct {
+	mu       sync.Mutex
+	pathList string // PATH, для которого собран кэш
+	entries  map[string]*PathEntry
+}
+
+type PathEntry struct {
+	Name string
+	Path string
+	Hits int // сколько раз программа запускалась по этой записи
+}
+
+func NewPathCache() *PathCache {
+	return &PathCache{entries: map[string]*PathEntry{}}
+}
+
+// Lookup ищет программу для запуска и учитывает его в Hits. Имена с / и
+// PATH с относительными каталогами не кэшируются: результат зависит от
+// текущего каталога. nil-кэш просто ищет программу.
+func (c *PathCache) Lookup(file, pathList, cwd string) (string, error) {
+	return c.find(file, pathList, cwd, 1)
+}
+
+// Remember ищет программу и запоминает ее, не считая запуском (hash name).
+func (c *PathCache) Remember(file, pathList, cwd string) (string, error) {
+	return c.find(file, pathList, cwd, 0)
+}
+
+func (c *PathCache) find(file, pathList, cwd string, hits int) (string, error) {
+	if c == nil || strings.Contains(file, "/") || !absolutePath(pathList) {
+		return LookPath(file, pathList, cwd)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.check(pathList)
+
+	if entry, ok := c.entries[file]; ok {
+		if _, err := exec.LookPath(entry.Path); err == nil {
+			entry.Hits += hits
+			return entry.Path, nil
+		}
+		delete(c.entries, file)
+	}
+
+	path, err := LookPath(file, pathList, cwd)
+	if err != nil {
+		return "", err
+	}
+	c.entries[file] = &PathEntry{Name: file, Path: path, Hits: hits}
+	return path, nil
+}
+
+// Hashed возвращает запомненный путь программы.
+func (c *PathCache) Hashed(file, pathList string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.check(pathList)
+
+	entry, ok := c.entries[file]
+	if !ok {
+		return "", false
+	}
+	return entry.Path, true
+}
+
+// Entries возвращает копии записей, отсортированные по имени.
+func (c *PathCache) Entries(pathList string) []PathEntry {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.check(pathList)
+
+	entries := []PathEntry{}
+	for _, entry := range c.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Reset забывает все пути (hash -r).
+func (c *PathCache) Reset() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*PathEntry{}
+}
+
+// check сбрасывает кэш, собранный для другого PATH. Вызывается под mu.
+func (c *PathCache) check(pathList string) {
+	if c.pathList != pathList {
+		c.pathList = pathList
+		c.entries = map[string]*PathEntry{}
+	}
+}
+
+func absolutePath(pathList string) bool {
+	for _, dir := range filepath.SplitList(pathList) {
+		if !filepath.IsAbs(dir) {
+			return false
+		}
+	}
+	return true
+}
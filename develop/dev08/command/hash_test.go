@@ -0,0 +1,57 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathCache(t *testing.T) {
+	root := t.TempDir()
+	WriteFiles(t, root, map[string]string{"a/prog": "", "b/prog": "", "b/other": ""})
+	for _, name := range []string{"a/prog", "b/prog", "b/other"} {
+		os.Chmod(filepath.Join(root, name), 0755)
+	}
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	pathList := a + string(filepath.ListSeparator) + b
+
+	cache := NewPathCache()
+	if path, err := cache.Lookup("prog", pathList, root); err != nil || path != filepath.Join(a, "prog") {
+		t.Fatalf("unexpected lookup %q %v", path, err)
+	}
+	cache.Lookup("prog", pathList, root)
+	cache.Remember("other", pathList, root)
+
+	entries := cache.Entries(pathList)
+	if len(entries) != 2 || entries[0].Name != "other" || entries[0].Hits != 0 || entries[1].Hits != 2 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	// запомненная программа удалена: ищется заново
+	os.Remove(filepath.Join(a, "prog"))
+	if path, err := cache.Lookup("prog", pathList, root); err != nil || path != filepath.Join(b, "prog") {
+		t.Errorf("expected %s after removal, got %q %v", filepath.Join(b, "prog"), path, err)
+	}
+
+	// новый PATH сбрасывает кэш
+	if _, ok := cache.Hashed("other", b); ok {
+		t.Error("cache is not reset after PATH change")
+	}
+	if _, err := cache.Lookup("missing", b, root); err == nil {
+		t.Error("expected error for missing program")
+	}
+
+	// относительный каталог в PATH: результат зависит от cwd, не кэшируется
+	if path, err := cache.Lookup("prog", "b", root); err != nil || path != filepath.Join(b, "prog") {
+		t.Errorf("unexpected relative lookup %q %v", path, err)
+	}
+	if entries := cache.Entries("b"); len(entries) != 0 {
+		t.Errorf("relative PATH is cached: %+v", entries)
+	}
+
+	cache.Lookup("prog", b, root)
+	cache.Reset()
+	if entries := cache.Entries(b); len(entries) != 0 {
+		t.Errorf("expected empty cache after reset, got %+v", entries)
+	}
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Head - head [-n N] [file...]: первые N строк (10 по умолчанию).
+type Head struct{}
+
+func (cd *Head) Run(ctx *Context, args []string) int {
+	opts, args, err := parseOptions(args, "", "n")
+	lines := 10
+	if value, ok := opts['n']; ok && err == nil {
+		if lines, err = strconv.Atoi(value); err != nil || lines < 0 {
+			err = fmt.Errorf("invalid number of lines: %s", value)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "head: %v\nusage: head [-n lines] [file ...]\n", err)
+		return 1
+	}
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	status := 0
+	for idx, name := range args {
+		in, err := openInput(ctx, name)
+		if err != nil {
+			pathError(ctx.Stderr, "head", name, err)
+			status = 1
+			continue
+		}
+
+		if len(args) > 1 {
+			fileHeader(ctx.Stdout, name, idx == 0)
+		}
+		err = copyLines(ctx.Stdout, bufio.NewReader(in), lines)
+		in.Close()
+		if err != nil {
+			pathError(ctx.Stderr, "head", name, err)
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// fileHeader отделяет вывод нескольких файлов в head и tail.
+func fileHeader(stdout io.Writer, name string, first bool) {
+	if !first {
+		fmt.Fprintln(stdout)
+	}
+	if name == "-" {
+		name = "standard input"
+	}
+	fmt.Fprintf(stdout, "==> %s <==\n", name)
+}
+
+func copyLines(stdout io.Writer, in *bufio.Reader, n int) error {
+	for i := 0; i < n; i++ {
+		line, err := in.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, err := stdout.Write(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
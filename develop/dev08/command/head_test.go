@@ -0,0 +1,140 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHeadTail(t *testing.T) {
+	root := t.TempDir()
+	lines := ""
+	for i := 1; i <= 12; i++ {
+		lines += strings.Repeat("x", i) + "\n"
+	}
+	WriteFiles(t, root, map[string]string{"lines": lines, "short": "a\nb", "empty": ""})
+
+	tests := []struct {
+		cmd      Runner
+		args     []string
+		stdout   string
+		exitCode int
+	}{
+		{&Head{}, []string{"lines"}, strings.Join(strings.SplitAfter(lines, "\n")[:10], ""), 0},
+		{&Head{}, []string{"-n", "2", "lines"}, "x\nxx\n", 0},
+		{&Head{}, []string{"-n0", "lines"}, "", 0},
+		{&Head{}, []string{"-n", "5", "short"}, "a\nb", 0},
+		{&Head{}, []string{"-n", "1"}, "in\n", 0},
+		{&Head{}, []string{"-n", "1", "short", "-", "empty"}, "==> short <==\na\n\n==> standard input <==\nin\n\n==> empty <==\n", 0},
+		{&Head{}, []string{"-n", "x"}, "", 1},
+		{&Head{}, []string{"missing"}, "", 1},
+		{&Tail{}, []string{"lines"}, strings.Join(strings.SplitAfter(lines, "\n")[2:], ""), 0},
+		{&Tail{}, []string{"-n", "2", "lines"}, strings.Repeat("x", 11) + "\n" + strings.Repeat("x", 12) + "\n", 0},
+		{&Tail{}, []string{"-n", "+12", "lines"}, strings.Repeat("x", 12) + "\n", 0},
+		{&Tail{}, []string{"-n", "+1", "short"}, "a\nb", 0},
+		{&Tail{}, []string{"-n", "1", "short"}, "b", 0},
+		{&Tail{}, []string{"-n0", "short"}, "", 0},
+		{&Tail{}, []string{"-n", "1", "empty", "-"}, "==> empty <==\n\n==> standard input <==\nout\n", 0},
+		{&Tail{}, []string{"-n", "-1"}, "", 1},
+	}
+
+	for _, test := range tests {
+		stdout := &bytes.Buffer{}
+		code := test.cmd.Run(NewContext(map[string]string{"PWD": root}, strings.NewReader("in\nout\n"), stdout, io.Discard), test.args)
+		if code != test.exitCode || stdout.String() != test.stdout {
+			t.Errorf("%T %v: expected exit(%d) %q, got exit(%d) %q", test.cmd, test.args, test.exitCode, test.stdout, code, stdout.String())
+		}
+	}
+}
+
+// LimitWriter принимает n байт, затем возвращает ошибку, как закрытый канал.
+type LimitWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	n   int
+}
+
+func (w *LimitWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() >= w.n {
+		return 0, errors.New("closed")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *LimitWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestTailFollow(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "log")
+	WriteFiles(t, root, map[string]string{"log": "one\ntwo\n"})
+
+	stdout := &LimitWriter{n: len("two\nthree\nfour\n")}
+	status := make(chan int, 1)
+	go func() {
+		status <- (&Tail{Poll: 10 * time.Millisecond}).Run(NewContext(map[string]string{"PWD": root}, nil, stdout, io.Discard), []string{"-f", "-n", "1", "log"})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("three\n")
+	time.Sleep(50 * time.Millisecond)
+	f.WriteString("four\n")
+	f.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	// вывод уже не принимается: следующая запись завершает tail
+	os.WriteFile(path, []byte("five\n"), 0644)
+
+	select {
+	case code := <-status:
+		if code != 0 {
+			t.Errorf("expected exit(0), got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tail -f is not finished")
+	}
+	if stdout.String() != "two\nthree\nfour\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestTailFollowCancel(t *testing.T) {
+	root := t.TempDir()
+	WriteFiles(t, root, map[string]string{"log": "one\n"})
+
+	ctx := NewContext(map[string]string{"PWD": root}, nil, io.Discard, io.Discard)
+	cancelCtx, cancel := context.WithCancel(ctx.Context)
+	ctx.Context = cancelCtx
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	status := make(chan int, 1)
+	go func() {
+		status <- (&Tail{Poll: 10 * time.Millisecond}).Run(ctx, []string{"-f", "log"})
+	}()
+
+	select {
+	case code := <-status:
+		if code != Interrupted {
+			t.Errorf("expected exit(%d), got %d", Interrupted, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tail -f is not cancelled")
+	}
+}
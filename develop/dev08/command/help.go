@@ -2,24 +2,67 @@ package command
 
 import (
 	"fmt"
-	"io"
 )
 
 type Help struct{}
 
 var message string = `Hello from go-shell! You can use:
   help                  - show this message
-  exit                  - exit shell :(
+  exit [N]              - exit shell :(
+  Tab / Up, Down / ^R   - complete, browse history, search history
+  ^C / ^Z               - interrupt or stop foreground command
   cd [path]             - change directory
   pwd                   - current directory
   echo [...args]        - prints to stdout args
-  kill <pid>            - kill process by id
+  kill [-SIG] <pid|%N>  - send signal (TERM by default) to processes or job
+  ps [-p pid,...]       - list processes (--ppid, -C name, -s state filters)
+  ls [-alR] [path...]   - list directory (long format, hidden files, recursive)
+  cat [file...]         - print files or stdin
+  head, tail [-n N]     - first or last N lines (tail -f follows file)
+  wc [-lwc] [file...]   - count lines, words, bytes
+  mkdir [-p] <dir...>   - create directories
+  rm [-rf] <path...>    - remove files (-r directories)
+  cp [-r] <src..> <dst> - copy files (-r directories)
+  mv <src..> <dst>      - move or rename files
+  nc [-u] host port     - send stdin to tcp/udp connection (-l listen, -z scan, -w secs)
+  jobs                  - list background jobs
+  fg [%N]               - wait for job in foreground
+  bg [%N]               - resume stopped job in background
+  wait [%N]             - wait for background jobs
+  export [NAME[=value]] - export variable to child processes
+  unset NAME            - remove variable
+  set [-- args]         - list variables or set $1, $2, ...
+  shift [N]             - drop first N positional parameters
+  source <file> [args]  - run <file> in current shell (also ". <file>")
+  alias [name=value]    - define or list aliases (unalias name)
+  type, which <name>    - show how name is resolved: alias, function, builtin or path
+  hash [-r] [name...]   - remembered program paths (-r forgets them)
+  true, false, :        - succeed or fail
+  test <expr>           - check files (-f -d ...), strings, numbers (also "[ <expr> ]")
   <any PATH executable> - execute file from PATH
   <cmd1> | <cmd2>       - pipe <cmd1> stdout to <cmd2> stdin
-  <cmd1> &              - run <cmd1> in background`
+  <cmd1> &              - run <cmd1> in background
+  <cmd1> ; <cmd2>       - run <cmd1>, then <cmd2>
+  <cmd1> && <cmd2>      - run <cmd2> if <cmd1> succeeded
+  <cmd1> || <cmd2>      - run <cmd2> if <cmd1> failed
+  ( <cmds> )            - run <cmds> in subshell
+  <cmd1> > <file>       - write stdout to <file> (>> appends)
+  <cmd1> < <file>       - read stdin from <file>
+  <cmd1> 2> <file>      - write stderr to <file>, 2>&1 joins it with stdout
+  <cmd1> <<EOF          - read stdin from following lines until EOF
+  $VAR ${VAR:-default}  - variable value, $? $$ $! are special
+  $(<cmds>)             - output of <cmds>
+  *.go ? [a-z] **/x     - file names matching pattern
+  ~ ~user {a,b} {1..5}  - home directory, alternatives, sequence
+  NAME=value [cmd]      - set variable (only for cmd, if given)
+  if <cmds>; then <cmds>; [elif ...;] [else <cmds>;] fi
+  while|until <cmds>; do <cmds>; done (break [N], continue [N])
+  for NAME [in <words>]; do <cmds>; done
+  case <word> in <pattern>[|...]) <cmds> ;; ... esac
+  name() { <cmds>; }    - define function (local NAME[=value], return [N])`
 
-func (cd *Help) Run(args []string, vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
-	fmt.Fprint(stdout, message)
+func (cd *Help) Run(ctx *Context, args []string) int {
+	fmt.Fprint(ctx.Stdout, message)
 
 	return 0
 }
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// waitInput ждет, пока из f можно читать, и возвращает false, если раньше
+// закрылся stop. Builtin не может прервать блокирующее чтение терминала,
+// поэтому читает только когда данные уже есть.
+func waitInput(f *os.File, stop <-chan struct{}) bool {
+	raw, err := f.SyscallConn()
+	if err != nil {
+		return true
+	}
+
+	for {
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+
+		ready := true
+		raw.Control(func(fd uintptr) {
+			set := syscall.FdSet{}
+			bits := uintptr(8 * unsafe.Sizeof(set.Bits[0]))
+			if fd/bits >= uintptr(len(set.Bits)) {
+				return
+			}
+			set.Bits[fd/bits] |= 1 << (fd % bits)
+
+			timeout := syscall.NsecToTimeval(int64(100 * time.Millisecond))
+			n, err := syscall.Select(int(fd)+1, &set, nil, nil, &timeout)
+			ready = n > 0 || (err != nil && err != syscall.EINTR)
+		})
+		if ready {
+			return true
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package command
+
+import "os"
+
+func waitInput(f *os.File, stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return false
+	default:
+		return true
+	}
+}
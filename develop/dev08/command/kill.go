@@ -2,37 +2,105 @@ package command
 
 import (
 	"fmt"
-	"io"
-	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"syscall"
 )
 
-type Kill struct{}
+// ParseSignal принимает имя (TERM, SIGTERM, term) или номер сигнала.
+func ParseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil && n >= 0 {
+		return syscall.Signal(n), nil
+	}
+
+	sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("%s: invalid signal specification", name)
+	}
+	return sig, nil
+}
+
+// Kill посылает сигнал процессам: kill [-s SIG | -SIG | -N] pid|%job...
+// Отрицательный pid - группа процессов. Jobs переводит спецификацию
+// задачи в pid ее процессов, без него %job не поддерживается.
+type Kill struct {
+	Jobs func(spec string) ([]int, error)
+}
 
-func (cd *Kill) Run(args []string, vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+func (cd *Kill) Run(ctx *Context, args []string) int {
+	sig := syscall.SIGTERM
+
+	if len(args) > 0 && args[0] == "-l" {
+		names := []string{}
+		for name, sig := range signals {
+			names = append(names, fmt.Sprintf("%2d) SIG%s", int(sig), name))
+		}
+		sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+		fmt.Fprintln(ctx.Stdout, strings.Join(names, "\n"))
+		return 0
+	}
+
+	if len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "--" {
+		name := args[0][1:]
+		args = args[1:]
+
+		if name == "s" || name == "n" {
+			if len(args) == 0 {
+				fmt.Fprintln(ctx.Stderr, "kill: -s: option requires an argument")
+				return 1
+			}
+			name = args[0]
+			args = args[1:]
+		}
+
+		var err error
+		if sig, err = ParseSignal(name); err != nil {
+			fmt.Fprintln(ctx.Stderr, "kill:", err)
+			return 1
+		}
+	}
+
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
 
 	if len(args) == 0 {
-		fmt.Fprintln(stderr, "kill: not enough arguments")
+		fmt.Fprintln(ctx.Stderr, "kill: not enough arguments")
 		return 1
 	}
 
-	pid, err := strconv.ParseInt(args[0], 0, 0)
-	if err != nil {
-		fmt.Fprintln(stderr, "kill:", err)
-		return 1
+	status := 0
+	for _, target := range args {
+		pids, err := cd.resolve(target)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "kill:", err)
+			status = 1
+			continue
+		}
+
+		for _, pid := range pids {
+			if err := SendSignal(pid, sig); err != nil {
+				fmt.Fprintf(ctx.Stderr, "kill: (%d) - %v\n", pid, err)
+				status = 1
+			}
+		}
 	}
 
-	process, err := os.FindProcess(int(pid))
-	if err != nil {
-		fmt.Fprintln(stderr, "kill:", err)
-		return 1
+	return status
+}
+
+func (cd *Kill) resolve(target string) ([]int, error) {
+	if strings.HasPrefix(target, "%") {
+		if cd.Jobs == nil {
+			return nil, fmt.Errorf("%s: no job control", target)
+		}
+		return cd.Jobs(target)
 	}
 
-	err = process.Kill()
+	pid, err := strconv.Atoi(target)
 	if err != nil {
-		fmt.Fprintln(stderr, "kill:", err)
-		return 1
+		return nil, fmt.Errorf("%s: arguments must be process or job IDs", target)
 	}
-
-	return 0
+	return []int{pid}, nil
 }
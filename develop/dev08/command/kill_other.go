@@ -0,0 +1,33 @@
+//go:build !unix
+
+package command
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// без Unix-сигналов процесс можно только завершить
+var signals = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+// SendSignal без Unix-сигналов только завершает процесс.
+func SendSignal(pid int, sig syscall.Signal) error {
+	if sig == 0 {
+		_, err := os.FindProcess(pid)
+		return err
+	}
+	if sig != syscall.SIGKILL && sig != syscall.SIGTERM && sig != syscall.SIGINT {
+		return errors.New("signal not supported")
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
@@ -0,0 +1,90 @@
+//go:build unix
+
+package command
+
+import (
+	"io"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name string
+		sig  syscall.Signal
+		err  bool
+	}{
+		{"TERM", syscall.SIGTERM, false},
+		{"SIGKILL", syscall.SIGKILL, false},
+		{"int", syscall.SIGINT, false},
+		{"9", syscall.SIGKILL, false},
+		{"0", 0, false},
+		{"FOO", 0, true},
+		{"-1", 0, true},
+	}
+
+	for _, test := range tests {
+		sig, err := ParseSignal(test.name)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if sig != test.sig {
+			t.Errorf("%s: expected %v, got %v", test.name, test.sig, sig)
+		}
+	}
+}
+
+func TestKill(t *testing.T) {
+	tests := []struct {
+		args     []string
+		signal   syscall.Signal
+		exitCode int
+	}{
+		{[]string{}, 0, 1},
+		{[]string{"-FOO"}, 0, 1},
+		{[]string{"%1"}, 0, 1},
+		{[]string{"abc"}, 0, 1},
+		{nil, syscall.SIGTERM, 0},
+		{[]string{"-9"}, syscall.SIGKILL, 0},
+		{[]string{"-s", "INT"}, syscall.SIGINT, 0},
+		{[]string{"-SIGUSR1", "--"}, syscall.SIGUSR1, 0},
+	}
+
+	for idx, test := range tests {
+		if test.signal == 0 {
+			if code := (&Kill{}).Run(NewContext(nil, nil, io.Discard, io.Discard), test.args); code != test.exitCode {
+				t.Errorf("for %d expected exit(%d), got %d", idx, test.exitCode, code)
+			}
+			continue
+		}
+
+		// два процесса: kill принимает несколько pid
+		cmds := []*exec.Cmd{exec.Command("sleep", "10"), exec.Command("sleep", "10")}
+		args := append([]string{}, test.args...)
+		for _, cmd := range cmds {
+			if err := cmd.Start(); err != nil {
+				t.Skip(err)
+			}
+			args = append(args, strconv.Itoa(cmd.Process.Pid))
+		}
+
+		if code := (&Kill{}).Run(NewContext(nil, nil, io.Discard, io.Discard), args); code != test.exitCode {
+			t.Errorf("for %d expected exit(%d), got %d", idx, test.exitCode, code)
+		}
+
+		for _, cmd := range cmds {
+			err := cmd.Wait()
+			exit, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Errorf("for %d expected signaled process, got %v", idx, err)
+				continue
+			}
+			if status := exit.Sys().(syscall.WaitStatus); status.Signal() != test.signal {
+				t.Errorf("for %d expected %v, got %v", idx, test.signal, status.Signal())
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ls - ls [-alR1] [file...]: по имени в строке. -a показывает скрытые
+// файлы, -l - права, владельца, размер и время изменения, -R выводит
+// подкаталоги рекурсивно.
+type Ls struct{}
+
+type lsEntry struct {
+	name string
+	path string
+	info os.FileInfo
+}
+
+type lister struct {
+	all       bool
+	long      bool
+	recursive bool
+	stdout    io.Writer
+	stderr    io.Writer
+
+	now     time.Time
+	printed bool
+	users   map[uint32]string
+	groups  map[uint32]string
+}
+
+func (cd *Ls) Run(ctx *Context, args []string) int {
+	opts, args, err := parseOptions(args, "alR1", "")
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "ls: %v\nusage: ls [-alR1] [file ...]\n", err)
+		return 1
+	}
+
+	l := &lister{stdout: ctx.Stdout, stderr: ctx.Stderr, now: time.Now(), users: map[uint32]string{}, groups: map[uint32]string{}}
+	_, l.all = opts['a']
+	_, l.long = opts['l']
+	_, l.recursive = opts['R']
+
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	status := 0
+	files, dirs := []lsEntry{}, []lsEntry{}
+	for _, name := range args {
+		path := ctx.Path(name)
+		info, err := os.Lstat(path)
+		// ссылка на каталог в аргументах раскрывается, кроме ls -l
+		if err == nil && info.Mode()&os.ModeSymlink != 0 && !l.long {
+			if target, err := os.Stat(path); err == nil {
+				info = target
+			}
+		}
+		if err != nil {
+			pathError(ctx.Stderr, "ls", name, err)
+			status = 1
+			continue
+		}
+
+		if info.IsDir() {
+			dirs = append(dirs, lsEntry{name, path, info})
+		} else {
+			files = append(files, lsEntry{name, path, info})
+		}
+	}
+
+	sortEntries(files)
+	sortEntries(dirs)
+	l.print(files)
+
+	for _, dir := range dirs {
+		if len(args) > 1 || l.recursive {
+			l.header(dir.name)
+		}
+		if !l.listDir(dir.name, dir.path) {
+			status = 1
+		}
+	}
+
+	return status
+}
+
+func sortEntries(entries []lsEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+}
+
+func (l *lister) header(name string) {
+	if l.printed {
+		fmt.Fprintln(l.stdout)
+	}
+	fmt.Fprintf(l.stdout, "%s:\n", name)
+	l.printed = true
+}
+
+// listDir выводит содержимое каталога: name - как показывать его путь,
+// path - путь в файловой системе.
+func (l *lister) listDir(name, path string) bool {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		pathError(l.stderr, "ls", name, err)
+		return false
+	}
+
+	entries := []lsEntry{}
+	if l.all {
+		for _, special := range []string{".", ".."} {
+			if info, err := os.Lstat(filepath.Join(path, special)); err == nil {
+				entries = append(entries, lsEntry{special, filepath.Join(path, special), info})
+			}
+		}
+	}
+	for _, entry := range dirEntries {
+		if strings.HasPrefix(entry.Name(), ".") && !l.all {
+			continue
+		}
+		// файл мог быть удален во время чтения каталога
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, lsEntry{entry.Name(), filepath.Join(path, entry.Name()), info})
+	}
+	sortEntries(entries)
+	l.print(entries)
+
+	ok := true
+	if l.recursive {
+		for _, entry := range entries {
+			if !entry.info.IsDir() || entry.name == "." || entry.name == ".." {
+				continue
+			}
+
+			sub := strings.TrimSuffix(name, "/") + "/" + entry.name
+			l.header(sub)
+			ok = l.listDir(sub, entry.path) && ok
+		}
+	}
+	return ok
+}
+
+func (l *lister) print(entries []lsEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	l.printed = true
+
+	if !l.long {
+		for _, entry := range entries {
+			fmt.Fprintln(l.stdout, entry.name)
+		}
+		return
+	}
+
+	rows := [][]string{}
+	widths := make([]int, 6)
+	for _, entry := range entries {
+		row := l.columns(entry)
+		for i := range widths {
+			if len(row[i]) > widths[i] {
+				widths[i] = len(row[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	for _, row := range rows {
+		fmt.Fprintf(l.stdout, "%s %*s %-*s %-*s %*s %s %s\n",
+			row[0], widths[1], row[1], widths[2], row[2], widths[3], row[3], widths[4], row[4], row[5], row[6])
+	}
+}
+
+// columns возвращает поля строки ls -l: права, число ссылок, владелец,
+// группа, размер, время изменения и имя.
+func (l *lister) columns(entry lsEntry) []string {
+	info := entry.info
+	links, owner, group := "1", "?", "?"
+	if nlink, uid, gid, ok := fileOwner(info); ok {
+		links = strconv.FormatUint(nlink, 10)
+		owner = l.lookup(l.users, uid, func(id string) string {
+			if u, err := user.LookupId(id); err == nil {
+				return u.Username
+			}
+			return id
+		})
+		group = l.lookup(l.groups, gid, func(id string) string {
+			if g, err := user.LookupGroupId(id); err == nil {
+				return g.Name
+			}
+			return id
+		})
+	}
+
+	modTime := info.ModTime()
+	layout := "Jan _2 15:04"
+	if modTime.Before(l.now.AddDate(0, -6, 0)) || modTime.After(l.now.Add(time.Hour)) {
+		layout = "Jan _2  2006"
+	}
+
+	name := entry.name
+	if info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Readlink(entry.path); err == nil {
+			name += " -> " + target
+		}
+	}
+
+	return []string{modeString(info.Mode()), links, owner, group, strconv.FormatInt(info.Size(), 10), modTime.Format(layout), name}
+}
+
+func (l *lister) lookup(cache map[uint32]string, id uint32, find func(id string) string) string {
+	name, ok := cache[id]
+	if !ok {
+		name = find(strconv.FormatUint(uint64(id), 10))
+		cache[id] = name
+	}
+	return name
+}
+
+// modeString - права в виде drwxr-xr-x, как у ls. FileMode.String
+// обозначает типы файлов иначе: L для ссылок, D для устройств.
+func modeString(mode os.FileMode) string {
+	kind := byte('-')
+	switch {
+	case mode&os.ModeDir != 0:
+		kind = 'd'
+	case mode&os.ModeSymlink != 0:
+		kind = 'l'
+	case mode&os.ModeNamedPipe != 0:
+		kind = 'p'
+	case mode&os.ModeSocket != 0:
+		kind = 's'
+	case mode&os.ModeCharDevice != 0:
+		kind = 'c'
+	case mode&os.ModeDevice != 0:
+		kind = 'b'
+	}
+
+	const rwx = "rwxrwxrwx"
+	b := []byte{kind}
+	for i := 0; i < 9; i++ {
+		if mode&(1<<uint(8-i)) != 0 {
+			b = append(b, rwx[i])
+		} else {
+			b = append(b, '-')
+		}
+	}
+
+	special := []struct {
+		bit      os.FileMode
+		pos      int
+		set, off byte
+	}{
+		{os.ModeSetuid, 3, 's', 'S'},
+		{os.ModeSetgid, 6, 's', 'S'},
+		{os.ModeSticky, 9, 't', 'T'},
+	}
+	for _, s := range special {
+		if mode&s.bit == 0 {
+			continue
+		}
+		if b[s.pos] == '-' {
+			b[s.pos] = s.off
+		} else {
+			b[s.pos] = s.set
+		}
+	}
+
+	return string(b)
+}
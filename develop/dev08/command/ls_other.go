@@ -0,0 +1,10 @@
+//go:build !unix
+
+package command
+
+import "os"
+
+// fileOwner - без Unix владелец файла неизвестен, ls -l пишет "?".
+func fileOwner(info os.FileInfo) (nlink uint64, uid, gid uint32, ok bool) {
+	return 0, 0, 0, false
+}
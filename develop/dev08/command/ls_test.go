@@ -0,0 +1,95 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLs(t *testing.T) {
+	root := t.TempDir()
+	WriteFiles(t, root, map[string]string{"b": "", "a": "", ".hidden": "", "d/f": "", "d/s/g": "", "e/": ""})
+	os.Symlink("d", filepath.Join(root, "link"))
+
+	tests := []struct {
+		args     []string
+		stdout   string
+		exitCode int
+	}{
+		{[]string{}, "a\nb\nd\ne\nlink\n", 0},
+		{[]string{"-a", "d"}, ".\n..\nf\ns\n", 0},
+		{[]string{"-a", "e"}, ".\n..\n", 0},
+		{[]string{"b", "a"}, "a\nb\n", 0},
+		{[]string{"link"}, "f\ns\n", 0},
+		{[]string{"e", "a", "d"}, "a\n\nd:\nf\ns\n\ne:\n", 0},
+		{[]string{"-R", "d"}, "d:\nf\ns\n\nd/s:\ng\n", 0},
+		{[]string{"-R1", "d/"}, "d/:\nf\ns\n\nd/s:\ng\n", 0},
+		{[]string{"missing", "a"}, "a\n", 1},
+		{[]string{"-x"}, "", 1},
+	}
+
+	for _, test := range tests {
+		stdout := &bytes.Buffer{}
+		code := (&Ls{}).Run(NewContext(map[string]string{"PWD": root}, nil, stdout, io.Discard), test.args)
+		if code != test.exitCode || stdout.String() != test.stdout {
+			t.Errorf("%v: expected exit(%d) %q, got exit(%d) %q", test.args, test.exitCode, test.stdout, code, stdout.String())
+		}
+	}
+}
+
+func TestLsLong(t *testing.T) {
+	root := t.TempDir()
+	WriteFiles(t, root, map[string]string{"small": "x", "large": strings.Repeat("x", 12345), "d/": ""})
+	os.Chmod(filepath.Join(root, "small"), 0640)
+	os.Symlink("small", filepath.Join(root, "link"))
+	old := time.Date(2001, time.February, 3, 4, 5, 0, 0, time.Local)
+	os.Chtimes(filepath.Join(root, "large"), old, old)
+
+	stdout := &bytes.Buffer{}
+	if code := (&Ls{}).Run(NewContext(map[string]string{"PWD": root}, nil, stdout, io.Discard), []string{"-l"}); code != 0 {
+		t.Fatalf("expected exit(0), got %d", code)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	expected := []string{
+		`^drwxr-xr-x +\d+ \S+ +\S+ +\d+ \w{3} [ \d]\d \d\d:\d\d d$`,
+		`^-rw-r--r-- +1 \S+ +\S+ 12345 Feb  3  2001 large$`,
+		`^lrwxrwxrwx +1 \S+ +\S+ +5 \w{3} [ \d]\d \d\d:\d\d link -> small$`,
+		`^-rw-r----- +1 \S+ +\S+ +1 \w{3} [ \d]\d \d\d:\d\d small$`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	for i, pattern := range expected {
+		if !regexp.MustCompile(pattern).MatchString(lines[i]) {
+			t.Errorf("line %q does not match %s", lines[i], pattern)
+		}
+	}
+}
+
+func TestModeString(t *testing.T) {
+	tests := []struct {
+		mode     os.FileMode
+		expected string
+	}{
+		{0644, "-rw-r--r--"},
+		{os.ModeDir | 0755, "drwxr-xr-x"},
+		{os.ModeSymlink | 0777, "lrwxrwxrwx"},
+		{os.ModeNamedPipe | 0600, "prw-------"},
+		{os.ModeDevice | os.ModeCharDevice | 0666, "crw-rw-rw-"},
+		{os.ModeSetuid | 0755, "-rwsr-xr-x"},
+		{os.ModeSetgid | 0740, "-rwxr-S---"},
+		{os.ModeDir | os.ModeSticky | 0777, "drwxrwxrwt"},
+	}
+
+	for _, test := range tests {
+		if s := modeString(test.mode); s != test.expected {
+			t.Errorf("%v: expected %s, got %s", test.mode, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package command
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner - число ссылок, владелец и группа файла.
+func fileOwner(info os.FileInfo) (nlink uint64, uid, gid uint32, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return uint64(stat.Nlink), stat.Uid, stat.Gid, true
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+	"os"
+)
+
+// MakeDir - mkdir [-p] dir...: с -p создает и родительские каталоги,
+// а существующий каталог не считается ошибкой.
+type MakeDir struct{}
+
+func (cd *MakeDir) Run(ctx *Context, args []string) int {
+	opts, args, err := parseOptions(args, "p", "")
+	if err == nil && len(args) == 0 {
+		err = fmt.Errorf("missing operand")
+	}
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "mkdir: %v\nusage: mkdir [-p] directory ...\n", err)
+		return 1
+	}
+	_, parents := opts['p']
+
+	status := 0
+	for _, name := range args {
+		path := ctx.Path(name)
+		if parents {
+			err = os.MkdirAll(path, 0777)
+		} else {
+			err = os.Mkdir(path, 0777)
+		}
+
+		if err != nil {
+			pathError(ctx.Stderr, "mkdir", name, err)
+			status = 1
+		}
+	}
+
+	return status
+}
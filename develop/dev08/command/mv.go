@@ -0,0 +1,35 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Move - mv src... dst. Между файловыми системами файлы копируются,
+// а затем удаляются.
+type Move struct{}
+
+func (cd *Move) Run(ctx *Context, args []string) int {
+	_, args, err := parseOptions(args, "", "")
+	if err == nil && len(args) < 2 {
+		err = fmt.Errorf("missing destination operand")
+	}
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "mv: %v\nusage: mv source ... target\n", err)
+		return 1
+	}
+
+	return transfer(ctx, "mv", args, func(src, dst string) error {
+		err := os.Rename(src, dst)
+		if !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+
+		if err := copyPath(src, dst, true, false); err != nil {
+			return err
+		}
+		return os.RemoveAll(src)
+	})
+}
@@ -0,0 +1,379 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const ncUsage = "usage: nc [-uv] [-w secs] host port\n       nc -l [-uv] [-w secs] [host] port\n       nc -z [-uv] [-w secs] host port[-port]..."
+
+// Netcat передает stdin в соединение tcp или udp (-u), а полученные
+// данные пишет в stdout: nc [-uv] [-w secs] host port. С -l ждет одно
+// входящее соединение, с -z только проверяет, открыты ли порты.
+// После конца stdin соединение tcp закрывается на запись, и nc дочитывает
+// ответ, пока его не закроет другая сторона. -w ограничивает время
+// подключения и ожидания данных после конца stdin.
+type Netcat struct{}
+
+type ncOptions struct {
+	network string
+	listen  bool
+	scan    bool
+	verbose bool
+	timeout time.Duration
+	args    []string
+}
+
+// ncConn - соединение tcp или udp, в том числе принятое в режиме -l.
+type ncConn interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+}
+
+func (cd *Netcat) Run(ctx *Context, args []string) int {
+	opts, err := parseNcArgs(args)
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "nc: %v\n%s\n", err, ncUsage)
+		return 1
+	}
+
+	if opts.scan {
+		return opts.scanPorts(ctx)
+	}
+
+	var conn ncConn
+	if opts.listen {
+		conn, err = opts.accept(ctx)
+	} else {
+		host, port := opts.args[0], opts.args[1]
+		dialer := net.Dialer{Timeout: opts.timeout}
+		conn, err = dialer.DialContext(ctx, opts.network, net.JoinHostPort(host, port))
+		if err == nil && opts.verbose {
+			fmt.Fprintf(ctx.Stderr, "Connection to %s %s port [%s] succeeded!\n", host, port, opts.network)
+		}
+	}
+	if ctx.Err() != nil {
+		return Interrupted
+	}
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "nc:", err)
+		return 1
+	}
+	defer conn.Close()
+	defer closeOnCancel(ctx, conn)()
+
+	err = opts.exchange(conn, ctx.Stdin, ctx.Stdout)
+	if ctx.Err() != nil {
+		return Interrupted
+	}
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "nc:", err)
+		return 1
+	}
+	return 0
+}
+
+func parseNcArgs(args []string) (ncOptions, error) {
+	opts := ncOptions{network: "tcp"}
+
+	flags, args, err := parseOptions(args, "ulzv", "w")
+	if err != nil {
+		return opts, err
+	}
+	for flag, value := range flags {
+		switch flag {
+		case 'u':
+			opts.network = "udp"
+		case 'l':
+			opts.listen = true
+		case 'z':
+			opts.scan = true
+		case 'v':
+			opts.verbose = true
+		case 'w':
+			secs, err := strconv.ParseFloat(value, 64)
+			if err != nil || secs <= 0 {
+				return opts, fmt.Errorf("invalid timeout %q", value)
+			}
+			opts.timeout = time.Duration(secs * float64(time.Second))
+		}
+	}
+
+	opts.args = args
+	switch {
+	case opts.listen && opts.scan:
+		return opts, fmt.Errorf("-l and -z can't be used together")
+	case opts.listen && len(args) != 1 && len(args) != 2:
+		return opts, fmt.Errorf("expected [host] port")
+	case opts.scan && len(args) < 2:
+		return opts, fmt.Errorf("expected host and ports")
+	case !opts.listen && !opts.scan && len(args) != 2:
+		return opts, fmt.Errorf("expected host and port")
+	}
+	return opts, nil
+}
+
+// accept ждет одно входящее соединение. Для udp соединением считается
+// адрес, с которого пришел первый пакет.
+func (opts ncOptions) accept(ctx *Context) (ncConn, error) {
+	host, port := "", opts.args[0]
+	if len(opts.args) == 2 {
+		host, port = opts.args[0], opts.args[1]
+	}
+	addr := net.JoinHostPort(host, port)
+
+	if opts.network == "udp" {
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		defer closeOnCancel(ctx, pc)()
+		if opts.verbose {
+			fmt.Fprintf(ctx.Stderr, "Listening on %s\n", pc.LocalAddr())
+		}
+
+		buf := make([]byte, 64*1024)
+		n, peer, err := pc.ReadFrom(buf)
+		if err != nil {
+			pc.Close()
+			return nil, err
+		}
+		if opts.verbose {
+			fmt.Fprintf(ctx.Stderr, "Connection received on %s\n", peer)
+		}
+		return &packetConn{PacketConn: pc, peer: peer, pending: buf[:n]}, nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+	defer closeOnCancel(ctx, ln)()
+	if opts.verbose {
+		fmt.Fprintf(ctx.Stderr, "Listening on %s\n", ln.Addr())
+	}
+
+	conn, err := ln.Accept()
+	if err == nil && opts.verbose {
+		fmt.Fprintf(ctx.Stderr, "Connection received on %s\n", conn.RemoteAddr())
+	}
+	return conn, err
+}
+
+// exchange передает stdin в conn, а ответ в stdout, пока другая сторона
+// не закроет соединение. У udp конца соединения нет: клиент без -w
+// завершается сразу после конца stdin, а с -w - когда ответы перестанут
+// приходить.
+func (opts ncOptions) exchange(conn ncConn, stdin io.Reader, stdout io.Writer) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var idle int32 // stdin закончился: включен таймаут ожидания данных
+	sent := make(chan error, 1)
+	go func() {
+		err := copyInput(conn, stdin, stop)
+		sent <- err
+		if err != nil {
+			return
+		}
+
+		if opts.timeout > 0 {
+			atomic.StoreInt32(&idle, 1)
+			conn.SetReadDeadline(time.Now().Add(opts.timeout))
+		}
+		if tcp, ok := conn.(interface{ CloseWrite() error }); ok {
+			tcp.CloseWrite()
+		} else if !opts.listen && opts.timeout == 0 {
+			conn.SetReadDeadline(time.Now())
+		}
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		if atomic.LoadInt32(&idle) != 0 {
+			conn.SetReadDeadline(time.Now().Add(opts.timeout))
+		}
+
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if _, err := stdout.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	select {
+	case err := <-sent:
+		return err
+	default:
+		return nil
+	}
+}
+
+// copyInput передает stdin в conn до конца stdin или закрытия stop.
+// Чтение файла ждет данных через waitInput: иначе после выхода nc
+// горутина осталась бы в чтении и забрала бы ввод следующей команды.
+func copyInput(conn io.Writer, stdin io.Reader, stop <-chan struct{}) error {
+	f, ok := stdin.(*os.File)
+	if !ok {
+		_, err := io.Copy(conn, stdin)
+		return err
+	}
+
+	buf := make([]byte, 32*1024)
+	for waitInput(f, stop) {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanPorts проверяет порты host: код возврата 0, если открыт хотя бы один.
+// С -v о каждом порте сообщается в stderr, как в nc.
+func (opts ncOptions) scanPorts(ctx *Context) int {
+	host := opts.args[0]
+	status := 1
+
+	for _, spec := range opts.args[1:] {
+		from, to, err := parsePorts(spec)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "nc:", err)
+			return 1
+		}
+
+		for port := from; port <= to; port++ {
+			if ctx.Err() != nil {
+				return Interrupted
+			}
+
+			err := probePort(ctx, opts.network, net.JoinHostPort(host, strconv.Itoa(port)), opts.timeout)
+			if err == nil {
+				status = 0
+			}
+			if !opts.verbose {
+				continue
+			}
+
+			if err == nil {
+				fmt.Fprintf(ctx.Stderr, "Connection to %s %d port [%s] succeeded!\n", host, port, opts.network)
+			} else {
+				var opErr *net.OpError
+				if errors.As(err, &opErr) {
+					err = opErr.Err
+				}
+				fmt.Fprintf(ctx.Stderr, "nc: connect to %s port %d (%s) failed: %v\n", host, port, opts.network, err)
+			}
+		}
+	}
+	return status
+}
+
+// probePort подключается к порту. У udp подключения нет: закрытый порт
+// виден по ICMP port unreachable, который приходит ошибкой чтения после
+// отправки пакета, а порт без ответа считается открытым.
+func probePort(ctx context.Context, network, addr string, timeout time.Duration) error {
+	if network == "udp" && timeout == 0 {
+		timeout = time.Second
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if network != "udp" {
+		return nil
+	}
+
+	if _, err := conn.Write([]byte("X")); err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// closeOnCancel закрывает c при отмене ctx: так прерываются блокирующие
+// чтение и ожидание соединения. Возвращенная функция снимает слежение.
+func closeOnCancel(ctx context.Context, c io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// parsePorts разбирает порт или диапазон портов from-to.
+func parsePorts(spec string) (int, int, error) {
+	first, last, isRange := strings.Cut(spec, "-")
+	from, err := strconv.Atoi(first)
+	to := from
+	if err == nil && isRange {
+		to, err = strconv.Atoi(last)
+	}
+
+	if err != nil || from < 1 || to > 65535 || from > to {
+		return 0, 0, fmt.Errorf("%s: invalid port range", spec)
+	}
+	return from, to, nil
+}
+
+// packetConn - соединение udp в режиме -l: пакеты с других адресов
+// отбрасываются, ответы отправляются на адрес первого пакета.
+type packetConn struct {
+	net.PacketConn
+	peer    net.Addr
+	pending []byte // первый пакет, полученный при ожидании соединения
+}
+
+func (c *packetConn) Read(p []byte) (int, error) {
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+
+	for {
+		n, addr, err := c.ReadFrom(p)
+		if err != nil || addr.String() == c.peer.String() {
+			return n, err
+		}
+	}
+}
+
+func (c *packetConn) Write(p []byte) (int, error) {
+	return c.WriteTo(p, c.peer)
+}
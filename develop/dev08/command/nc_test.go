@@ -0,0 +1,262 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNetcatArgs(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"localhost"},
+		{"-x", "localhost", "80"},
+		{"-w"},
+		{"-w", "abc", "localhost", "80"},
+		{"-lz", "80"},
+		{"-l", "localhost", "80", "81"},
+		{"-z", "localhost"},
+		{"-z", "localhost", "5-1"},
+		{"-z", "localhost", "0"},
+	}
+
+	for _, args := range tests {
+		stderr := &bytes.Buffer{}
+		if code := (&Netcat{}).Run(NewContext(nil, strings.NewReader(""), io.Discard, stderr), args); code != 1 {
+			t.Errorf("%v: expected exit(1), got %d", args, code)
+		}
+		if !strings.Contains(stderr.String(), "usage: nc") && !strings.Contains(stderr.String(), "invalid port") {
+			t.Errorf("%v: unexpected stderr %q", args, stderr.String())
+		}
+	}
+}
+
+func TestNetcatTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	// сервер отвечает, только получив весь ввод: nc должен закрыть
+	// соединение на запись после конца stdin
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		conn.Write(bytes.ToUpper(data))
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	stdout := &bytes.Buffer{}
+	code := (&Netcat{}).Run(NewContext(nil, strings.NewReader("hello\nworld\n"), stdout, io.Discard), []string{host, port})
+	if code != 0 {
+		t.Errorf("expected exit(0), got %d", code)
+	}
+	if stdout.String() != "HELLO\nWORLD\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	// порт закрыт
+	ln.Close()
+	if code := (&Netcat{}).Run(NewContext(nil, strings.NewReader(""), io.Discard, io.Discard), []string{"-w", "1", host, port}); code != 1 {
+		t.Errorf("expected exit(1) for closed port, got %d", code)
+	}
+}
+
+func TestNetcatUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(bytes.ToUpper(buf[:n]), addr)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(pc.LocalAddr().String())
+	stdout := &bytes.Buffer{}
+	code := (&Netcat{}).Run(NewContext(nil, strings.NewReader("ping"), stdout, io.Discard), []string{"-u", "-w", "0.5", host, port})
+	if code != 0 {
+		t.Errorf("expected exit(0), got %d", code)
+	}
+	if stdout.String() != "PING" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestNetcatListen(t *testing.T) {
+	for _, network := range []string{"tcp", "udp"} {
+		args := []string{"-lv", "-w", "0.5", "127.0.0.1", "0"}
+		if network == "udp" {
+			args[0] = "-luv"
+		}
+
+		stderr, stderrW := io.Pipe()
+		stdout := &bytes.Buffer{}
+		status := make(chan int, 1)
+		go func() {
+			status <- (&Netcat{}).Run(NewContext(nil, strings.NewReader("reply"), stdout, stderrW), args)
+			stderrW.Close()
+		}()
+
+		line, err := bufio.NewReader(stderr).ReadString('\n')
+		addr := strings.TrimSpace(strings.TrimPrefix(line, "Listening on "))
+		if err != nil || addr == line {
+			t.Fatalf("%s: unexpected stderr %q", network, line)
+		}
+		go io.Copy(io.Discard, stderr)
+
+		conn, err := net.Dial(network, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Write([]byte("request"))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, 1024)
+		n, _ := conn.Read(buf)
+		if string(buf[:n]) != "reply" {
+			t.Errorf("%s: client received %q", network, buf[:n])
+		}
+		conn.Close()
+
+		select {
+		case code := <-status:
+			if code != 0 {
+				t.Errorf("%s: expected exit(0), got %d", network, code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: nc is not finished", network)
+		}
+		if stdout.String() != "request" {
+			t.Errorf("%s: unexpected output %q", network, stdout.String())
+		}
+	}
+}
+
+func TestNetcatScan(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	_, open, _ := net.SplitHostPort(ln.Addr().String())
+
+	closedLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	_, closed, _ := net.SplitHostPort(closedLn.Addr().String())
+	closedLn.Close()
+
+	tests := []struct {
+		ports    []string
+		stderr   []string
+		exitCode int
+	}{
+		{[]string{open}, []string{"Connection to 127.0.0.1 " + open + " port [tcp] succeeded!"}, 0},
+		{[]string{closed}, []string{"nc: connect to 127.0.0.1 port " + closed + " (tcp) failed: connect: connection refused"}, 1},
+		{[]string{closed, open + "-" + open}, []string{"failed", "succeeded"}, 0},
+	}
+
+	for _, test := range tests {
+		stderr := &bytes.Buffer{}
+		args := append([]string{"-zv", "-w", "1", "127.0.0.1"}, test.ports...)
+		if code := (&Netcat{}).Run(NewContext(nil, nil, io.Discard, stderr), args); code != test.exitCode {
+			t.Errorf("%v: expected exit(%d), got %d", test.ports, test.exitCode, code)
+		}
+		for _, part := range test.stderr {
+			if !strings.Contains(stderr.String(), part) {
+				t.Errorf("%v: expected %q in stderr %q", test.ports, part, stderr.String())
+			}
+		}
+	}
+
+	// без -v ничего не выводится
+	stderr := &bytes.Buffer{}
+	port, _ := strconv.Atoi(open)
+	if code := (&Netcat{}).Run(NewContext(nil, nil, io.Discard, stderr), []string{"-z", "127.0.0.1", strconv.Itoa(port)}); code != 0 || stderr.Len() != 0 {
+		t.Errorf("expected silent exit(0), got %d %q", code, stderr.String())
+	}
+}
+
+// Сервер закрывает соединение, пока stdin открыт: nc завершается,
+// не забирая ввод, который придет позже.
+func TestNetcatLeavesInput(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("bye"))
+		conn.Close()
+	}()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	stdout := &bytes.Buffer{}
+	if code := (&Netcat{}).Run(NewContext(nil, r, stdout, io.Discard), []string{host, port}); code != 0 || stdout.String() != "bye" {
+		t.Errorf("expected exit(0) and %q, got %d %q", "bye", code, stdout.String())
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	w.Write([]byte("next"))
+	buf := make([]byte, 16)
+	n, _ := r.Read(buf)
+	if string(buf[:n]) != "next" {
+		t.Errorf("input is consumed by nc: read %q", buf[:n])
+	}
+}
+
+func TestNetcatCancel(t *testing.T) {
+	for _, args := range [][]string{{"-l", "127.0.0.1", "0"}, {"-lu", "127.0.0.1", "0"}} {
+		ctx := NewContext(nil, strings.NewReader(""), io.Discard, io.Discard)
+		cancelCtx, cancel := context.WithCancel(ctx.Context)
+		ctx.Context = cancelCtx
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		status := make(chan int, 1)
+		go func() {
+			status <- (&Netcat{}).Run(ctx, args)
+		}()
+
+		select {
+		case code := <-status:
+			if code != Interrupted {
+				t.Errorf("%v: expected exit(%d), got %d", args, Interrupted, code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%v: nc is not cancelled", args)
+		}
+	}
+}
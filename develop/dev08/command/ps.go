@@ -0,0 +1,174 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Process struct {
+	Pid     int
+	PPid    int
+	State   string
+	Comm    string
+	Cmdline []string
+}
+
+// Command возвращает командную строку процесса, для потоков ядра - [comm].
+func (p Process) Command() string {
+	if len(p.Cmdline) == 0 {
+		return "[" + p.Comm + "]"
+	}
+	return strings.Join(p.Cmdline, " ")
+}
+
+// ReadProcesses читает список процессов из procfs, смонтированной в root.
+// Процессы, завершившиеся во время чтения, пропускаются.
+func ReadProcesses(root string) ([]Process, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	procs := []Process{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		proc, err := ReadProcess(root, pid)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, proc)
+	}
+
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Pid < procs[j].Pid })
+	return procs, nil
+}
+
+// ReadProcess читает один процесс из procfs.
+func ReadProcess(root string, pid int) (Process, error) {
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return Process{}, err
+	}
+
+	// pid (comm) state ppid ... - comm может содержать пробелы и скобки
+	line := string(stat)
+	open := strings.IndexByte(line, '(')
+	closing := strings.LastIndexByte(line, ')')
+	if open < 0 || closing < open {
+		return Process{}, fmt.Errorf("%s: malformed stat", dir)
+	}
+
+	fields := strings.Fields(line[closing+1:])
+	if len(fields) < 2 {
+		return Process{}, fmt.Errorf("%s: malformed stat", dir)
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Process{}, fmt.Errorf("%s: malformed stat", dir)
+	}
+
+	proc := Process{Pid: pid, PPid: ppid, State: fields[0], Comm: line[open+1 : closing]}
+
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err == nil && len(cmdline) > 0 {
+		proc.Cmdline = strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	}
+
+	return proc, nil
+}
+
+// Ps печатает процессы: ps [-p pid,...] [--ppid pid,...] [-C name] [-s state].
+// Root - каталог procfs, по умолчанию /proc.
+type Ps struct {
+	Root string
+}
+
+func (cd *Ps) Run(ctx *Context, args []string) int {
+	filters := []func(Process) bool{}
+
+	for len(args) > 0 {
+		if len(args) < 2 {
+			fmt.Fprintf(ctx.Stderr, "ps: unknown option %s\nusage: ps [-p pid,...] [--ppid pid,...] [-C name] [-s state]\n", args[0])
+			return 1
+		}
+
+		opt, value := args[0], args[1]
+		args = args[2:]
+
+		switch opt {
+		case "-p", "--pid", "--ppid":
+			pids := map[int]bool{}
+			for _, item := range strings.Split(value, ",") {
+				pid, err := strconv.Atoi(item)
+				if err != nil {
+					fmt.Fprintf(ctx.Stderr, "ps: invalid process id %q\n", item)
+					return 1
+				}
+				pids[pid] = true
+			}
+
+			if opt == "--ppid" {
+				filters = append(filters, func(p Process) bool { return pids[p.PPid] })
+			} else {
+				filters = append(filters, func(p Process) bool { return pids[p.Pid] })
+			}
+		case "-C":
+			names := strings.Split(value, ",")
+			filters = append(filters, func(p Process) bool {
+				for _, name := range names {
+					if p.Comm == name {
+						return true
+					}
+				}
+				return false
+			})
+		case "-s":
+			filters = append(filters, func(p Process) bool { return strings.Contains(value, p.State) })
+		default:
+			fmt.Fprintf(ctx.Stderr, "ps: unknown option %s\nusage: ps [-p pid,...] [--ppid pid,...] [-C name] [-s state]\n", opt)
+			return 1
+		}
+	}
+
+	root := cd.Root
+	if root == "" {
+		root = "/proc"
+	}
+
+	procs, err := ReadProcesses(root)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "ps:", err)
+		return 1
+	}
+
+	fmt.Fprintf(ctx.Stdout, "%7s %7s S CMD\n", "PID", "PPID")
+	found := false
+	for _, proc := range procs {
+		matched := true
+		for _, filter := range filters {
+			matched = matched && filter(proc)
+		}
+		if !matched {
+			continue
+		}
+
+		found = true
+		fmt.Fprintf(ctx.Stdout, "%7d %7d %s %s\n", proc.Pid, proc.PPid, proc.State, proc.Command())
+	}
+
+	// как procps: ничего не нашлось - код 1
+	if !found {
+		return 1
+	}
+	return 0
+}
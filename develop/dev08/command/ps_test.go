@@ -0,0 +1,72 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func WriteProc(t *testing.T, root, pid, stat, cmdline string) {
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644)
+	os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644)
+}
+
+func TestPs(t *testing.T) {
+	root := t.TempDir()
+	WriteProc(t, root, "1", "1 (init) S 0 1 1 0", "/sbin/init\x00splash\x00")
+	WriteProc(t, root, "2", "2 (kthreadd) S 0 0 0 0", "")
+	WriteProc(t, root, "30", "30 (my (odd) name) R 1 30 30 0", "./odd\x00")
+	WriteProc(t, root, "12", "12 (bash) Z 1 12 12 0", "bash\x00")
+	os.MkdirAll(filepath.Join(root, "self"), 0755)
+	os.MkdirAll(filepath.Join(root, "99"), 0755)
+
+	tests := []struct {
+		args     []string
+		pids     []string
+		exitCode int
+	}{
+		{[]string{}, []string{"1", "2", "12", "30"}, 0},
+		{[]string{"-p", "2,30"}, []string{"2", "30"}, 0},
+		{[]string{"--ppid", "1"}, []string{"12", "30"}, 0},
+		{[]string{"-C", "bash"}, []string{"12"}, 0},
+		{[]string{"-C", "my (odd) name"}, []string{"30"}, 0},
+		{[]string{"-s", "RZ", "--ppid", "1"}, []string{"12", "30"}, 0},
+		{[]string{"-p", "5"}, []string{}, 1},
+		{[]string{"-p", "x"}, nil, 1},
+		{[]string{"-x"}, nil, 1},
+	}
+
+	for idx, test := range tests {
+		out := &bytes.Buffer{}
+		code := (&Ps{Root: root}).Run(NewContext(nil, nil, out, io.Discard), test.args)
+		if code != test.exitCode {
+			t.Errorf("for %d expected exit(%d), got %d", idx, test.exitCode, code)
+		}
+		if test.pids == nil {
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")[1:]
+		pids := []string{}
+		for _, line := range lines {
+			pids = append(pids, strings.Fields(line)[0])
+		}
+		if strings.Join(pids, ",") != strings.Join(test.pids, ",") {
+			t.Errorf("for %d expected pids %v, got %v", idx, test.pids, pids)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	(&Ps{Root: root}).Run(NewContext(nil, nil, out, io.Discard), []string{"-p", "1,2"})
+	expected := "    PID    PPID S CMD\n      1       0 S /sbin/init splash\n      2       0 S [kthreadd]\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -2,20 +2,13 @@ package command
 
 import (
 	"fmt"
-	"io"
-	"os"
 )
 
 type ProcessWD struct{}
 
-func (cd *ProcessWD) Run(args []string, vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+func (cd *ProcessWD) Run(ctx *Context, args []string) int {
 
-	path, err := os.Getwd()
-	if err != nil {
-		fmt.Fprintln(stderr, "pwd:", err)
-	}
-
-	fmt.Fprintln(stdout, path)
+	fmt.Fprintln(ctx.Stdout, ctx.Dir)
 
 	return 0
 }
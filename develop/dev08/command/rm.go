@@ -0,0 +1,63 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Remove - rm [-rf] file...: каталоги удаляются только с -r, -f молча
+// пропускает несуществующие файлы. ".", ".." и "/" не удаляются.
+type Remove struct{}
+
+func (cd *Remove) Run(ctx *Context, args []string) int {
+	opts, args, err := parseOptions(args, "rRf", "")
+	_, force := opts['f']
+	if err == nil && len(args) == 0 && !force {
+		err = fmt.Errorf("missing operand")
+	}
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "rm: %v\nusage: rm [-rf] file ...\n", err)
+		return 1
+	}
+	_, r := opts['r']
+	_, R := opts['R']
+	recursive := r || R
+
+	status := 0
+	for _, name := range args {
+		path := filepath.Clean(ctx.Path(name))
+		if base := filepath.Base(filepath.Clean(name)); base == "." || base == ".." || path == "/" {
+			fmt.Fprintf(ctx.Stderr, "rm: refusing to remove %s\n", name)
+			status = 1
+			continue
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			if !(force && os.IsNotExist(err)) {
+				pathError(ctx.Stderr, "rm", name, err)
+				status = 1
+			}
+			continue
+		}
+
+		if info.IsDir() && !recursive {
+			fmt.Fprintf(ctx.Stderr, "rm: %s: is a directory\n", name)
+			status = 1
+			continue
+		}
+
+		if recursive {
+			err = os.RemoveAll(path)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			pathError(ctx.Stderr, "rm", name, err)
+			status = 1
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,178 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tail - tail [-f] [-n N|+N] [file...]: последние N строк (10 по
+// умолчанию), с +N - начиная со строки N. С -f после вывода ждет новых
+// данных в файлах и выводит их, пока запись в stdout удается.
+type Tail struct {
+	Poll time.Duration // период проверки файлов для -f, по умолчанию 200ms
+}
+
+type tailFile struct {
+	name   string
+	file   *os.File
+	offset int64
+}
+
+func (cd *Tail) Run(ctx *Context, args []string) int {
+	opts, args, err := parseOptions(args, "f", "n")
+	lines, fromStart := 10, false
+	if value, ok := opts['n']; ok && err == nil {
+		fromStart = strings.HasPrefix(value, "+")
+		if lines, err = strconv.Atoi(strings.TrimPrefix(value, "+")); err != nil || lines < 0 {
+			err = fmt.Errorf("invalid number of lines: %s", value)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "tail: %v\nusage: tail [-f] [-n [+]lines] [file ...]\n", err)
+		return 1
+	}
+	_, follow := opts['f']
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	status := 0
+	followed := []*tailFile{}
+	for idx, name := range args {
+		in, err := openInput(ctx, name)
+		if err != nil {
+			pathError(ctx.Stderr, "tail", name, err)
+			status = 1
+			continue
+		}
+
+		if len(args) > 1 {
+			fileHeader(ctx.Stdout, name, idx == 0)
+		}
+		if err := tailLines(ctx.Stdout, bufio.NewReader(in), lines, fromStart); err != nil {
+			pathError(ctx.Stderr, "tail", name, err)
+			in.Close()
+			status = 1
+			continue
+		}
+
+		// stdin не отслеживается: конец канала - это конец данных
+		f, ok := in.(*os.File)
+		if !follow || !ok {
+			in.Close()
+			continue
+		}
+		offset, _ := f.Seek(0, io.SeekCurrent)
+		followed = append(followed, &tailFile{name: name, file: f, offset: offset})
+	}
+
+	if len(followed) > 0 {
+		if !cd.follow(ctx, followed, len(args) > 1) {
+			return Interrupted
+		}
+	}
+	return status
+}
+
+// tailLines выводит последние n строк или, с fromStart, все строки
+// начиная с n-й. in всегда дочитывается до конца.
+func tailLines(stdout io.Writer, in *bufio.Reader, n int, fromStart bool) error {
+	if fromStart {
+		for i := 1; i < n; i++ {
+			if _, err := in.ReadBytes('\n'); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+		_, err := io.Copy(stdout, in)
+		return err
+	}
+
+	if n == 0 {
+		_, err := io.Copy(io.Discard, in)
+		return err
+	}
+
+	ring := make([][]byte, n)
+	count := 0
+	for {
+		line, err := in.ReadBytes('\n')
+		if len(line) > 0 {
+			ring[count%n] = line
+			count++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	start := 0
+	if count > n {
+		start = count - n
+	}
+	for i := start; i < count; i++ {
+		if _, err := stdout.Write(ring[i%n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// follow выводит данные, дописанные в файлы, пока удается запись или
+// пока не отменен контекст: тогда возвращает false. Если файл стал
+// короче, он выводится заново с начала.
+func (cd *Tail) follow(ctx *Context, files []*tailFile, headers bool) bool {
+	poll := cd.Poll
+	if poll == 0 {
+		poll = 200 * time.Millisecond
+	}
+
+	defer func() {
+		for _, f := range files {
+			f.file.Close()
+		}
+	}()
+
+	last := files[len(files)-1].name
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(poll):
+		}
+
+		for _, f := range files {
+			info, err := f.file.Stat()
+			if err != nil {
+				continue
+			}
+			if info.Size() < f.offset {
+				fmt.Fprintf(ctx.Stderr, "tail: %s: file truncated\n", f.name)
+				f.file.Seek(0, io.SeekStart)
+				f.offset = 0
+			}
+			if info.Size() == f.offset {
+				continue
+			}
+
+			if headers && last != f.name {
+				fileHeader(ctx.Stdout, f.name, false)
+				last = f.name
+			}
+			n, err := io.Copy(ctx.Stdout, f.file)
+			f.offset += n
+			if err != nil {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Test - test expr и [ expr ] (Bracket). Возвращает 0, если выражение
+// истинно, 1 - если ложно, 2 - при ошибке. Пути считаются от vars["PWD"].
+type Test struct {
+	Bracket bool
+}
+
+func (cd *Test) Run(ctx *Context, args []string) int {
+	name := "test"
+	if cd.Bracket {
+		name = "["
+		if len(args) == 0 || args[len(args)-1] != "]" {
+			fmt.Fprintln(ctx.Stderr, "[: missing `]'")
+			return 2
+		}
+		args = args[:len(args)-1]
+	}
+
+	if len(args) == 0 {
+		return 1
+	}
+
+	t := &testExpr{args: args, ctx: ctx}
+	result, err := t.or()
+	if err == nil && t.pos < len(args) {
+		err = fmt.Errorf("%s: unexpected argument", args[t.pos])
+	}
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "%s: %v\n", name, err)
+		return 2
+	}
+
+	if result {
+		return 0
+	}
+	return 1
+}
+
+var unaryTests = map[string]bool{
+	"-n": true, "-z": true, "-e": true, "-f": true, "-d": true, "-s": true,
+	"-r": true, "-w": true, "-x": true, "-L": true, "-h": true,
+}
+
+var binaryTests = map[string]bool{
+	"=": true, "==": true, "!=": true, "<": true, ">": true,
+	"-eq": true, "-ne": true, "-lt": true, "-le": true, "-gt": true, "-ge": true,
+}
+
+// testExpr разбирает выражение рекурсивным спуском:
+//
+//	or      := and ('-o' and)*
+//	and     := not ('-a' not)*
+//	not     := '!' not | primary
+//	primary := '(' or ')' | UNARY ARG | ARG BINARY ARG | ARG
+type testExpr struct {
+	args []string
+	pos  int
+	ctx  *Context
+}
+
+func (t *testExpr) peek(offset int) (string, bool) {
+	if t.pos+offset < len(t.args) {
+		return t.args[t.pos+offset], true
+	}
+	return "", false
+}
+
+func (t *testExpr) or() (bool, error) {
+	result, err := t.and()
+	for err == nil {
+		if arg, _ := t.peek(0); arg != "-o" {
+			break
+		}
+		t.pos++
+
+		var next bool
+		next, err = t.and()
+		result = result || next
+	}
+	return result, err
+}
+
+func (t *testExpr) and() (bool, error) {
+	result, err := t.not()
+	for err == nil {
+		if arg, _ := t.peek(0); arg != "-a" {
+			break
+		}
+		t.pos++
+
+		var next bool
+		next, err = t.not()
+		result = result && next
+	}
+	return result, err
+}
+
+func (t *testExpr) not() (bool, error) {
+	arg, ok := t.peek(0)
+	// "!" без операнда - просто непустая строка
+	if _, hasNext := t.peek(1); ok && arg == "!" && hasNext {
+		t.pos++
+		result, err := t.not()
+		return !result, err
+	}
+	return t.primary()
+}
+
+func (t *testExpr) primary() (bool, error) {
+	arg, ok := t.peek(0)
+	if !ok {
+		return false, fmt.Errorf("argument expected")
+	}
+
+	if op, ok := t.peek(1); ok && binaryTests[op] {
+		right, ok := t.peek(2)
+		if !ok {
+			return false, fmt.Errorf("%s: argument expected", op)
+		}
+		t.pos += 3
+		return t.binary(arg, op, right)
+	}
+
+	if arg == "(" {
+		t.pos++
+		result, err := t.or()
+		if err != nil {
+			return false, err
+		}
+		if closing, _ := t.peek(0); closing != ")" {
+			return false, fmt.Errorf("`)' expected")
+		}
+		t.pos++
+		return result, nil
+	}
+
+	if operand, ok := t.peek(1); ok && unaryTests[arg] {
+		t.pos += 2
+		return t.unary(arg, operand), nil
+	}
+
+	t.pos++
+	return arg != "", nil
+}
+
+func (t *testExpr) unary(op, arg string) bool {
+	switch op {
+	case "-n":
+		return arg != ""
+	case "-z":
+		return arg == ""
+	}
+
+	path := t.ctx.Path(arg)
+
+	if op == "-L" || op == "-h" {
+		info, err := os.Lstat(path)
+		return err == nil && info.Mode()&os.ModeSymlink != 0
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	switch op {
+	case "-f":
+		return info.Mode().IsRegular()
+	case "-d":
+		return info.IsDir()
+	case "-s":
+		return info.Size() > 0
+	case "-r":
+		return access(path, info, 4)
+	case "-w":
+		return access(path, info, 2)
+	case "-x":
+		return access(path, info, 1)
+	}
+	return true
+}
+
+func (t *testExpr) binary(left, op, right string) (bool, error) {
+	switch op {
+	case "=", "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	case "<":
+		return left < right, nil
+	case ">":
+		return left > right, nil
+	}
+
+	a, err := strconv.ParseInt(strings.TrimSpace(left), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("%s: integer expression expected", left)
+	}
+	b, err := strconv.ParseInt(strings.TrimSpace(right), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("%s: integer expression expected", right)
+	}
+
+	switch op {
+	case "-eq":
+		return a == b, nil
+	case "-ne":
+		return a != b, nil
+	case "-lt":
+		return a < b, nil
+	case "-le":
+		return a <= b, nil
+	case "-gt":
+		return a > b, nil
+	default:
+		return a >= b, nil
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package command
+
+import "os"
+
+// access без Unix проверяет права файла для владельца.
+func access(path string, info os.FileInfo, mode uint32) bool {
+	return uint32(info.Mode().Perm()>>6)&mode != 0
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTest(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "file"), []byte("data"), 0644)
+	os.WriteFile(filepath.Join(dir, "empty"), nil, 0755)
+	os.Symlink("file", filepath.Join(dir, "link"))
+	vars := map[string]string{"PWD": dir}
+
+	tests := []struct {
+		args     []string
+		bracket  bool
+		exitCode int
+	}{
+		{[]string{}, false, 1},
+		{[]string{"abc"}, false, 0},
+		{[]string{""}, false, 1},
+		{[]string{"-n", ""}, false, 1},
+		{[]string{"-z", ""}, false, 0},
+		{[]string{"a", "=", "a"}, false, 0},
+		{[]string{"a", "!=", "a"}, false, 1},
+		{[]string{"a", "<", "b"}, false, 0},
+		{[]string{"10", "-gt", "9"}, false, 0},
+		{[]string{" 3", "-le", "2"}, false, 1},
+		{[]string{"x", "-eq", "1"}, false, 2},
+		{[]string{"!", "a", "=", "b"}, false, 0},
+		{[]string{"!"}, false, 0},
+		{[]string{"-f", "file", "-a", "-s", "file"}, false, 0},
+		{[]string{"-f", "empty", "-a", "-s", "empty"}, false, 1},
+		{[]string{"-d", "file", "-o", "-d", dir}, false, 0},
+		{[]string{"-e", "missing"}, false, 1},
+		{[]string{"-x", "empty"}, false, 0},
+		{[]string{"-L", "link"}, false, 0},
+		{[]string{"-L", "file"}, false, 1},
+		{[]string{"(", "a", "=", "b", "-o", "1", "-lt", "2", ")", "-a", "x"}, false, 0},
+		{[]string{"(", "a"}, false, 2},
+		{[]string{"a", "b"}, false, 2},
+		{[]string{"a", "=", "a", "]"}, true, 0},
+		{[]string{"]"}, true, 1},
+		{[]string{"a", "=", "a"}, true, 2},
+	}
+
+	for _, test := range tests {
+		if code := (&Test{Bracket: test.bracket}).Run(NewContext(vars, nil, io.Discard, io.Discard), test.args); code != test.exitCode {
+			t.Errorf("%q: expected exit(%d), got %d", test.args, test.exitCode, code)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package command
+
+import (
+	"os"
+	"syscall"
+)
+
+// access - доступен ли файл текущему пользователю: mode 4 - чтение,
+// 2 - запись, 1 - выполнение.
+func access(path string, info os.FileInfo, mode uint32) bool {
+	return syscall.Access(path, mode) == nil
+}
@@ -0,0 +1,10 @@
+package command
+
+// Status - команда, всегда возвращающая Code: true, false и :.
+type Status struct {
+	Code int
+}
+
+func (cd *Status) Run(ctx *Context, args []string) int {
+	return cd.Code
+}
@@ -0,0 +1,113 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// WordCount - wc [-lwc] [file...]: число строк, слов и байт, без флагов
+// все три. Для нескольких файлов выводится и итог.
+type WordCount struct{}
+
+func (cd *WordCount) Run(ctx *Context, args []string) int {
+	opts, args, err := parseOptions(args, "lwc", "")
+	if err != nil {
+		fmt.Fprintf(ctx.Stderr, "wc: %v\nusage: wc [-lwc] [file ...]\n", err)
+		return 1
+	}
+
+	selected := []byte{}
+	for _, flag := range []byte("lwc") {
+		if _, ok := opts[flag]; ok {
+			selected = append(selected, flag)
+		}
+	}
+	if len(selected) == 0 {
+		selected = []byte("lwc")
+	}
+
+	named := len(args) > 0
+	if !named {
+		args = []string{"-"}
+	}
+
+	// одно число для stdin выводится без выравнивания, как в coreutils
+	width := 7
+	if len(selected) == 1 && len(args) == 1 {
+		width = 1
+	}
+
+	status := 0
+	total := map[byte]int{}
+	for _, name := range args {
+		in, err := openInput(ctx, name)
+		if err != nil {
+			pathError(ctx.Stderr, "wc", name, err)
+			status = 1
+			continue
+		}
+
+		counts, err := countInput(in)
+		in.Close()
+		if err != nil {
+			pathError(ctx.Stderr, "wc", name, err)
+			status = 1
+			continue
+		}
+
+		for flag, n := range counts {
+			total[flag] += n
+		}
+		if !named {
+			name = ""
+		}
+		printCounts(ctx.Stdout, selected, counts, width, name)
+	}
+
+	if len(args) > 1 {
+		printCounts(ctx.Stdout, selected, total, width, "total")
+	}
+	return status
+}
+
+// countInput считает строки (l), слова (w) и байты (c).
+func countInput(in io.Reader) (map[byte]int, error) {
+	counts := map[byte]int{}
+	r := bufio.NewReader(in)
+	inWord := false
+
+	for {
+		c, size, err := r.ReadRune()
+		if err == io.EOF {
+			return counts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		counts['c'] += size
+		if c == '\n' {
+			counts['l']++
+		}
+		if unicode.IsSpace(c) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			counts['w']++
+		}
+	}
+}
+
+func printCounts(stdout io.Writer, selected []byte, counts map[byte]int, width int, name string) {
+	fields := []string{}
+	for _, flag := range selected {
+		fields = append(fields, fmt.Sprintf("%*d", width, counts[flag]))
+	}
+	if name != "" {
+		fields = append(fields, name)
+	}
+	fmt.Fprintln(stdout, strings.Join(fields, " "))
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWordCount(t *testing.T) {
+	root := t.TempDir()
+	WriteFiles(t, root, map[string]string{"a": "one two\nthree\n", "b": "  привет,  мир", "empty": ""})
+
+	tests := []struct {
+		args     []string
+		stdout   string
+		exitCode int
+	}{
+		{[]string{}, "      1       3      16\n", 0},
+		{[]string{"-l"}, "1\n", 0},
+		{[]string{"-l", "a"}, "2 a\n", 0},
+		{[]string{"-wc", "b"}, "      2      23 b\n", 0},
+		{[]string{"a", "b", "empty"}, "      2       3      14 a\n      0       2      23 b\n      0       0       0 empty\n      2       5      37 total\n", 0},
+		{[]string{"-l", "missing", "a"}, "      2 a\n      2 total\n", 1},
+		{[]string{"-x"}, "", 1},
+	}
+
+	for _, test := range tests {
+		stdout := &bytes.Buffer{}
+		code := (&WordCount{}).Run(NewContext(map[string]string{"PWD": root}, strings.NewReader("some input\n\ttext"), stdout, io.Discard), test.args)
+		if code != test.exitCode || stdout.String() != test.stdout {
+			t.Errorf("%v: expected exit(%d) %q, got exit(%d) %q", test.args, test.exitCode, test.stdout, code, stdout.String())
+		}
+	}
+}
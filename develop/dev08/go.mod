@@ -1,3 +1,3 @@
 module github.com/pgeowng/wb-l2/develop/dev08
 
-go 1.18
+go 1.19
@@ -0,0 +1,146 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// символы, недопустимые в имени алиаса
+const aliasSpecial = " \t\n;|&<>()'\"\\$`=/"
+
+// aliasCommand разбирает значение алиаса. Поддерживаются только простые
+// команды: слова, присваивания и перенаправления, без | ; && и т.п.
+func aliasCommand(value string) (*SimpleCommand, error) {
+	list, err := Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list.Items) == 1 && !list.Items[0].Background && len(list.Items[0].AndOr.Rest) == 0 {
+		if commands := list.Items[0].AndOr.First.Commands; len(commands) == 1 {
+			if cmd, ok := commands[0].(*SimpleCommand); ok {
+				return cmd, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("only simple commands are supported")
+}
+
+// expandAliases заменяет имя команды значением алиаса. Замена повторяется
+// для нового имени, но каждый алиас раскрывается один раз, как в sh:
+// alias ls='ls -F' не зацикливается. Имя в кавычках не раскрывается.
+func (sh *Shell) expandAliases(words []Word, redirects []*Redirect) ([]Word, []*Redirect) {
+	seen := map[string]bool{}
+	for {
+		idx := 0
+		for idx < len(words) {
+			if _, _, ok := splitAssignment(words[idx]); !ok {
+				break
+			}
+			idx++
+		}
+		if idx == len(words) || words[idx].Quoted() {
+			return words, redirects
+		}
+
+		name := words[idx].String()
+		value, ok := sh.Aliases[name]
+		if !ok || seen[name] {
+			return words, redirects
+		}
+		seen[name] = true
+
+		cmd, err := aliasCommand(value)
+		if err != nil {
+			return words, redirects
+		}
+
+		expanded := append([]Word{}, words[:idx]...)
+		expanded = append(expanded, cmd.Args...)
+		words = append(expanded, words[idx+1:]...)
+		redirects = append(append([]*Redirect{}, cmd.Redirects...), redirects...)
+	}
+}
+
+// Builtins для алиасов. Алиасы хранятся в шелле: подоболочка получает
+// их копию.
+
+type AliasCmd struct {
+	sh *Shell
+}
+
+// Без аргументов печатает все алиасы в виде, пригодном для повторного
+// выполнения, name - печатает один алиас, name=value - задает его.
+func (c *AliasCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) > 0 && args[0] == "-p" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		names := []string{}
+		for name := range c.sh.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(ctx.Stdout, "alias %s=%s\n", name, shellQuote(c.sh.Aliases[name]))
+		}
+		return 0
+	}
+
+	status := 0
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue {
+			if value, ok := c.sh.Aliases[name]; ok {
+				fmt.Fprintf(ctx.Stdout, "alias %s=%s\n", name, shellQuote(value))
+			} else {
+				fmt.Fprintf(ctx.Stderr, "alias: %s: not found\n", name)
+				status = 1
+			}
+			continue
+		}
+
+		if name == "" || strings.ContainsAny(name, aliasSpecial) {
+			fmt.Fprintf(ctx.Stderr, "alias: `%s': invalid alias name\n", name)
+			status = 1
+			continue
+		}
+		if _, err := aliasCommand(value); err != nil {
+			fmt.Fprintf(ctx.Stderr, "alias: %s: %v\n", name, err)
+			status = 1
+			continue
+		}
+		c.sh.Aliases[name] = value
+	}
+	return status
+}
+
+type UnaliasCmd struct {
+	sh *Shell
+}
+
+// unalias -a удаляет все алиасы.
+func (c *UnaliasCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) == 1 && args[0] == "-a" {
+		c.sh.Aliases = map[string]string{}
+		return 0
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(ctx.Stderr, "unalias: usage: unalias [-a] name [name ...]")
+		return 2
+	}
+
+	status := 0
+	for _, name := range args {
+		if _, ok := c.sh.Aliases[name]; !ok {
+			fmt.Fprintf(ctx.Stderr, "unalias: %s: not found\n", name)
+			status = 1
+			continue
+		}
+		delete(c.sh.Aliases, name)
+	}
+	return status
+}
@@ -0,0 +1,94 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+func TestAlias(t *testing.T) {
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{"alias say='echo said'; say hi", "said hi", 0},
+		{"alias say='echo said' e=echo; alias", "alias e='echo'\nalias say='echo said'\n", 0},
+		{"alias say=\"echo 'a  b'\"; alias say; say", "alias say='echo '\\''a  b'\\'''\na  b", 0},
+		{"alias say='X=1 echo'; say $X", "", 0},
+		{"alias out='echo x >/dev/null'; out y", "", 0},
+		// имя в кавычках и алиас внутри своего значения не раскрываются
+		{"alias echo='echo a'; echo b; 'echo' c", "a bc", 0},
+		{"alias a=b b=a; a", "", 127},
+		{"alias e=echo; (alias e=false); e ok", "ok", 0},
+		{"alias e=echo; unalias e; e", "", 127},
+		{"alias e=echo f=echo; unalias -a; alias", "", 0},
+		{"alias missing", "", 1},
+		{"unalias missing", "", 1},
+		{"alias 'a b=echo'", "", 1},
+		{"alias p='echo a | upper'", "", 1},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		out := &bytes.Buffer{}
+		status := sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if status != test.status || out.String() != test.stdout {
+			t.Errorf("%q: expected status %d and %q, got %d and %q", test.line, test.status, test.stdout, status, out.String())
+		}
+	}
+}
+
+func TestTypeHash(t *testing.T) {
+	bin := t.TempDir()
+	prog := filepath.Join(bin, "prog")
+	if err := os.WriteFile(prog, []byte("#!/bin/sh\necho prog\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{"alias ll='ls -l'; type ll", "ll is aliased to `ls -l'\n", 0},
+		{"f() { :; }; type f echo", "f is a function\necho is a shell builtin\n", 0},
+		{"type prog", "prog is " + prog + "\n", 0},
+		{"prog; type prog", "prog\nprog is hashed (" + prog + ")\n", 0},
+		{"type -t prog echo missing", "file\nbuiltin\n", 1},
+		{"type -p prog echo", prog + "\n", 0},
+		{"alias ll='ls -l'; f() { :; }; which ll f echo prog missing",
+			"ll: aliased to ls -l\nf: shell function\necho: shell built-in command\n" + prog + "\nmissing not found\n", 1},
+		{"hash", "", 0},
+		{"prog; prog >/dev/null; hash prog; hash", "prog\nhits\tcommand\n   2\t" + prog + "\n", 0},
+		{"hash prog; hash -r; hash", "", 0},
+		{"hash missing", "", 1},
+		// смена PATH сбрасывает кэш
+		{"hash prog; PATH=/; hash", "", 0},
+		{"hash prog; rm " + prog + "; prog", "", 127},
+	}
+
+	for _, test := range tests {
+		os.WriteFile(prog, []byte("#!/bin/sh\necho prog\n"), 0755)
+		sh, err := NewShell(map[string]string{"PWD": "/", "PATH": bin}, map[string]Command{
+			"echo": &command.Echo{},
+			"rm":   &command.Remove{},
+			"exec": &command.Exec{},
+			":":    &command.Status{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := &bytes.Buffer{}
+		status := sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if status != test.status || out.String() != test.stdout {
+			t.Errorf("%q: expected status %d and %q, got %d and %q", test.line, test.status, test.stdout, status, out.String())
+		}
+	}
+}
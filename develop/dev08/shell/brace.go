@@ -0,0 +1,211 @@
+package shell
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// braceItem - часть слова для раскрытия скобок: текст или один из
+// символов { , } вне кавычек (op).
+type braceItem struct {
+	part WordPart
+	op   byte
+}
+
+// braceExpand раскрывает {a,b,c} и последовательности {1..5}, {a..e},
+// {1..10..2} в слове. Скобки и запятые в кавычках, а также внутри ${...}
+// и $(...) не считаются. Слово без раскрытия возвращается как есть.
+func braceExpand(word Word) []Word {
+	items := braceItems(word)
+	hasOp := false
+	for _, item := range items {
+		hasOp = hasOp || item.op != 0
+	}
+	if !hasOp {
+		return []Word{word}
+	}
+
+	words := []Word{}
+	for _, expanded := range expandItems(items) {
+		w := Word{}
+		for _, item := range expanded {
+			if item.op != 0 {
+				w.add(string(item.op), Unquoted)
+			} else {
+				w.add(item.part.Text, item.part.Quote)
+			}
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+func braceItems(word Word) []braceItem {
+	items := []braceItem{}
+	for _, part := range word {
+		if part.Quote != Unquoted {
+			items = append(items, braceItem{part: part})
+			continue
+		}
+
+		text := part.Text
+		start := 0
+		for i := 0; i < len(text); i++ {
+			switch c := text[i]; {
+			case c == '$' && i+1 < len(text) && (text[i+1] == '{' || text[i+1] == '('):
+				lx := &Lexer{src: text[i:]}
+				raw, err := lx.dollar()
+				if err != nil {
+					i = len(text) - 1
+				} else {
+					i += len(raw) - 1
+				}
+			case c == '{' || c == ',' || c == '}':
+				if start < i {
+					items = append(items, braceItem{part: WordPart{Text: text[start:i]}})
+				}
+				items = append(items, braceItem{op: c})
+				start = i + 1
+			}
+		}
+		if start < len(text) {
+			items = append(items, braceItem{part: WordPart{Text: text[start:]}})
+		}
+	}
+	return items
+}
+
+// expandItems раскрывает первую подходящую группу скобок и рекурсивно
+// остальные. Скобки без запятых и последовательности остаются текстом.
+func expandItems(items []braceItem) [][]braceItem {
+	for open := range items {
+		if items[open].op != '{' {
+			continue
+		}
+
+		alts, closing := braceAlternatives(items, open)
+		if alts == nil {
+			continue
+		}
+
+		result := [][]braceItem{}
+		for _, alt := range alts {
+			combined := append(append(append([]braceItem{}, items[:open]...), alt...), items[closing+1:]...)
+			result = append(result, expandItems(combined)...)
+		}
+		return result
+	}
+	return [][]braceItem{items}
+}
+
+// braceAlternatives ищет закрывающую скобку для items[open] и возвращает
+// варианты: части между запятыми верхнего уровня или элементы
+// последовательности.
+func braceAlternatives(items []braceItem, open int) ([][]braceItem, int) {
+	depth := 0
+	commas := []int{}
+	for idx := open + 1; idx < len(items); idx++ {
+		switch items[idx].op {
+		case '{':
+			depth++
+		case ',':
+			if depth == 0 {
+				commas = append(commas, idx)
+			}
+		case '}':
+			if depth > 0 {
+				depth--
+				continue
+			}
+
+			if len(commas) == 0 {
+				seq := braceSequence(items[open+1 : idx])
+				if seq == nil {
+					return nil, 0
+				}
+				alts := [][]braceItem{}
+				for _, value := range seq {
+					alts = append(alts, []braceItem{{part: WordPart{Text: value}}})
+				}
+				return alts, idx
+			}
+
+			alts := [][]braceItem{}
+			start := open + 1
+			for _, comma := range append(commas, idx) {
+				alts = append(alts, items[start:comma])
+				start = comma + 1
+			}
+			return alts, idx
+		}
+	}
+	return nil, 0
+}
+
+// braceSequence раскрывает x..y[..step] для чисел и одиночных букв.
+// Числа с ведущими нулями дополняются нулями до одной ширины.
+func braceSequence(items []braceItem) []string {
+	if len(items) != 1 || items[0].part.Quote != Unquoted {
+		return nil
+	}
+
+	bounds := strings.Split(items[0].part.Text, "..")
+	if len(bounds) != 2 && len(bounds) != 3 {
+		return nil
+	}
+
+	step := 1
+	if len(bounds) == 3 {
+		var err error
+		if step, err = strconv.Atoi(bounds[2]); err != nil || step == 0 {
+			return nil
+		}
+		if step < 0 {
+			step = -step
+		}
+	}
+
+	from, errFrom := strconv.Atoi(bounds[0])
+	to, errTo := strconv.Atoi(bounds[1])
+	format := "%d"
+	if errFrom != nil || errTo != nil {
+		if len(bounds[0]) != 1 || len(bounds[1]) != 1 || !isLetter(bounds[0][0]) || !isLetter(bounds[1][0]) {
+			return nil
+		}
+		from, to = int(bounds[0][0]), int(bounds[1][0])
+		format = "%c"
+	} else if width := padWidth(bounds[0], bounds[1]); width > 0 {
+		format = "%0" + strconv.Itoa(width) + "d"
+	}
+
+	if from > to {
+		step = -step
+	}
+	values := []string{}
+	for value := from; (step > 0 && value <= to) || (step < 0 && value >= to); value += step {
+		values = append(values, fmt.Sprintf(format, value))
+	}
+	return values
+}
+
+// padWidth возвращает ширину, если у границы есть ведущий ноль: {01..10}.
+func padWidth(bounds ...string) int {
+	width := 0
+	padded := false
+	for _, bound := range bounds {
+		digits := strings.TrimPrefix(bound, "-")
+		padded = padded || (len(digits) > 1 && digits[0] == '0')
+		if len(bound) > width {
+			width = len(bound)
+		}
+	}
+	if !padded {
+		return 0
+	}
+	return width
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,138 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// символы, которые в дополненном слове экранируются обратным слешем
+const specialChars = " \t\n;|&<>()'\"\\$*?#"
+
+// Complete дополняет последнее слово строки: в позиции команды - имена
+// из Shell.Commands, алиасы и исполняемые файлы из PATH, иначе - пути
+// относительно PWD. Каталоги дополняются с / на конце.
+func (sh *Shell) Complete(line string) (int, []string) {
+	start := wordStart(line)
+	prefix := unescape(line[start:])
+
+	before := strings.TrimRight(line[:start], " \t")
+	isCommand := before == "" || strings.ContainsAny(before[len(before)-1:], ";|&(")
+
+	names := []string{}
+	if isCommand && !strings.Contains(prefix, "/") {
+		names = sh.completeCommand(prefix)
+	} else {
+		names = sh.completePath(prefix)
+	}
+
+	candidates := []string{}
+	for _, name := range names {
+		candidates = append(candidates, escape(name))
+	}
+	return start, candidates
+}
+
+func (sh *Shell) completeCommand(prefix string) []string {
+	seen := map[string]bool{}
+	for name := range sh.Commands {
+		if strings.HasPrefix(name, prefix) && name != "exec" {
+			seen[name] = true
+		}
+	}
+	for name := range sh.Aliases {
+		if strings.HasPrefix(name, prefix) {
+			seen[name] = true
+		}
+	}
+
+	for _, dir := range filepath.SplitList(sh.Vars["PATH"]) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), prefix) || seen[entry.Name()] {
+				continue
+			}
+			info, err := os.Stat(filepath.Join(dir, entry.Name()))
+			if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+				seen[entry.Name()] = true
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (sh *Shell) completePath(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+
+	lookup := dir
+	if !filepath.IsAbs(lookup) {
+		lookup = filepath.Join(sh.Vars["PWD"], dir)
+	}
+
+	entries, err := os.ReadDir(lookup)
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		// скрытые файлы - только если их явно начали набирать
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+
+		if info, err := os.Stat(filepath.Join(lookup, name)); err == nil && info.IsDir() {
+			name += "/"
+		}
+		names = append(names, dir+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// wordStart ищет начало последнего слова с учетом экранирования.
+func wordStart(line string) int {
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\':
+			i++
+		case strings.IndexByte(" \t;|&<>()", line[i]) >= 0:
+			start = i + 1
+		}
+	}
+	return start
+}
+
+func unescape(word string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(word); i++ {
+		if word[i] == '\\' && i+1 < len(word) {
+			i++
+		}
+		b.WriteByte(word[i])
+	}
+	return b.String()
+}
+
+func escape(word string) string {
+	b := strings.Builder{}
+	for _, r := range word {
+		if strings.ContainsRune(specialChars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+package shell
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// stopped сообщает, что оставшиеся команды списка нужно пропустить:
+// выполнен exit, return, break или continue, нажат Ctrl-C или отменен
+// контекст шелла.
+func (sh *Shell) stopped() bool {
+	return sh.exited || sh.returning || sh.breaking > 0 || sh.continuing > 0 || sh.cancelled()
+}
+
+func (sh *Shell) runIf(cmd *If, stdio IO) int {
+	for _, clause := range cmd.Clauses {
+		status := sh.RunList(clause.Cond, stdio)
+		if sh.stopped() {
+			return status
+		}
+		if status == 0 {
+			return sh.RunList(clause.Body, stdio)
+		}
+	}
+
+	if cmd.Else != nil {
+		return sh.RunList(cmd.Else, stdio)
+	}
+	return 0
+}
+
+func (sh *Shell) runLoop(cmd *Loop, stdio IO) int {
+	sh.loopDepth++
+	defer func() { sh.loopDepth-- }()
+
+	status := 0
+	for {
+		cond := sh.RunList(cmd.Cond, stdio)
+		if sh.loopDone() || (cond == 0) == cmd.Until {
+			return status
+		}
+
+		status = sh.RunList(cmd.Body, stdio)
+		if sh.loopDone() {
+			return status
+		}
+	}
+}
+
+func (sh *Shell) runFor(cmd *For, stdio IO) int {
+	values := positional(sh.Vars)
+	if cmd.HasIn {
+		var err error
+		if values, err = sh.Expand(cmd.Words, stdio); err != nil {
+			fmt.Fprintln(stdio.Stderr, err)
+			return 1
+		}
+	}
+
+	sh.loopDepth++
+	defer func() { sh.loopDepth-- }()
+
+	status := 0
+	for _, value := range values {
+		sh.Vars[cmd.Var] = value
+		status = sh.RunList(cmd.Body, stdio)
+		if sh.loopDone() {
+			break
+		}
+	}
+	return status
+}
+
+// loopDone вызывается после тела или условия цикла и сообщает, что цикл
+// нужно завершить. break N и continue N уменьшают счетчик на каждом
+// уровне вложенности: continue продолжает цикл, на котором счетчик
+// дошел до нуля.
+func (sh *Shell) loopDone() bool {
+	switch {
+	case sh.breaking > 0:
+		sh.breaking--
+		return true
+	case sh.continuing > 0:
+		sh.continuing--
+		return sh.continuing > 0
+	}
+	return sh.exited || sh.returning || sh.cancelled()
+}
+
+func (sh *Shell) runCase(cmd *Case, stdio IO) int {
+	word, err := sh.ExpandWord(cmd.Word, stdio)
+	if err != nil {
+		fmt.Fprintln(stdio.Stderr, err)
+		return 1
+	}
+
+	for _, item := range cmd.Items {
+		for _, pattern := range item.Patterns {
+			p, err := sh.patternWord(pattern, stdio)
+			if err != nil {
+				fmt.Fprintln(stdio.Stderr, err)
+				return 1
+			}
+			if MatchPattern(p, word) {
+				return sh.RunList(item.Body, stdio)
+			}
+		}
+	}
+	return 0
+}
+
+// Function - функция, определенная в шелле как name() { ...; }. Она
+// выполняется в шелле, которому принадлежит, поэтому меняет его
+// переменные; в конвейере это подоболочка, как и для других builtins.
+type Function struct {
+	Body Node
+	sh   *Shell
+}
+
+func (f *Function) Run(ctx *command.Context, args []string) int {
+	sh := f.sh
+
+	saved := positional(sh.Vars)
+	setPositional(sh.Vars, args)
+	sh.locals = append(sh.locals, map[string]*string{})
+	// break внутри функции не действует на циклы снаружи нее
+	loopDepth := sh.loopDepth
+	sh.loopDepth = 0
+	sh.callDepth++
+
+	status := sh.RunCommand(f.Body, IO{ctx.Stdin, ctx.Stdout, ctx.Stderr})
+	sh.returning = false
+
+	sh.callDepth--
+	sh.loopDepth = loopDepth
+	restoreVars(sh.Vars, sh.locals[len(sh.locals)-1])
+	sh.locals = sh.locals[:len(sh.locals)-1]
+	setPositional(sh.Vars, saved)
+	return status
+}
+
+func restoreVars(vars map[string]string, saved map[string]*string) {
+	for name, value := range saved {
+		if value == nil {
+			delete(vars, name)
+		} else {
+			vars[name] = *value
+		}
+	}
+}
+
+// LocalCmd - local NAME[=value]...: переменная восстанавливается
+// при выходе из функции.
+type LocalCmd struct {
+	sh *Shell
+}
+
+func (c *LocalCmd) Run(ctx *command.Context, args []string) int {
+	if len(c.sh.locals) == 0 {
+		fmt.Fprintln(ctx.Stderr, "local: can only be used in a function")
+		return 1
+	}
+	frame := c.sh.locals[len(c.sh.locals)-1]
+
+	status := 0
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		if !IsName(name) {
+			fmt.Fprintf(ctx.Stderr, "local: `%s': not a valid identifier\n", arg)
+			status = 1
+			continue
+		}
+
+		if _, ok := frame[name]; !ok {
+			if old, ok := ctx.Vars[name]; ok {
+				frame[name] = &old
+			} else {
+				frame[name] = nil
+			}
+		}
+		ctx.Vars[name] = value
+	}
+	return status
+}
+
+// ReturnCmd - return [N]: выход из функции или файла, выполняемого source,
+// с кодом N, без аргумента - с $?.
+type ReturnCmd struct {
+	sh *Shell
+}
+
+func (c *ReturnCmd) Run(ctx *command.Context, args []string) int {
+	if c.sh.callDepth == 0 {
+		fmt.Fprintln(ctx.Stderr, "return: can only `return' from a function or sourced script")
+		return 1
+	}
+
+	code, _ := strconv.Atoi(ctx.Vars["?"])
+	if len(args) > 0 {
+		var err error
+		if code, err = strconv.Atoi(args[0]); err != nil {
+			fmt.Fprintf(ctx.Stderr, "return: %s: numeric argument required\n", args[0])
+			code = 2
+		}
+	}
+
+	c.sh.returning = true
+	return code & 0xff
+}
+
+// BreakCmd - break [N] и continue [N] (Continue).
+type BreakCmd struct {
+	sh       *Shell
+	Continue bool
+}
+
+func (c *BreakCmd) Run(ctx *command.Context, args []string) int {
+	name := "break"
+	if c.Continue {
+		name = "continue"
+	}
+
+	if c.sh.loopDepth == 0 {
+		fmt.Fprintf(ctx.Stderr, "%s: only meaningful in a `for', `while', or `until' loop\n", name)
+		return 1
+	}
+
+	n, status := 1, 0
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			// как в bash: ошибка, но выход из одного цикла, иначе
+			// while true; do break 0; done не кончится
+			fmt.Fprintf(ctx.Stderr, "%s: %s: loop count out of range\n", name, args[0])
+			n, status = 1, 1
+		}
+	}
+	if n > c.sh.loopDepth {
+		n = c.sh.loopDepth
+	}
+
+	if c.Continue {
+		c.sh.continuing = n
+	} else {
+		c.sh.breaking = n
+	}
+	return status
+}
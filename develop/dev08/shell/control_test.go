@@ -0,0 +1,141 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestControlFlow(t *testing.T) {
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{`if true; then echo a; fi`, "a", 0},
+		{`if false; then echo a; fi`, "", 0},
+		{`if false; then echo a; elif status 0; then echo b; else echo c; fi`, "b", 0},
+		{`if false; then echo a; else status 3; fi`, "", 3},
+		{`if [ a = b ]; then echo eq; else echo ne; fi`, "ne", 0},
+		{`if true; then echo a; fi | upper`, "A", 0},
+		{`{ echo a; echo b; } | upper`, "AB", 0},
+		{`{ X=1; }; echo $X`, "1", 0},
+		{`for x in a "b c" d; do echo "<$x>"; done`, "<a><b c><d>", 0},
+		{`L="1 2"; for x in $L 3; do echo $x; done; echo $x`, "1233", 0},
+		{`set -- p q; for x; do echo $x; done`, "pq", 0},
+		{`for x in; do echo $x; done`, "", 0},
+		{`N=; while [ "$N" != xxx ]; do N=x$N; echo $N; done`, "xxxxxx", 0},
+		{`until true; do echo never; done`, "", 0},
+		{`for x in 1 2 3; do if [ $x = 2 ]; then break; fi; echo $x; done`, "1", 0},
+		{`for x in 1 2 3; do if [ $x = 2 ]; then continue; fi; echo $x; done`, "13", 0},
+		{`for x in a b; do for y in 1 2; do echo $x$y; break 2; done; done; echo end`, "a1end", 0},
+		{`for x in a b; do for y in 1 2; do continue 2; echo no; done; echo no; done; echo $x$y`, "b1", 0},
+		{`while true; do break; done`, "", 0},
+		{`while true; do break 0; done; echo end`, "end", 0},
+		{`for x in 1 2; do echo $x; continue x; echo no; done`, "12", 1},
+		{`break`, "", 1},
+		{`case abc in a) echo 1;; a*c) echo 2;; *) echo 3;; esac`, "2", 0},
+		{`case x in a|x) echo 1;; esac`, "1", 0},
+		{`case '*' in "*") echo star;; esac`, "star", 0},
+		{`case abc in "a"*) echo quoted;; esac`, "quoted", 0},
+		{`case abc in "a*") echo no;; esac`, "", 0},
+		{`P=b*; case bcd in $P) echo var;; esac`, "var", 0},
+		{`case x in y) echo no; esac; status 0`, "", 0},
+		{`if false; then :; fi > /nonexistent/out`, "", 1},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		out := &SafeBuffer{}
+
+		status := sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if status != test.status {
+			t.Errorf("%q: expected status %d, got %d", test.line, test.status, status)
+		}
+		if out.String() != test.stdout {
+			t.Errorf("%q: expected stdout %q, got %q", test.line, test.stdout, out.String())
+		}
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lib.sh"), []byte("echo lib\nreturn 4\necho unreachable\n"), 0644)
+
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{`f() { echo "$# $1"; }; f a b; echo $#`, "2 a0", 0},
+		{`f() { status 5; }; f`, "", 5},
+		{`f() { return 3; echo no; }; f; echo $?`, "3", 0},
+		{`f() { status 6; return; echo no; }; f`, "", 6},
+		{`f() { if true; then return; fi; echo no; }; status 6; f`, "", 0},
+		{`f() { for x in 1 2; do return 7; done; echo no; }; f`, "", 7},
+		{`f() { echo $1; }; f x | upper`, "X", 0},
+		{`f() { upper; }; echo piped | f`, "PIPED", 0},
+		{`f() { X=inner; }; f; echo $X`, "inner", 0},
+		{`X=outer; f() { local X=inner Y; echo $X; }; f; echo $X ${Y-unset}`, "innerouter unset", 0},
+		{`f() { local X=$1; if [ $1 != 3 ]; then f 3; fi; echo $X; }; f 1`, "31", 0},
+		{`f() { echo $X; }; X=tmp f; echo ${X-none}`, "tmpnone", 0},
+		{`f() { (return 2); echo $?; }; f`, "2", 0},
+		{`f() { g() { echo g; }; }; f; g`, "g", 0},
+		{`f() ( X=sub ); f; echo ${X-none}`, "none", 0},
+		{`f() { echo $1; }; f a > out; upper < out`, "A", 0},
+		{`for x in 1 2; do f() { break; }; f; echo $x; done`, "12", 0},
+		{`f() { exit 9; }; f; echo no`, "", 9},
+		{`local X`, "", 1},
+		{`return`, "", 1},
+		{`f() { source lib.sh; echo $?; }; f`, "lib4", 0},
+		{`f() { echo $X; }; X=a; f & wait`, "[1]\na", 0},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		sh.Vars["PWD"] = dir
+		out := &SafeBuffer{}
+
+		status := sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if status != test.status {
+			t.Errorf("%q: expected status %d, got %d", test.line, test.status, status)
+		}
+		if out.String() != test.stdout {
+			t.Errorf("%q: expected stdout %q, got %q", test.line, test.stdout, out.String())
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"*", "", true},
+		{"a*", "a/b/c", true},
+		{"*.go", "main.go", true},
+		{"*.go", "main.gox", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"[abc]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"[!a-c]x", "dx", true},
+		{"[]]", "]", true},
+		{"[a", "[a", true},
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{"**a*b", "xxaxxb", true},
+		{"ж?", "жё", true},
+	}
+
+	for _, test := range tests {
+		if got := MatchPattern(test.pattern, test.s); got != test.match {
+			t.Errorf("%q %q: expected %v, got %v", test.pattern, test.s, test.match, got)
+		}
+	}
+}
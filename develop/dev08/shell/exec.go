@@ -0,0 +1,402 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type IO struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Execute разбирает и выполняет строку. Возвращает код возврата последней
+// выполненной команды, он же сохраняется в $?.
+func (sh *Shell) Execute(line string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	list, err := Parse(line)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return sh.setStatus(2)
+	}
+
+	return sh.RunList(list, IO{stdin, stdout, stderr})
+}
+
+func (sh *Shell) setStatus(code int) int {
+	sh.Vars["?"] = strconv.Itoa(code)
+	return code
+}
+
+// RunList выполняет элементы списка по порядку. Элементы с & выполняются в
+// подоболочке, регистрируются в таблице задач и не читают stdin шелла.
+func (sh *Shell) RunList(list *List, stdio IO) int {
+	status := 0
+	for _, item := range list.Items {
+		if sh.stopped() {
+			break
+		}
+
+		if !item.Background {
+			status = sh.RunAndOr(item.AndOr, stdio)
+			continue
+		}
+
+		job := sh.Jobs.Add(item.Text)
+		fmt.Fprintf(stdio.Stdout, "[%d]\n", job.ID)
+		sh.background = job
+
+		bgio := stdio
+		bgio.Stdin = strings.NewReader("")
+		sub := sh.Subshell()
+		sub.job = job
+		go func(andOr *AndOr) {
+			sh.Jobs.Finish(job, sub.RunAndOr(andOr, bgio))
+		}(item.AndOr)
+
+		status = sh.setStatus(0)
+	}
+	return status
+}
+
+// RunAndOr выполняет конвейеры с учетом короткого замыкания && и ||.
+func (sh *Shell) RunAndOr(andOr *AndOr, stdio IO) int {
+	status := sh.RunPipeline(andOr.First, stdio)
+	for _, item := range andOr.Rest {
+		if !sh.stopped() && (item.Op == "&&") == (status == 0) {
+			status = sh.RunPipeline(item.Pipeline, stdio)
+		}
+	}
+	return status
+}
+
+// RunPipeline запускает команды конвейера и ждет их завершения. Если команд
+// несколько, каждая выполняется в своей подоболочке, как в bash.
+// Возвращает код возврата последней команды.
+func (sh *Shell) RunPipeline(pipeline *Pipeline, stdio IO) int {
+	if len(pipeline.Commands) == 1 {
+		return sh.setStatus(sh.RunCommand(pipeline.Commands[0], stdio))
+	}
+
+	job := sh.job
+	if sh.ctl != nil && job == nil {
+		job = newJob(pipeline.Text)
+	}
+
+	subs := []*Shell{}
+	for range pipeline.Commands {
+		sub := sh.Subshell()
+		sub.job = job
+		subs = append(subs, sub)
+	}
+
+	// с управлением задачами конвейер - задача переднего плана, его можно остановить
+	if job != sh.job {
+		return sh.setStatus(sh.ctl.wait(sh.ctl.jobs, job, stdio.Stdout, func() {
+			sh.ctl.jobs.Finish(job, runStages(subs, pipeline.Commands, stdio))
+		}))
+	}
+	return sh.setStatus(runStages(subs, pipeline.Commands, stdio))
+}
+
+// runStages выполняет команды конвейера в подоболочках subs.
+func runStages(subs []*Shell, commands []Node, stdio IO) int {
+	// При использовании |, связывает cmd1.stdout -> cmd2.stdin, через io.Pipe
+	wg := sync.WaitGroup{}
+	src := stdio.Stdin
+	status := 0
+
+	for idx, cmd := range commands {
+		isLast := idx+1 == len(commands)
+
+		cmdio := stdio
+		cmdio.Stdin = src
+
+		var pr *io.PipeReader
+		var pw *io.PipeWriter
+		if !isLast {
+			pr, pw = io.Pipe()
+			cmdio.Stdout = pw
+			src = pr
+		}
+
+		wg.Add(1)
+		go func(sub *Shell, cmd Node, cmdio IO, pw *io.PipeWriter) {
+			defer wg.Done()
+			code := sub.RunCommand(cmd, cmdio)
+
+			if pw != nil {
+				pw.Close()
+			} else {
+				status = code
+			}
+
+			// следующая команда больше не читает: писатель получит ошибку, а не зависнет
+			if r, ok := cmdio.Stdin.(*io.PipeReader); ok {
+				r.Close()
+			}
+		}(subs[idx], cmd, cmdio, pw)
+	}
+
+	wg.Wait()
+	return status
+}
+
+// RunCommand выполняет простую или составную команду. Составные команды,
+// кроме ( ), выполняются в текущем шелле.
+func (sh *Shell) RunCommand(node Node, stdio IO) int {
+	switch cmd := node.(type) {
+	case *SimpleCommand:
+		return sh.RunSimple(cmd, stdio)
+	case *FuncDef:
+		sh.Commands[cmd.Name] = &Function{Body: cmd.Body, sh: sh}
+		return 0
+	}
+
+	compound, ok := node.(Compound)
+	if !ok {
+		fmt.Fprintf(stdio.Stderr, "sh: unsupported node %T\n", node)
+		return 1
+	}
+
+	cmdio, closeFiles, err := sh.Redirect(*compound.redirects(), stdio)
+	if err != nil {
+		fmt.Fprintln(stdio.Stderr, err)
+		return 1
+	}
+	defer closeFiles()
+
+	switch cmd := compound.(type) {
+	case *Subshell:
+		return sh.Subshell().RunList(cmd.Body, cmdio)
+	case *BraceGroup:
+		return sh.RunList(cmd.Body, cmdio)
+	case *If:
+		return sh.runIf(cmd, cmdio)
+	case *Loop:
+		return sh.runLoop(cmd, cmdio)
+	case *For:
+		return sh.runFor(cmd, cmdio)
+	case *Case:
+		return sh.runCase(cmd, cmdio)
+	default:
+		fmt.Fprintf(stdio.Stderr, "sh: unsupported node %T\n", node)
+		return 1
+	}
+}
+
+// Redirect применяет перенаправления слева направо, поэтому
+// "> out 2>&1" и "2>&1 > out" дают разный результат, как в sh.
+// Относительные пути считаются от $PWD. Возвращенная функция
+// закрывает открытые файлы.
+func (sh *Shell) Redirect(redirects []*Redirect, stdio IO) (IO, func(), error) {
+	files := []*os.File{}
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	for _, r := range redirects {
+		target, err := sh.ExpandWord(r.Target, stdio)
+		if err != nil {
+			closeFiles()
+			return stdio, nil, err
+		}
+
+		var stream any
+		switch r.Op {
+		case "<", ">", ">>":
+			flag := os.O_RDONLY
+			if r.Op == ">" {
+				flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			} else if r.Op == ">>" {
+				flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+
+			path := target
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(sh.Vars["PWD"], path)
+			}
+			// ограниченный шелл не меняет файлы, как bash -r, и не читает их:
+			// файлы разрешают только явно разрешенные команды
+			if sh.allowed != nil && path != os.DevNull {
+				closeFiles()
+				if r.Op == "<" {
+					return stdio, nil, fmt.Errorf("sh: %s: restricted: cannot redirect input", target)
+				}
+				return stdio, nil, fmt.Errorf("sh: %s: restricted: cannot redirect output", target)
+			}
+
+			f, err := os.OpenFile(path, flag, 0644)
+			if err != nil {
+				closeFiles()
+				if pathErr, ok := err.(*os.PathError); ok {
+					err = pathErr.Err
+				}
+				return stdio, nil, fmt.Errorf("sh: %s: %v", target, err)
+			}
+			files = append(files, f)
+			stream = f
+		case "<<", "<<-":
+			// с разделителем в кавычках текст не раскрывается
+			body := r.Body
+			if !r.Target.Quoted() {
+				if body, err = sh.expandText(body, true, stdio); err != nil {
+					closeFiles()
+					return stdio, nil, err
+				}
+			}
+			stream = strings.NewReader(body)
+		case "<&", ">&":
+			fd, err := strconv.Atoi(target)
+			if err != nil || fd < 0 || fd > 2 {
+				closeFiles()
+				return stdio, nil, fmt.Errorf("sh: %s: bad file descriptor", target)
+			}
+			stream = []any{stdio.Stdin, stdio.Stdout, stdio.Stderr}[fd]
+		}
+
+		ok := false
+		switch r.Fd {
+		case 0:
+			stdio.Stdin, ok = stream.(io.Reader)
+		case 1:
+			stdio.Stdout, ok = stream.(io.Writer)
+		case 2:
+			stdio.Stderr, ok = stream.(io.Writer)
+		}
+		if !ok {
+			closeFiles()
+			return stdio, nil, fmt.Errorf("sh: %d: bad file descriptor", r.Fd)
+		}
+	}
+
+	return stdio, closeFiles, nil
+}
+
+// RunSimple раскрывает слова команды и выполняет ее. Присваивания перед
+// командой (FOO=1 cmd) действуют только на время ее выполнения и попадают
+// в окружение процесса, без команды - остаются в шелле.
+func (sh *Shell) RunSimple(cmd *SimpleCommand, stdio IO) int {
+	sh.substStatus = 0
+
+	words, redirects := sh.expandAliases(cmd.Args, cmd.Redirects)
+	assigns := []assignment{}
+	for len(words) > 0 {
+		name, value, ok := splitAssignment(words[0])
+		if !ok {
+			break
+		}
+		assigns = append(assigns, assignment{name, value})
+		words = words[1:]
+	}
+
+	args, err := sh.Expand(words, stdio)
+	if err != nil {
+		fmt.Fprintln(stdio.Stderr, err)
+		return 1
+	}
+
+	saved := map[string]*string{}
+	defer restoreVars(sh.Vars, saved)
+
+	for _, assign := range assigns {
+		name := assign.name
+		value, err := sh.ExpandWord(assign.value, stdio)
+		if err != nil {
+			fmt.Fprintln(stdio.Stderr, err)
+			return 1
+		}
+
+		if _, ok := saved[name]; !ok && len(args) > 0 {
+			if old, ok := sh.Vars[name]; ok {
+				saved[name] = &old
+			} else {
+				saved[name] = nil
+			}
+		}
+		sh.Vars[name] = value
+	}
+
+	cmdio, closeFiles, err := sh.Redirect(redirects, stdio)
+	if err != nil {
+		fmt.Fprintln(stdio.Stderr, err)
+		return 1
+	}
+	defer closeFiles()
+
+	if len(args) == 0 {
+		return sh.substStatus
+	}
+
+	// внешняя команда получает окружение, а не все переменные шелла
+	env := sh.Environ()
+	for name := range saved {
+		env[name] = sh.Vars[name]
+	}
+
+	if prog, ok := sh.Commands[args[0]]; ok {
+		return prog.Run(sh.commandContext(sh.foregroundContext(), env, cmdio), args[1:])
+	}
+
+	prog, ok := sh.Commands["exec"]
+	if !ok {
+		fmt.Fprintf(cmdio.Stderr, "sh: %s: command not found\n", args[0])
+		return 127
+	}
+
+	// внешнюю команду на переднем плане прерывает SIGINT, а не контекст
+	ctx := sh.commandContext(sh.baseContext(), env, cmdio)
+	if starter, ok := prog.(Starter); ok && (sh.job != nil || sh.ctl != nil) {
+		return sh.runProcess(starter, ctx, args)
+	}
+
+	return prog.Run(ctx, args)
+}
+
+// Subshell возвращает копию шелла: изменения переменных внутри ( ) не видны снаружи.
+func (sh *Shell) Subshell() *Shell {
+	vars := map[string]string{}
+	for k, v := range sh.Vars {
+		vars[k] = v
+	}
+
+	commands := map[string]Command{}
+	for name, cmd := range sh.Commands {
+		commands[name] = cmd
+	}
+
+	exported := map[string]bool{}
+	for name := range sh.Exported {
+		exported[name] = true
+	}
+
+	aliases := map[string]string{}
+	for name, value := range sh.Aliases {
+		aliases[name] = value
+	}
+
+	sub := &Shell{
+		Vars:       vars,
+		Exported:   exported,
+		Commands:   commands,
+		Aliases:    aliases,
+		Jobs:       NewJobTable(),
+		job:        sh.job,
+		background: sh.background,
+		ctl:        sh.ctl,
+		ctx:        sh.ctx,
+		allowed:    sh.allowed,
+		paths:      sh.paths,
+	}
+	// return и break в ( ) завершают только подоболочку
+	sub.callDepth, sub.loopDepth = sh.callDepth, sh.loopDepth
+	sub.registerBuiltins()
+	return sub
+}
@@ -0,0 +1,329 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expand раскрывает слова команды по порядку: фигурные скобки {a,b} и
+// {1..5}, тильду, $VAR, ${VAR}, ${VAR:-word}, ${VAR-word}, спецпеременные
+// $? $$ $! $# $@ $* и подстановку $(...). Результат раскрытия вне кавычек
+// разбивается на поля по пробелам, а слово без кавычек, раскрывшееся в
+// пустую строку, пропадает. Поля с *, ? или [...] вне кавычек заменяются
+// подходящими путями относительно $PWD, если они есть.
+func (sh *Shell) Expand(words []Word, stdio IO) ([]string, error) {
+	fields := []string{}
+
+	for _, word := range words {
+		for _, word := range braceExpand(word) {
+			expanded, err := sh.expandFields(sh.tilde(word), stdio)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, f := range expanded {
+				if pattern := f.pattern.String(); hasGlobMeta(pattern) {
+					if matches := sh.glob(pattern); len(matches) > 0 {
+						fields = append(fields, matches...)
+						continue
+					}
+				}
+				fields = append(fields, f.text.String())
+			}
+		}
+	}
+
+	return fields, nil
+}
+
+// field - поле результата раскрытия. pattern - то же поле как шаблон
+// для поиска файлов: символы из кавычек в нем экранированы.
+type field struct {
+	text    strings.Builder
+	pattern strings.Builder
+	have    bool
+}
+
+func (f *field) quoted(s string) {
+	f.text.WriteString(s)
+	f.pattern.WriteString(escapePattern(s))
+	f.have = true
+}
+
+func (f *field) unquoted(r rune) {
+	f.text.WriteRune(r)
+	f.pattern.WriteRune(r)
+	f.have = true
+}
+
+func (sh *Shell) expandFields(word Word, stdio IO) ([]*field, error) {
+	fields := []*field{}
+	cur := &field{}
+
+	for _, part := range word {
+		if part.Quote == SingleQuoted {
+			cur.quoted(part.Text)
+			continue
+		}
+
+		// "$@" - каждый позиционный параметр отдельным словом
+		if pre, post, ok := strings.Cut(part.Text, "$@"); ok && part.Quote == DoubleQuoted {
+			params := positional(sh.Vars)
+			if len(params) == 0 && pre == "" && post == "" && len(word) == 1 {
+				continue
+			}
+
+			text, err := sh.expandText(pre, false, stdio)
+			if err != nil {
+				return nil, err
+			}
+			cur.quoted(text)
+
+			for idx, param := range params {
+				if idx > 0 {
+					fields = append(fields, cur)
+					cur = &field{}
+				}
+				cur.quoted(param)
+			}
+
+			// остаток части может содержать еще один "$@" - раскрываем как обычный
+			part = WordPart{Text: post, Quote: DoubleQuoted}
+		}
+
+		text, err := sh.expandText(part.Text, false, stdio)
+		if err != nil {
+			return nil, err
+		}
+
+		if part.Quote == DoubleQuoted {
+			cur.quoted(text)
+			continue
+		}
+
+		// пробелы в тексте без кавычек могли появиться только из раскрытия
+		for _, r := range text {
+			if !unicode.IsSpace(r) {
+				cur.unquoted(r)
+			} else if cur.have {
+				fields = append(fields, cur)
+				cur = &field{}
+			}
+		}
+	}
+
+	if cur.have {
+		fields = append(fields, cur)
+	}
+	return fields, nil
+}
+
+// tilde раскрывает ~ и ~user в начале слова без кавычек: ~ - это $HOME.
+// Раскрытый путь не разбивается на поля и не считается шаблоном.
+func (sh *Shell) tilde(word Word) Word {
+	if len(word) == 0 || word[0].Quote != Unquoted || !strings.HasPrefix(word[0].Text, "~") {
+		return word
+	}
+
+	text := word[0].Text
+	end := strings.IndexByte(text, '/')
+	if end < 0 {
+		// в ~"user" имя в кавычках: тильда остается
+		if len(word) > 1 {
+			return word
+		}
+		end = len(text)
+	}
+
+	home := sh.Vars["HOME"]
+	if name := text[1:end]; name != "" {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return word
+		}
+		home = u.HomeDir
+	}
+	if home == "" {
+		return word
+	}
+
+	result := Word{{Text: home, Quote: SingleQuoted}}
+	if end < len(text) {
+		result = append(result, WordPart{Text: text[end:], Quote: Unquoted})
+	}
+	return append(result, word[1:]...)
+}
+
+// ExpandWord раскрывает слово без разбиения на поля и поиска файлов: так
+// раскрываются значения присваиваний и имена файлов в перенаправлениях.
+func (sh *Shell) ExpandWord(word Word, stdio IO) (string, error) {
+	b := strings.Builder{}
+	for _, part := range sh.tilde(word) {
+		if part.Quote == SingleQuoted {
+			b.WriteString(part.Text)
+			continue
+		}
+
+		text, err := sh.expandText(part.Text, false, stdio)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+	}
+	return b.String(), nil
+}
+
+// expandText раскрывает $ в тексте. В here-doc (escapes) обратный слеш
+// экранирует $ ` и \, как внутри двойных кавычек.
+func (sh *Shell) expandText(text string, escapes bool, stdio IO) (string, error) {
+	b := strings.Builder{}
+
+	for i := 0; i < len(text); {
+		switch {
+		case escapes && text[i] == '\\' && i+1 < len(text) && strings.IndexByte("$`\\", text[i+1]) >= 0:
+			b.WriteByte(text[i+1])
+			i += 2
+		case text[i] == '$':
+			value, n, err := sh.dollar(text[i:], stdio)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += n
+		default:
+			b.WriteByte(text[i])
+			i++
+		}
+	}
+
+	return b.String(), nil
+}
+
+// dollar раскрывает конструкцию в начале s и возвращает значение
+// и число прочитанных байт.
+func (sh *Shell) dollar(s string, stdio IO) (string, int, error) {
+	if len(s) < 2 {
+		return s, len(s), nil
+	}
+
+	switch c := s[1]; {
+	case c == '(':
+		lx := &Lexer{src: s}
+		raw, err := lx.dollar()
+		if err != nil {
+			return "", 0, err
+		}
+		return sh.substitute(raw[2:len(raw)-1], stdio), len(raw), nil
+	case c == '{':
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", 0, fmt.Errorf("sh: %s: bad substitution", s)
+		}
+		value, err := sh.braced(s[2:end], stdio)
+		if err != nil {
+			return "", 0, fmt.Errorf("sh: %s: %w", s[:end+1], err)
+		}
+		return value, end + 1, nil
+	case isSpecialVar(c):
+		value, _ := sh.lookup(s[1:2])
+		return value, 2, nil
+	case isNameStart(c):
+		n := nameLen(s[1:])
+		value, _ := sh.lookup(s[1 : 1+n])
+		return value, 1 + n, nil
+	default:
+		return "$", 1, nil
+	}
+}
+
+// braced раскрывает содержимое ${...}.
+func (sh *Shell) braced(body string, stdio IO) (string, error) {
+	name := body
+	if len(body) > 0 && isSpecialVar(body[0]) {
+		name = body[:1]
+	} else if n := nameLen(body); n > 0 {
+		name = body[:n]
+	} else {
+		return "", fmt.Errorf("bad substitution")
+	}
+
+	value, ok := sh.lookup(name)
+	rest := body[len(name):]
+
+	switch {
+	case rest == "":
+		return value, nil
+	case strings.HasPrefix(rest, ":-"):
+		if value == "" {
+			return sh.expandText(rest[2:], false, stdio)
+		}
+		return value, nil
+	case strings.HasPrefix(rest, "-"):
+		if !ok {
+			return sh.expandText(rest[1:], false, stdio)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("bad substitution")
+	}
+}
+
+func (sh *Shell) lookup(name string) (string, bool) {
+	switch name {
+	case "$":
+		return strconv.Itoa(os.Getpid()), true
+	case "@", "*":
+		return strings.Join(positional(sh.Vars), " "), true
+	case "!":
+		if sh.background == nil {
+			return "", false
+		}
+		// задачи - горутины, а не процессы: у задачи из одних builtins
+		// pid нет, вместо него спецификация, ее понимают wait, fg и bg
+		if pid, ok := sh.background.LastPid(); ok {
+			return strconv.Itoa(pid), true
+		}
+		return fmt.Sprintf("%%%d", sh.background.ID), true
+	}
+	value, ok := sh.Vars[name]
+	return value, ok
+}
+
+// substitute выполняет $(...) в подоболочке и возвращает ее вывод без
+// завершающих переводов строки. Код возврата запоминается для команд,
+// состоящих только из присваиваний: после x=$(false) $? равен 1.
+func (sh *Shell) substitute(src string, stdio IO) string {
+	out := &bytes.Buffer{}
+	sh.substStatus = sh.Subshell().Execute(src, stdio.Stdin, out, stdio.Stderr)
+	return strings.TrimRight(out.String(), "\n")
+}
+
+func isSpecialVar(c byte) bool {
+	return strings.IndexByte("?$!#@*", c) >= 0 || (c >= '0' && c <= '9')
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// nameLen возвращает длину имени переменной в начале s.
+func nameLen(s string) int {
+	if len(s) == 0 || !isNameStart(s[0]) {
+		return 0
+	}
+
+	n := 1
+	for n < len(s) && (isNameStart(s[n]) || (s[n] >= '0' && s[n] <= '9')) {
+		n++
+	}
+	return n
+}
+
+func IsName(s string) bool {
+	return s != "" && nameLen(s) == len(s)
+}
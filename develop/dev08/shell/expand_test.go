@@ -0,0 +1,190 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{`X=1; echo $X`, "1", 0},
+		{`X=1; echo "$X" '$X' \$X`, "1 $X $X", 0},
+		{`X=a; echo ${X}b $Xb.`, "ab .", 0},
+		{`echo ${X:-def} ${X-def}`, "def def", 0},
+		{`X=; echo ${X:-def} [${X-def}]`, "def []", 0},
+		{`X=val; echo ${X:-def}`, "val", 0},
+		{`D=d; echo ${X:-$D}`, "d", 0},
+		{`X="a   b"; setvar Y $X; echo $Y`, "a", 0},
+		{`X="a   b"; echo $X "$X"`, "a b a   b", 0},
+		{`echo a $EMPTY "$EMPTY" b`, "a  b", 0},
+		{`status 3; echo $?`, "3", 0},
+		{`echo $`, "$", 0},
+		{`echo $(echo a b)`, "a b", 0},
+		{`echo "$(echo "x  y")"`, "x  y", 0},
+		{`echo $(echo $(echo nested))`, "nested", 0},
+		{`echo $(echo ")")`, ")", 0},
+		{`X=$(status 4)`, "", 4},
+		{`X=$(status 4); echo $?`, "4", 0},
+		{`X=1 setvar Y 2; echo $X$Y`, "2", 0},
+		{`X=1; X=2 status 0; echo $X`, "1", 0},
+		{`X=1 Y=$X; echo $Y`, "1", 0},
+		{`"X=1"`, "", 127},
+		{`echo ${1abc}`, "", 1},
+		{`export A=1 B; echo $A`, "1", 0},
+		{`X=1; unset X; echo ${X-gone}`, "gone", 0},
+		{`unset 1x`, "", 1},
+		{`set -- a b; echo $# $1 $2`, "2 a b", 0},
+		{"X=here; upper <<EOF\n$X \\$X\nEOF", "HERE $X\n", 0},
+		{"X=here; upper <<'EOF'\n$X\nEOF", "$X\n", 0},
+		{`F=out; echo a > $F; upper < out`, "A", 0},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		sh.Vars["PWD"] = t.TempDir()
+		out := &bytes.Buffer{}
+
+		status := sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if status != test.status {
+			t.Errorf("%q: expected status %d, got %d", test.line, test.status, status)
+		}
+		if out.String() != test.stdout {
+			t.Errorf("%q: expected stdout %q, got %q", test.line, test.stdout, out.String())
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	sh := NewExecShell(t)
+	out := &bytes.Buffer{}
+
+	sh.Execute(`A=1; B=2; export A; export C=3 D; unset B; export`, strings.NewReader(""), out, io.Discard)
+
+	expected := "export A='1'\nexport C='3'\nexport PWD='/'\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestExecEnviron(t *testing.T) {
+	sh, err := NewShell(map[string]string{
+		"PWD":  t.TempDir(),
+		"PATH": os.Getenv("PATH"),
+	}, map[string]Command{"exec": &command.Exec{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	sh.Execute(`LOCAL=1; export SHARED=2; ONCE=3 sh -c 'echo $LOCAL,$SHARED,$ONCE,$(basename $PWD)'`, strings.NewReader(""), out, io.Discard)
+
+	expected := ",2,3," + filepath.Base(sh.Vars["PWD"]) + "\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+	if _, ok := sh.Vars["ONCE"]; ok {
+		t.Error("prefix assignment leaked to shell")
+	}
+}
+
+func TestBraceTildeExpand(t *testing.T) {
+	tests := []struct {
+		line   string
+		stdout string
+	}{
+		{`echo a{b,c}d`, "abd acd"},
+		{`echo {a,b}{1,2}`, "a1 a2 b1 b2"},
+		{`echo {a,{b,c}x}`, "a bx cx"},
+		{`echo {a,}z`, "az z"},
+		{`echo {1..5}`, "1 2 3 4 5"},
+		{`echo {5..1..2}`, "5 3 1"},
+		{`echo {08..11}`, "08 09 10 11"},
+		{`echo {c..a}`, "c b a"},
+		{`echo {a} {} {a..} {1..b} x{`, "{a} {} {a..} {1..b} x{"},
+		{`echo {a{b,c}`, "{ab {ac"},
+		{`echo "{a,b}" '{a,b}' \{a,b}`, "{a,b} {a,b} {a,b}"},
+		{`echo {"a b",c}`, "a b c"},
+		{`X=1,2; echo {$X} ${X}`, "{1,2} 1,2"},
+		{`echo {$(echo a),b}`, "a b"},
+		{`for x in {1..3}; do echo $x; done`, "123"},
+		{`echo ~ ~/x ~"/x" "~" x~`, "/home/user /home/user/x ~/x ~ x~"},
+		{`X=~/bin; echo $X`, "/home/user/bin"},
+		{`echo ~{a,b}`, "~a ~b"},
+		{`echo ~root`, "/root"},
+		{`echo ~no-such-user-here`, "~no-such-user-here"},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		sh.Vars["HOME"] = "/home/user"
+		out := &bytes.Buffer{}
+
+		sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if out.String() != test.stdout {
+			t.Errorf("%q: expected stdout %q, got %q", test.line, test.stdout, out.String())
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.txt", ".hidden.go", "sub/d.go", "sub/deep/e.go", "sub/deep/f.txt", "[x].go"} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755)
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	tests := []struct {
+		line   string
+		stdout string
+	}{
+		{`echo *.go`, "[x].go a.go b.go"},
+		{`echo ?.go`, "a.go b.go"},
+		{`echo [ab].go`, "a.go b.go"},
+		{`echo [!a].*`, "b.go c.txt"},
+		{`echo .*`, ".hidden.go"},
+		{`echo */*.go`, "sub/d.go"},
+		{`echo *.none`, "*.none"},
+		{`echo "*.go" '*'.go \*.go`, "*.go *.go *.go"},
+		{`echo \[x\].go`, "[x].go"},
+		{`P='*.txt'; echo $P "$P"`, "c.txt *.txt"},
+		{`echo **/*.go`, "[x].go a.go b.go sub/d.go sub/deep/e.go"},
+		{`echo sub/**`, "sub/d.go sub/deep sub/deep/e.go sub/deep/f.txt"},
+		{`echo */`, "sub/"},
+		{`echo **/`, "sub/ sub/deep/"},
+		{`echo sub/**/`, "sub/ sub/deep/"},
+		{`echo sub/*/*.txt`, "sub/deep/f.txt"},
+		{`echo ./s*`, "./sub"},
+		{`echo {a,c}.*`, "a.go c.txt"},
+		{`echo [`, "["},
+		{`X=*.go; echo $X`, "[x].go a.go b.go"},
+		{`F=*.go; echo a > $F; echo *.go`, "*.go [x].go a.go b.go"},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		sh.Vars["PWD"] = dir
+		out := &bytes.Buffer{}
+
+		sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if out.String() != test.stdout {
+			t.Errorf("%q: expected stdout %q, got %q", test.line, test.stdout, out.String())
+		}
+	}
+
+	sh := NewExecShell(t)
+	out := &bytes.Buffer{}
+	sh.Execute(`echo `+filepath.Join(dir, "sub", "*.go"), strings.NewReader(""), out, io.Discard)
+	if out.String() != filepath.Join(dir, "sub", "d.go") {
+		t.Errorf("unexpected absolute glob result %q", out.String())
+	}
+}
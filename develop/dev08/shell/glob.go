@@ -0,0 +1,111 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// glob ищет файлы по шаблону. Относительный шаблон считается от $PWD,
+// и найденные пути остаются относительными, как в sh. Компонент ** совпадает
+// с любым числом каталогов. Имена, начинающиеся с точки, находятся только
+// шаблоном, который сам начинается с точки.
+func (sh *Shell) glob(pattern string) []string {
+	dir, prefix := sh.Vars["PWD"], ""
+	if strings.HasPrefix(pattern, "/") {
+		dir, prefix = "/", "/"
+		pattern = strings.TrimLeft(pattern, "/")
+	}
+
+	matches := globSegments(dir, prefix, strings.Split(pattern, "/"))
+	sort.Strings(matches)
+	return matches
+}
+
+func globSegments(dir, prefix string, segs []string) []string {
+	if len(segs) == 0 {
+		return []string{prefix}
+	}
+	seg, rest := segs[0], segs[1:]
+
+	switch {
+	case seg == "" && len(rest) == 0:
+		// шаблон кончается на /: только найденные каталоги. Пустой
+		// prefix - сам $PWD у **/, он не совпадение
+		if prefix == "" {
+			return nil
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return []string{strings.TrimSuffix(prefix, "/") + "/"}
+		}
+		return nil
+	case seg == "":
+		return globSegments(dir, prefix, rest)
+	case !hasGlobMeta(seg):
+		name := unescapePattern(seg)
+		path := filepath.Join(dir, name)
+		if _, err := os.Lstat(path); err != nil {
+			return nil
+		}
+		return globSegments(path, joinPrefix(prefix, name), rest)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	matches := []string{}
+	if seg == "**" && len(rest) > 0 {
+		matches = append(matches, globSegments(dir, prefix, rest)...)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(seg, ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if seg == "**" {
+			if len(rest) == 0 {
+				matches = append(matches, joinPrefix(prefix, name))
+			}
+			// по символическим ссылкам не спускаемся: они могут образовать цикл
+			if entry.IsDir() {
+				matches = append(matches, globSegments(path, joinPrefix(prefix, name), segs)...)
+			}
+			continue
+		}
+
+		if !MatchPattern(seg, name) {
+			continue
+		}
+		if len(rest) > 0 {
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		matches = append(matches, globSegments(path, joinPrefix(prefix, name), rest)...)
+	}
+	return matches
+}
+
+func joinPrefix(prefix, name string) string {
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return prefix + name
+	}
+	return prefix + "/" + name
+}
+
+func unescapePattern(s string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
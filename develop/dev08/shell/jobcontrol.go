@@ -0,0 +1,270 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// jobControl - управление задачами интерактивного шелла. Внешние команды
+// конвейера выполняются в своей группе процессов, группа переднего плана
+// получает терминал, Ctrl-Z останавливает ее и возвращает управление
+// шеллу. Один на шелл и все его подоболочки.
+type jobControl struct {
+	tty  *os.File  // терминал шелла, nil - терминал не передается
+	pgid int       // группа процессов шелла
+	jobs *JobTable // таблица задач шелла: в нее попадают остановленные задачи
+	proc string    // каталог procfs
+
+	mu sync.Mutex
+	fg *Job // задача переднего плана
+
+	// interrupt отменяется по SIGINT: оставшиеся команды пропускаются,
+	// а builtin переднего плана прерывается
+	parent    context.Context
+	interrupt context.Context
+	cancel    context.CancelFunc
+}
+
+// EnableJobControl включает управление задачами. tty - терминал шелла
+// или nil. Сигналы SIGINT и SIGTSTP, полученные шеллом, пересылаются
+// группе переднего плана. Возвращенная функция выключает обработку сигналов.
+func (sh *Shell) EnableJobControl(tty *os.File) func() {
+	ctl := &jobControl{tty: tty, pgid: getpgrp(), jobs: sh.Jobs, proc: "/proc", parent: sh.baseContext()}
+	ctl.interrupt, ctl.cancel = context.WithCancel(ctl.parent)
+	sh.ctl = ctl
+	sh.registerBuiltins()
+
+	// шелл забирает терминал у задачи, находясь в фоновой группе
+	signal.Ignore(sigTTOU)
+
+	signals := make(chan os.Signal, 8)
+	signal.Notify(signals, syscall.SIGINT, sigTSTP, sigCHLD)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				ctl.handle(sig.(syscall.Signal))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		signal.Reset(sigTTOU)
+		close(done)
+	}
+}
+
+func (c *jobControl) handle(sig syscall.Signal) {
+	switch sig {
+	case sigCHLD:
+		c.update()
+		return
+	case syscall.SIGINT:
+		c.setInterrupt()
+	}
+
+	if fg := c.foreground(); fg != nil {
+		fg.Signal(sig)
+	}
+}
+
+// update вызывается по SIGCHLD: сообщает об остановке задачи переднего
+// плана и обновляет состояние фоновых задач.
+func (c *jobControl) update() {
+	fg := c.foreground()
+	if fg != nil && fg.Stopped(c.proc) {
+		select {
+		case fg.stop <- struct{}{}:
+		default:
+		}
+	}
+
+	for _, job := range c.jobs.Jobs() {
+		state, _ := c.jobs.State(job)
+		if job == fg || job.Pgid() == 0 || state == JobDone {
+			continue
+		}
+
+		stopped := job.Stopped(c.proc)
+		if stopped && state == JobRunning {
+			c.jobs.SetState(job, JobStopped)
+		} else if !stopped && state == JobStopped {
+			c.jobs.SetState(job, JobRunning)
+		}
+	}
+}
+
+func (c *jobControl) foreground() *Job {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.fg
+}
+
+// context возвращает контекст команд переднего плана.
+func (c *jobControl) context() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.interrupt
+}
+
+func (c *jobControl) isInterrupted() bool {
+	return c != nil && c.context().Err() != nil
+}
+
+func (c *jobControl) setInterrupt() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cancel()
+}
+
+func (c *jobControl) clearInterrupt() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.interrupt.Err() != nil {
+		c.interrupt, c.cancel = context.WithCancel(c.parent)
+	}
+}
+
+// terminalFor возвращает терминал, если job - задача переднего плана:
+// лидер ее группы заберет терминал при запуске.
+func (c *jobControl) terminalFor(job *Job) *os.File {
+	if c == nil || c.foreground() != job {
+		return nil
+	}
+	return c.tty
+}
+
+// giveTerminal отдает терминал группе задачи, а без задачи - шеллу.
+func (c *jobControl) giveTerminal(job *Job) {
+	if c.tty == nil {
+		return
+	}
+
+	pgid := c.pgid
+	if job != nil && job.Pgid() != 0 {
+		pgid = job.Pgid()
+	}
+	setForeground(c.tty, pgid)
+}
+
+// wait выполняет задачу на переднем плане: run запускается в горутине и
+// должен завершить задачу через Finish таблицы шелла. Если процессы задачи
+// остановлены, она остается в table остановленной, а wait возвращает
+// 128+SIGTSTP, не дожидаясь ее завершения, как sh после Ctrl-Z.
+func (c *jobControl) wait(table *JobTable, job *Job, stdout io.Writer, run func()) int {
+	c.mu.Lock()
+	prev := c.fg
+	c.fg = job
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.fg = prev
+		c.mu.Unlock()
+		c.giveTerminal(prev)
+	}()
+
+	if run != nil {
+		go run()
+	}
+
+	select {
+	case <-job.done:
+	case <-job.stop:
+		select {
+		case <-job.done:
+		default:
+			table.Stop(job)
+			fmt.Fprintf(stdout, "\n%s\n", table.Describe(job))
+			return 128 + int(sigTSTP)
+		}
+	}
+
+	table.Remove(job)
+	_, code := table.State(job)
+	if code == command.Interrupted {
+		c.setInterrupt()
+	}
+	return code
+}
+
+// resume продолжает остановленную задачу на переднем плане (fg).
+func (c *jobControl) resume(table *JobTable, job *Job, stdout io.Writer) int {
+	table.SetState(job, JobRunning)
+	c.giveTerminal(job)
+	job.Signal(sigCONT)
+
+	// уведомление об остановке, полученное до продолжения, устарело
+	select {
+	case <-job.stop:
+	default:
+	}
+	return c.wait(table, job, stdout, nil)
+}
+
+// runProcess запускает внешнюю команду. В фоновой задаче или стадии
+// конвейера процесс попадает в ее группу. На переднем плане с управлением
+// задачами для команды создается своя задача, которую можно остановить.
+func (sh *Shell) runProcess(starter Starter, ctx *command.Context, args []string) int {
+	if sh.job != nil {
+		proc, code := sh.startProcess(sh.job, starter, ctx, args)
+		if proc == nil {
+			return code
+		}
+
+		defer sh.job.RemovePid(proc.Process.Pid)
+		return command.Wait(proc, ctx.Stderr)
+	}
+
+	job := newJob(strings.Join(args, " "))
+	return sh.ctl.wait(sh.ctl.jobs, job, ctx.Stdout, func() {
+		proc, code := sh.startProcess(job, starter, ctx, args)
+		if proc != nil {
+			code = command.Wait(proc, ctx.Stderr)
+			job.RemovePid(proc.Process.Pid)
+		}
+		sh.ctl.jobs.Finish(job, code)
+	})
+}
+
+func (sh *Shell) startProcess(job *Job, starter Starter, ctx *command.Context, args []string) (*exec.Cmd, int) {
+	for {
+		proc, code := starter.Command(ctx, args)
+		if proc == nil {
+			return nil, code
+		}
+
+		retry, err := job.start(proc, sh.ctl != nil, sh.ctl.terminalFor(job))
+		if err == nil {
+			return proc, 0
+		}
+		if !retry {
+			return nil, command.StartError(err, ctx.Stderr)
+		}
+	}
+}
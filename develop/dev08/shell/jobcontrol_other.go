@@ -0,0 +1,25 @@
+//go:build !unix
+
+package shell
+
+import (
+	"os"
+	"syscall"
+)
+
+// Сигналов управления задачами нет: номера как в Linux, шелл их не получает.
+const (
+	sigTSTP = syscall.Signal(0x14)
+	sigCONT = syscall.Signal(0x12)
+	sigCHLD = syscall.Signal(0x11)
+	sigTTOU = syscall.Signal(0x16)
+)
+
+func getpgrp() int {
+	return 0
+}
+
+// groupAttr - групп процессов нет: процессы задачи запускаются как есть.
+func groupAttr(pgid int, tty *os.File) *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,188 @@
+//go:build unix
+
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+func NewJobControlShell(t *testing.T) *Shell {
+	sh, err := NewShell(map[string]string{"PWD": "/", "PATH": os.Getenv("PATH")}, map[string]Command{
+		"echo": &command.Echo{},
+		"exec": &command.Exec{},
+		"kill": &command.Kill{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := sh.EnableJobControl(nil)
+	t.Cleanup(stop)
+	return sh
+}
+
+// StartForeground выполняет строку в горутине и ждет, пока у задачи
+// переднего плана появятся n процессов.
+func StartForeground(t *testing.T, sh *Shell, line string, out io.Writer, n int) (*Job, chan int) {
+	status := make(chan int, 1)
+	go func() {
+		status <- sh.Execute(line, strings.NewReader(""), out, io.Discard)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if job := sh.ctl.foreground(); job != nil && len(job.Pids()) == n {
+			// процесс мог еще не выполнить exec
+			time.Sleep(50 * time.Millisecond)
+			return job, status
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("foreground job is not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func WaitStatus(t *testing.T, status chan int) int {
+	select {
+	case code := <-status:
+		return code
+	case <-time.After(5 * time.Second):
+		t.Fatal("command is not finished")
+		return 0
+	}
+}
+
+func TestSignalExitStatus(t *testing.T) {
+	sh := NewJobControlShell(t)
+	out := &SafeBuffer{}
+
+	if status := sh.Execute(`sh -c 'kill -TERM $$'`, strings.NewReader(""), out, io.Discard); status != 143 {
+		t.Errorf("expected status 143, got %d", status)
+	}
+
+	// Ctrl-C, полученный шеллом, пересылается задаче и прерывает список
+	_, status := StartForeground(t, sh, `sleep 10; echo after`, out, 1)
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	if code := WaitStatus(t, status); code != 130 {
+		t.Errorf("expected status 130, got %d", code)
+	}
+	if out.String() != "" {
+		t.Errorf("commands after interrupt were executed: %q", out.String())
+	}
+}
+
+func TestStopForeground(t *testing.T) {
+	sh := NewJobControlShell(t)
+	out := &SafeBuffer{}
+
+	job, status := StartForeground(t, sh, `sleep 10; echo after $?`, out, 1)
+	if pgid := job.Pgid(); pgid != job.Pids()[0] || pgid == syscall.Getpgrp() {
+		t.Errorf("process is not a group leader: pgid %d, pids %v", pgid, job.Pids())
+	}
+
+	// Ctrl-Z: шелл пересылает SIGTSTP задаче и продолжает список
+	syscall.Kill(os.Getpid(), syscall.SIGTSTP)
+	if code := WaitStatus(t, status); code != 0 {
+		t.Errorf("expected status 0, got %d", code)
+	}
+	if !strings.Contains(out.String(), "[1]+  Stopped                 sleep 10\nafter 148") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	if state, _ := sh.Jobs.State(job); state != JobStopped {
+		t.Errorf("expected stopped job, got %s", state)
+	}
+
+	// fg продолжает задачу на переднем плане
+	out.Reset()
+	status = make(chan int, 1)
+	go func() {
+		status <- sh.Execute(`fg %1`, strings.NewReader(""), out, io.Discard)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	job.Signal(syscall.SIGTERM)
+
+	if code := WaitStatus(t, status); code != 143 {
+		t.Errorf("expected status 143, got %d", code)
+	}
+	if len(sh.Jobs.Jobs()) != 0 {
+		t.Errorf("finished job is left in table")
+	}
+}
+
+func TestStopPipeline(t *testing.T) {
+	sh := NewJobControlShell(t)
+	out := &SafeBuffer{}
+
+	job, status := StartForeground(t, sh, `sleep 10 | cat`, out, 2)
+	pids := job.Pids()
+	job.Signal(syscall.SIGTSTP)
+
+	if code := WaitStatus(t, status); code != 148 {
+		t.Errorf("expected status 148, got %d", code)
+	}
+	if !strings.Contains(out.String(), "Stopped                 sleep 10 | cat") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	for _, pid := range pids {
+		if pgid, _ := syscall.Getpgid(pid); pgid != job.Pgid() {
+			t.Errorf("process %d is in group %d, expected %d", pid, pgid, job.Pgid())
+		}
+	}
+
+	// bg продолжает задачу, а kill %1 посылает сигнал всей группе
+	for _, line := range []string{`bg %1`, `kill %1`} {
+		if code := sh.Execute(line, strings.NewReader(""), out, io.Discard); code != 0 {
+			t.Errorf("%s: expected status 0, got %d", line, code)
+		}
+	}
+	WaitDone(t, sh, 1)
+}
+
+// Ctrl-C прерывает builtin переднего плана, в том числе в конвейере.
+func TestInterruptBuiltin(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "log"), []byte("line\n"), 0644)
+
+	tests := []struct {
+		line   string
+		status int
+	}{
+		{"tail -f log; echo after", command.Interrupted},
+		// код конвейера - код последней команды: она завершилась по концу ввода
+		{"tail -f log | tail -f; echo after", 0},
+	}
+
+	for _, test := range tests {
+		sh, err := NewShell(map[string]string{"PWD": root}, map[string]Command{
+			"echo": &command.Echo{},
+			"tail": &command.Tail{Poll: 10 * time.Millisecond},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stop := sh.EnableJobControl(nil)
+
+		out := &SafeBuffer{}
+		status := make(chan int, 1)
+		go func() {
+			status <- sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		}()
+		time.Sleep(100 * time.Millisecond)
+		sh.ctl.handle(syscall.SIGINT)
+
+		if code := WaitStatus(t, status); code != test.status || strings.Contains(out.String(), "after") {
+			t.Errorf("%q: expected status %d without after, got %d and %q", test.line, test.status, code, out.String())
+		}
+		stop()
+	}
+}
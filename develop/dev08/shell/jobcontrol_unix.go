@@ -0,0 +1,30 @@
+//go:build unix
+
+package shell
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	sigTSTP = syscall.SIGTSTP
+	sigCONT = syscall.SIGCONT
+	sigCHLD = syscall.SIGCHLD
+	sigTTOU = syscall.SIGTTOU
+)
+
+func getpgrp() int {
+	return syscall.Getpgrp()
+}
+
+// groupAttr помещает процесс в группу pgid, 0 - в новую. С tty новая
+// группа сразу получает терминал.
+func groupAttr(pgid int, tty *os.File) *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{Setpgid: true, Pgid: pgid}
+	if pgid == 0 && tty != nil {
+		attr.Foreground = true
+		attr.Ctty = int(tty.Fd())
+	}
+	return attr
+}
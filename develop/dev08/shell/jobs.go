@@ -0,0 +1,565 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+type JobState int
+
+const (
+	JobRunning JobState = iota
+	JobStopped
+	JobDone
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobRunning:
+		return "Running"
+	case JobStopped:
+		return "Stopped"
+	default:
+		return "Done"
+	}
+}
+
+// Job - конвейер, запущенный в фоне через &.
+type Job struct {
+	ID  int
+	Cmd string
+
+	state    JobState
+	exitCode int
+	done     chan struct{}
+	stop     chan struct{} // процессы задачи остановлены, например Ctrl-Z
+	started  chan struct{} // задача запустила первый процесс ОС
+
+	pidsMu sync.Mutex
+	pids   []int // запущенные задачей процессы ОС
+	pgid   int   // группа процессов задачи, 0 - процессы в группе шелла
+	last   int   // последний запущенный процесс, для $!
+}
+
+func newJob(cmd string) *Job {
+	return &Job{Cmd: cmd, state: JobRunning, done: make(chan struct{}), stop: make(chan struct{}, 1), started: make(chan struct{})}
+}
+
+func (j *Job) Status() string {
+	if j.state == JobDone && j.exitCode != 0 {
+		return fmt.Sprintf("Exit %d", j.exitCode)
+	}
+	return j.state.String()
+}
+
+func (j *Job) AddPid(pid int) {
+	j.pidsMu.Lock()
+	defer j.pidsMu.Unlock()
+
+	j.pids = append(j.pids, pid)
+}
+
+func (j *Job) RemovePid(pid int) {
+	j.pidsMu.Lock()
+	defer j.pidsMu.Unlock()
+
+	for idx, item := range j.pids {
+		if item == pid {
+			j.pids = append(j.pids[:idx], j.pids[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (j *Job) Pids() []int {
+	j.pidsMu.Lock()
+	defer j.pidsMu.Unlock()
+
+	return append([]int{}, j.pids...)
+}
+
+// waitStarted ждет, пока задача запустит первый процесс или завершится:
+// горутина задачи могла еще не дойти до запуска.
+func (j *Job) waitStarted() {
+	select {
+	case <-j.started:
+	case <-j.done:
+	}
+}
+
+// LastPid ждет, пока задача запустит процесс или завершится, и возвращает
+// последний запущенный процесс. Задача из одних builtins процессов не
+// запускает, тогда ok false.
+func (j *Job) LastPid() (pid int, ok bool) {
+	j.waitStarted()
+
+	j.pidsMu.Lock()
+	defer j.pidsMu.Unlock()
+	return j.last, j.last != 0
+}
+
+func (j *Job) hasPid(pid int) bool {
+	j.pidsMu.Lock()
+	defer j.pidsMu.Unlock()
+
+	if pid == j.last {
+		return true
+	}
+	for _, item := range j.pids {
+		if item == pid {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *Job) Pgid() int {
+	j.pidsMu.Lock()
+	defer j.pidsMu.Unlock()
+
+	return j.pgid
+}
+
+// start запускает процесс задачи. С group процессы задачи живут в своей
+// группе: первый становится ее лидером, остальные присоединяются, а с tty
+// лидер сразу забирает терминал. Если лидер уже завершился и группы нет,
+// start возвращает retry: процесс нужно подготовить заново, он создаст
+// новую группу.
+func (j *Job) start(cmd *exec.Cmd, group bool, tty *os.File) (bool, error) {
+	j.pidsMu.Lock()
+	defer j.pidsMu.Unlock()
+
+	if group {
+		// без поддержки групп процессов (не Unix) атрибутов нет
+		cmd.SysProcAttr = groupAttr(j.pgid, tty)
+		group = cmd.SysProcAttr != nil
+	}
+
+	if err := cmd.Start(); err != nil {
+		if group && j.pgid != 0 && errors.Is(err, syscall.EPERM) {
+			j.pgid = 0
+			return true, err
+		}
+		return false, err
+	}
+
+	if group && j.pgid == 0 {
+		j.pgid = cmd.Process.Pid
+	}
+	if j.last == 0 {
+		close(j.started)
+	}
+	j.last = cmd.Process.Pid
+	j.pids = append(j.pids, cmd.Process.Pid)
+	return false, nil
+}
+
+// Signal посылает сигнал группе процессов задачи, а без группы - каждому процессу.
+func (j *Job) Signal(sig syscall.Signal) {
+	if pgid := j.Pgid(); pgid != 0 {
+		command.SendSignal(-pgid, sig)
+		return
+	}
+	for _, pid := range j.Pids() {
+		command.SendSignal(pid, sig)
+	}
+}
+
+// Stopped проверяет по procfs, что процессы задачи остановлены. Уже
+// завершившиеся процессы не мешают: остановленным считается и
+// конвейер, часть которого успела закончиться.
+func (j *Job) Stopped(root string) bool {
+	stopped := false
+	for _, pid := range j.Pids() {
+		proc, err := command.ReadProcess(root, pid)
+		switch {
+		case err != nil || proc.State == "Z" || proc.State == "X":
+		case proc.State == "T" || proc.State == "t":
+			stopped = true
+		default:
+			return false
+		}
+	}
+	return stopped
+}
+
+type JobTable struct {
+	mu   sync.Mutex
+	jobs []*Job
+}
+
+func NewJobTable() *JobTable {
+	return &JobTable{}
+}
+
+// Add регистрирует новую задачу. Номер - следующий за наибольшим занятым, как в bash.
+func (t *JobTable) Add(cmd string) *Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	job := newJob(cmd)
+	job.ID = t.nextID()
+	t.jobs = append(t.jobs, job)
+	return job
+}
+
+func (t *JobTable) nextID() int {
+	id := 1
+	for _, job := range t.jobs {
+		if job.ID >= id {
+			id = job.ID + 1
+		}
+	}
+	return id
+}
+
+// Stop отмечает задачу остановленной. Задача переднего плана при этом
+// попадает в таблицу и становится текущей.
+func (t *JobTable) Stop(job *Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if job.state == JobDone {
+		return
+	}
+	job.state = JobStopped
+
+	for idx, item := range t.jobs {
+		if item == job {
+			t.jobs = append(append(t.jobs[:idx:idx], t.jobs[idx+1:]...), job)
+			return
+		}
+	}
+	job.ID = t.nextID()
+	t.jobs = append(t.jobs, job)
+}
+
+func (t *JobTable) Finish(job *Job, exitCode int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	job.state = JobDone
+	job.exitCode = exitCode
+	close(job.done)
+}
+
+func (t *JobTable) SetState(job *Job, state JobState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if job.state != JobDone {
+		job.state = state
+	}
+}
+
+func (t *JobTable) State(job *Job) (JobState, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return job.state, job.exitCode
+}
+
+func (t *JobTable) Remove(job *Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for idx, item := range t.jobs {
+		if item == job {
+			t.jobs = append(t.jobs[:idx], t.jobs[idx+1:]...)
+			return
+		}
+	}
+}
+
+// mark возвращает признак текущей (+) и предыдущей (-) задачи.
+func (t *JobTable) mark(idx int) byte {
+	switch idx {
+	case len(t.jobs) - 1:
+		return '+'
+	case len(t.jobs) - 2:
+		return '-'
+	default:
+		return ' '
+	}
+}
+
+func (t *JobTable) format(idx int) string {
+	job := t.jobs[idx]
+	return fmt.Sprintf("[%d]%c  %-24s%s", job.ID, t.mark(idx), job.Status(), job.Cmd)
+}
+
+// List печатает все задачи в формате jobs.
+func (t *JobTable) List(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for idx := range t.jobs {
+		fmt.Fprintln(w, t.format(idx))
+	}
+}
+
+// Reap печатает уведомления о завершенных задачах и удаляет их из таблицы.
+func (t *JobTable) Reap(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	alive := []*Job{}
+	for idx, job := range t.jobs {
+		if job.state == JobDone {
+			fmt.Fprintln(w, t.format(idx))
+			continue
+		}
+		alive = append(alive, job)
+	}
+	t.jobs = alive
+}
+
+func (t *JobTable) Describe(job *Job) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for idx, item := range t.jobs {
+		if item == job {
+			return t.format(idx)
+		}
+	}
+	return fmt.Sprintf("[%d]   %-24s%s", job.ID, job.Status(), job.Cmd)
+}
+
+func (t *JobTable) Jobs() []*Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*Job{}, t.jobs...)
+}
+
+// Get ищет задачу по спецификации: %N, N, pid процесса задачи, %+, %%, %-,
+// %prefix или пусто (текущая).
+func (t *JobTable) Get(spec string) (*Job, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.jobs) == 0 {
+		return nil, fmt.Errorf("%s: no such job", jobSpecName(spec))
+	}
+
+	switch spec {
+	case "", "%", "%%", "%+":
+		return t.jobs[len(t.jobs)-1], nil
+	case "%-":
+		if len(t.jobs) < 2 {
+			return t.jobs[len(t.jobs)-1], nil
+		}
+		return t.jobs[len(t.jobs)-2], nil
+	}
+
+	name := strings.TrimPrefix(spec, "%")
+	if id, err := strconv.Atoi(name); err == nil {
+		// число без % может быть pid из $!
+		if name == spec {
+			for _, job := range t.jobs {
+				if job.hasPid(id) {
+					return job, nil
+				}
+			}
+		}
+		for _, job := range t.jobs {
+			if job.ID == id {
+				return job, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: no such job", spec)
+	}
+
+	if strings.HasPrefix(spec, "%") {
+		var found *Job
+		for _, job := range t.jobs {
+			if strings.HasPrefix(job.Cmd, name) {
+				if found != nil {
+					return nil, fmt.Errorf("%s: ambiguous job spec", spec)
+				}
+				found = job
+			}
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: no such job", spec)
+}
+
+func jobSpecName(spec string) string {
+	if spec == "" {
+		return "current"
+	}
+	return spec
+}
+
+// Pids возвращает процессы задачи для kill %N: у задачи со своей группой
+// это -pgid, сигнал получит вся группа. Только что запущенную задачу Pids
+// ждет, как и LastPid; ошибка - если процессов так и не появилось.
+func (t *JobTable) Pids(spec string) ([]int, error) {
+	job, err := t.Get(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	job.waitStarted()
+	if pgid := job.Pgid(); pgid != 0 {
+		return []int{-pgid}, nil
+	}
+
+	pids := job.Pids()
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("%s: job has no running processes", spec)
+	}
+	return pids, nil
+}
+
+// Wait ждет завершения задачи, удаляет ее из таблицы и возвращает код возврата.
+func (t *JobTable) Wait(job *Job) int {
+	<-job.done
+	t.Remove(job)
+
+	_, exitCode := t.State(job)
+	return exitCode
+}
+
+// Builtins для управления задачами. Им нужен доступ к таблице задач шелла,
+// поэтому они живут рядом с Shell, а не в пакете command.
+
+type JobsCmd struct {
+	jobs *JobTable
+}
+
+func (c *JobsCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) == 0 {
+		c.jobs.List(ctx.Stdout)
+		return 0
+	}
+
+	for _, spec := range args {
+		job, err := c.jobs.Get(spec)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "jobs:", err)
+			return 1
+		}
+
+		fmt.Fprintln(ctx.Stdout, c.jobs.Describe(job))
+	}
+	return 0
+}
+
+type FgCmd struct {
+	jobs *JobTable
+	ctl  *jobControl
+}
+
+func (c *FgCmd) Run(ctx *command.Context, args []string) int {
+	spec := ""
+	if len(args) > 0 {
+		spec = args[0]
+	}
+
+	job, err := c.jobs.Get(spec)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "fg:", err)
+		return 1
+	}
+
+	fmt.Fprintln(ctx.Stdout, job.Cmd)
+	if c.ctl != nil {
+		return c.ctl.resume(c.jobs, job, ctx.Stdout)
+	}
+
+	if state, _ := c.jobs.State(job); state == JobStopped {
+		job.Signal(sigCONT)
+	}
+	c.jobs.SetState(job, JobRunning)
+	return c.jobs.Wait(job)
+}
+
+type BgCmd struct {
+	jobs *JobTable
+}
+
+func (c *BgCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	exitCode := 0
+	for _, spec := range args {
+		job, err := c.jobs.Get(spec)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "bg:", err)
+			exitCode = 1
+			continue
+		}
+
+		switch state, _ := c.jobs.State(job); state {
+		case JobRunning:
+			fmt.Fprintf(ctx.Stderr, "bg: job %d already in background\n", job.ID)
+		case JobDone:
+			fmt.Fprintf(ctx.Stderr, "bg: job %d has terminated\n", job.ID)
+			exitCode = 1
+		default:
+			c.jobs.SetState(job, JobRunning)
+			job.Signal(sigCONT)
+			fmt.Fprintf(ctx.Stdout, "[%d]+ %s &\n", job.ID, job.Cmd)
+		}
+	}
+	return exitCode
+}
+
+type WaitCmd struct {
+	jobs *JobTable
+}
+
+// Без аргументов ждет все задачи и возвращает 0, иначе - код последней указанной.
+// Отмена контекста прерывает ожидание, задачи продолжают работать.
+func (c *WaitCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) == 0 {
+		for _, job := range c.jobs.Jobs() {
+			if !waitDone(ctx, job) {
+				return command.Interrupted
+			}
+			c.jobs.Wait(job)
+		}
+		return 0
+	}
+
+	exitCode := 0
+	for _, spec := range args {
+		job, err := c.jobs.Get(spec)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "wait:", err)
+			exitCode = 127
+			continue
+		}
+		if !waitDone(ctx, job) {
+			return command.Interrupted
+		}
+		exitCode = c.jobs.Wait(job)
+	}
+	return exitCode
+}
+
+func waitDone(ctx *command.Context, job *Job) bool {
+	select {
+	case <-job.done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,294 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// SafeBuffer - stdout для тестов, в который пишут фоновые задачи.
+type SafeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *SafeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *SafeBuffer) Close() error { return nil }
+
+func (b *SafeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *SafeBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+// Block - команда, которая завершается, когда закрывают канал release.
+type Block struct {
+	release chan struct{}
+}
+
+func (c *Block) Run(ctx *command.Context, args []string) int {
+	<-c.release
+	if len(args) > 0 && args[0] == "fail" {
+		return 3
+	}
+	return 0
+}
+
+func NewTestShell(t *testing.T) (*Shell, *Block) {
+	block := &Block{release: make(chan struct{})}
+	sh, err := NewShell(map[string]string{"PWD": "/"}, map[string]Command{
+		"echo":  &command.Echo{},
+		"block": block,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sh, block
+}
+
+func WaitDone(t *testing.T, sh *Shell, id int) {
+	t.Helper()
+	job, err := sh.Jobs.Get("%" + strconv.Itoa(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-job.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job %d is not done", id)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	sh, block := NewTestShell(t)
+	out := &SafeBuffer{}
+
+	sh.Execute("block &", nil, out, io.Discard)
+	sh.Execute("block fail &", nil, out, io.Discard)
+	if out.String() != "[1]\n[2]\n" {
+		t.Fatalf("unexpected job start output: %q", out.String())
+	}
+
+	out.Reset()
+	sh.Execute("jobs", nil, out, io.Discard)
+	expected := "[1]-  Running                 block\n" +
+		"[2]+  Running                 block fail\n"
+	if out.String() != expected {
+		t.Fatalf("jobs mismatch\nexpected: %q\ngot: %q", expected, out.String())
+	}
+
+	close(block.release)
+	WaitDone(t, sh, 1)
+	WaitDone(t, sh, 2)
+
+	out.Reset()
+	sh.Prompt(out)
+	expected = "[1]-  Done                    block\n" +
+		"[2]+  Exit 3                  block fail\n" +
+		"\n/ $ "
+	if out.String() != expected {
+		t.Fatalf("notices mismatch\nexpected: %q\ngot: %q", expected, out.String())
+	}
+
+	// уведомление печатается один раз
+	out.Reset()
+	sh.Prompt(out)
+	if out.String() != "\n/ $ " {
+		t.Fatalf("notices repeated: %q", out.String())
+	}
+}
+
+func TestFgWait(t *testing.T) {
+	sh, block := NewTestShell(t)
+	out := &SafeBuffer{}
+
+	sh.Execute("block fail &", nil, out, io.Discard)
+	sh.Execute("block &", nil, out, io.Discard)
+
+	code := make(chan int)
+	go func() {
+		code <- sh.Execute("fg %1", nil, out, io.Discard)
+	}()
+
+	select {
+	case <-code:
+		t.Fatal("fg returned before job finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(block.release)
+	if c := <-code; c != 3 {
+		t.Errorf("fg expected exit code 3, got %d", c)
+	}
+	if !strings.Contains(out.String(), "block fail\n") {
+		t.Errorf("fg must print job command, got %q", out.String())
+	}
+
+	if c := sh.Execute("wait %2", nil, out, io.Discard); c != 0 {
+		t.Errorf("wait expected 0, got %d", c)
+	}
+
+	if len(sh.Jobs.Jobs()) != 0 {
+		t.Errorf("waited jobs must be removed, got %v", sh.Jobs.Jobs())
+	}
+
+	if c := sh.Execute("fg", nil, out, io.Discard); c != 1 {
+		t.Errorf("fg without jobs expected 1, got %d", c)
+	}
+
+	if c := sh.Execute("wait %5", nil, out, io.Discard); c != 127 {
+		t.Errorf("wait for unknown job expected 127, got %d", c)
+	}
+
+	if c := sh.Execute("wait", nil, out, io.Discard); c != 0 {
+		t.Errorf("wait without jobs expected 0, got %d", c)
+	}
+}
+
+func TestJobSpec(t *testing.T) {
+	table := NewJobTable()
+	a := table.Add("sleep 10")
+	b := table.Add("echo hi")
+	c := table.Add("sleep 20")
+
+	tests := []struct {
+		spec string
+		job  *Job
+	}{
+		{"", c},
+		{"%%", c},
+		{"%+", c},
+		{"%-", b},
+		{"%1", a},
+		{"2", b},
+		{"%echo", b},
+		{"%sleep", nil},
+		{"%7", nil},
+	}
+
+	for _, test := range tests {
+		job, err := table.Get(test.spec)
+		if test.job == nil {
+			if err == nil {
+				t.Errorf("%q: expected error, got job %d", test.spec, job.ID)
+			}
+			continue
+		}
+		if err != nil || job != test.job {
+			t.Errorf("%q: expected job %d, got %v %v", test.spec, test.job.ID, job, err)
+		}
+	}
+
+	// номер освободившейся задачи переиспользуется только если он последний
+	table.Remove(c)
+	if job := table.Add("new"); job.ID != 3 {
+		t.Errorf("expected id 3, got %d", job.ID)
+	}
+}
+
+func TestKillJob(t *testing.T) {
+	sh, err := NewShell(map[string]string{"PWD": "/", "PATH": os.Getenv("PATH")}, map[string]Command{
+		"exec": &command.Exec{},
+		"kill": &command.Kill{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &SafeBuffer{}
+
+	sh.Execute(`sleep 10 &`, strings.NewReader(""), out, io.Discard)
+	job, err := sh.Jobs.Get("%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(job.Pids()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("job process is not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status := sh.Execute(`kill -TERM %1`, strings.NewReader(""), out, io.Discard); status != 0 {
+		t.Fatalf("kill failed with %d", status)
+	}
+	WaitDone(t, sh, 1)
+
+	if state, code := sh.Jobs.State(job); state != JobDone || code == 0 {
+		t.Errorf("expected killed job, got %s with code %d", state, code)
+	}
+	if status := sh.Execute(`kill %2`, strings.NewReader(""), out, io.Discard); status != 1 {
+		t.Errorf("expected error for unknown job, got %d", status)
+	}
+}
+
+func TestLastPid(t *testing.T) {
+	sh, err := NewShell(map[string]string{"PWD": "/", "PATH": os.Getenv("PATH")}, map[string]Command{
+		"echo": &command.Echo{},
+		"exec": &command.Exec{},
+		"kill": &command.Kill{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &SafeBuffer{}
+
+	// $! - pid процесса задачи, по нему работают kill и wait
+	sh.Execute(`sleep 10 &`, strings.NewReader(""), out, io.Discard)
+	out.Reset()
+	sh.Execute(`echo $!`, strings.NewReader(""), out, io.Discard)
+	job, err := sh.Jobs.Get("%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pids := job.Pids(); len(pids) != 1 || out.String() != strconv.Itoa(pids[0]) {
+		t.Fatalf("expected pid of job %v, got %q", pids, out.String())
+	}
+	if status := sh.Execute(`kill $!; wait $!`, strings.NewReader(""), out, io.Discard); status == 0 {
+		t.Errorf("expected killed job status, got %d", status)
+	}
+
+	// у задачи из одних builtins процессов нет
+	out.Reset()
+	sh.Execute(`echo a > /dev/null & echo $!`, strings.NewReader(""), out, io.Discard)
+	if out.String() != "[1]\n%1" {
+		t.Errorf("expected job spec for builtin job, got %q", out.String())
+	}
+}
+
+func TestKillStartingJob(t *testing.T) {
+	// kill сразу после & не должен опережать запуск процесса задачи
+	for i := 0; i < 20; i++ {
+		sh, err := NewShell(map[string]string{"PWD": "/", "PATH": os.Getenv("PATH")}, map[string]Command{
+			"exec": &command.Exec{},
+			"kill": &command.Kill{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status := sh.Execute(`sleep 10 & kill %1`, strings.NewReader(""), io.Discard, io.Discard); status != 0 {
+			t.Fatalf("kill failed with %d", status)
+		}
+		WaitDone(t, sh, 1)
+	}
+}
@@ -0,0 +1,359 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrIncomplete - ввод оборвался внутри кавычек или после оператора,
+// требующего продолжения. Интерактивный шелл в этом случае дочитывает строку.
+var ErrIncomplete = errors.New("unexpected end of input")
+
+type QuoteKind int
+
+const (
+	Unquoted QuoteKind = iota
+	SingleQuoted
+	DoubleQuoted
+)
+
+// WordPart - кусок слова с одинаковым видом кавычек. Экранированный
+// символ хранится как SingleQuoted: дальше он не раскрывается.
+type WordPart struct {
+	Text  string
+	Quote QuoteKind
+}
+
+type Word []WordPart
+
+// String возвращает значение слова после удаления кавычек.
+func (w Word) String() string {
+	b := strings.Builder{}
+	for _, part := range w {
+		b.WriteString(part.Text)
+	}
+	return b.String()
+}
+
+// Quoted сообщает, есть ли в слове кавычки или экранирование.
+func (w Word) Quoted() bool {
+	for _, part := range w {
+		if part.Quote != Unquoted {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Word) add(text string, quote QuoteKind) {
+	if n := len(*w); n > 0 && (*w)[n-1].Quote == quote {
+		(*w)[n-1].Text += text
+		return
+	}
+	*w = append(*w, WordPart{Text: text, Quote: quote})
+}
+
+type TokenKind int
+
+const (
+	TokWord TokenKind = iota
+	TokOp
+	TokNewline
+	TokEOF
+)
+
+type Token struct {
+	Kind TokenKind
+	Op   string
+	Word Word
+
+	Fd   int    // дескриптор перед оператором перенаправления (2>), иначе -1
+	Body string // текст here-doc для << и <<-
+
+	Pos int // смещение начала токена в строке
+	End int
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case TokWord:
+		return t.Word.String()
+	case TokOp:
+		return t.Op
+	case TokNewline:
+		return "newline"
+	default:
+		return "EOF"
+	}
+}
+
+// операторы, отсортированные так, чтобы длинные проверялись раньше
+var operators = []string{
+	"&&", "||", ";;", "<<-", "<<", ">>", "<&", ">&",
+	"|", "&", ";", "(", ")", "<", ">",
+}
+
+func IsRedirectOp(op string) bool {
+	return strings.ContainsAny(op, "<>")
+}
+
+type Lexer struct {
+	src string
+	pos int
+
+	tokens   []Token
+	heredocs []int // индексы операторов <<, чей текст еще не прочитан
+}
+
+// Lex разбивает строку на слова и операторы с учетом кавычек,
+// экранирования и комментариев. Текст here-doc читается со строки,
+// следующей за оператором <<, и сохраняется в токене оператора.
+func Lex(src string) ([]Token, error) {
+	lx := &Lexer{src: src}
+
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		lx.tokens = append(lx.tokens, tok)
+
+		switch {
+		case tok.Kind == TokOp && (tok.Op == "<<" || tok.Op == "<<-"):
+			lx.heredocs = append(lx.heredocs, len(lx.tokens)-1)
+		case tok.Kind == TokNewline && len(lx.heredocs) > 0:
+			if err := lx.readHeredocs(); err != nil {
+				return nil, err
+			}
+		case tok.Kind == TokEOF:
+			if len(lx.heredocs) > 0 {
+				return nil, fmt.Errorf("%w: here-document is not finished", ErrIncomplete)
+			}
+			return lx.tokens, nil
+		}
+	}
+}
+
+func (lx *Lexer) readHeredocs() error {
+	for _, idx := range lx.heredocs {
+		if idx+1 >= len(lx.tokens) || lx.tokens[idx+1].Kind != TokWord {
+			return fmt.Errorf("sh: syntax error: here-document delimiter expected")
+		}
+
+		op := &lx.tokens[idx]
+		delim := lx.tokens[idx+1].Word.String()
+		body := strings.Builder{}
+
+		for {
+			if lx.pos >= len(lx.src) {
+				return fmt.Errorf("%w: here-document delimited by `%s' is not finished", ErrIncomplete, delim)
+			}
+
+			end := strings.IndexByte(lx.src[lx.pos:], '\n')
+			if end < 0 {
+				end = len(lx.src) - lx.pos
+			}
+			line := lx.src[lx.pos : lx.pos+end]
+			lx.pos += end + 1
+			if lx.pos > len(lx.src) {
+				lx.pos = len(lx.src)
+			}
+
+			if op.Op == "<<-" {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == delim {
+				break
+			}
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+
+		op.Body = body.String()
+	}
+
+	lx.heredocs = nil
+	return nil
+}
+
+func (lx *Lexer) peek() byte {
+	if lx.pos < len(lx.src) {
+		return lx.src[lx.pos]
+	}
+	return 0
+}
+
+func (lx *Lexer) skipBlank() {
+	for lx.pos < len(lx.src) {
+		switch c := lx.src[lx.pos]; {
+		case c == ' ' || c == '\t':
+			lx.pos++
+		case c == '\\' && lx.pos+1 < len(lx.src) && lx.src[lx.pos+1] == '\n':
+			lx.pos += 2
+		case c == '#':
+			for lx.pos < len(lx.src) && lx.src[lx.pos] != '\n' {
+				lx.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (lx *Lexer) next() (Token, error) {
+	lx.skipBlank()
+	start := lx.pos
+
+	if lx.pos >= len(lx.src) {
+		return Token{Kind: TokEOF, Fd: -1, Pos: start, End: start}, nil
+	}
+
+	if lx.peek() == '\n' {
+		lx.pos++
+		return Token{Kind: TokNewline, Fd: -1, Pos: start, End: lx.pos}, nil
+	}
+
+	// номер дескриптора сразу перед < или >, например 2>
+	fd := -1
+	digits := lx.pos
+	for digits < len(lx.src) && lx.src[digits] >= '0' && lx.src[digits] <= '9' {
+		digits++
+	}
+	if digits > lx.pos && digits < len(lx.src) && (lx.src[digits] == '<' || lx.src[digits] == '>') {
+		fd, _ = strconv.Atoi(lx.src[lx.pos:digits])
+		lx.pos = digits
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(lx.src[lx.pos:], op) {
+			lx.pos += len(op)
+			return Token{Kind: TokOp, Op: op, Fd: fd, Pos: start, End: lx.pos}, nil
+		}
+	}
+
+	word, err := lx.word()
+	if err != nil {
+		return Token{}, err
+	}
+	return Token{Kind: TokWord, Word: word, Fd: -1, Pos: start, End: lx.pos}, nil
+}
+
+func (lx *Lexer) isWordEnd(c byte) bool {
+	return strings.IndexByte(" \t\n&|;()<>", c) >= 0
+}
+
+func (lx *Lexer) word() (Word, error) {
+	word := Word{}
+
+	for lx.pos < len(lx.src) {
+		c := lx.src[lx.pos]
+		switch {
+		case lx.isWordEnd(c):
+			return word, nil
+		case c == '\'':
+			end := strings.IndexByte(lx.src[lx.pos+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w while looking for matching `''", ErrIncomplete)
+			}
+			word.add(lx.src[lx.pos+1:lx.pos+1+end], SingleQuoted)
+			lx.pos += end + 2
+		case c == '"':
+			if err := lx.doubleQuoted(&word); err != nil {
+				return nil, err
+			}
+		case lx.isDollar():
+			text, err := lx.dollar()
+			if err != nil {
+				return nil, err
+			}
+			word.add(text, Unquoted)
+		case c == '\\':
+			if lx.pos+1 >= len(lx.src) {
+				return nil, fmt.Errorf("%w after `\\'", ErrIncomplete)
+			}
+			if lx.src[lx.pos+1] != '\n' {
+				word.add(lx.src[lx.pos+1:lx.pos+2], SingleQuoted)
+			}
+			lx.pos += 2
+		default:
+			word.add(lx.src[lx.pos:lx.pos+1], Unquoted)
+			lx.pos++
+		}
+	}
+
+	return word, nil
+}
+
+// doubleQuoted читает "...". Внутри экранируются только $ ` " \ и перевод строки.
+func (lx *Lexer) doubleQuoted(word *Word) error {
+	lx.pos++
+	// пустые кавычки "" - тоже слово
+	word.add("", DoubleQuoted)
+
+	for lx.pos < len(lx.src) {
+		c := lx.src[lx.pos]
+		switch {
+		case c == '"':
+			lx.pos++
+			return nil
+		case c == '\\' && lx.pos+1 < len(lx.src) && strings.IndexByte("$`\"\\\n", lx.src[lx.pos+1]) >= 0:
+			if lx.src[lx.pos+1] != '\n' {
+				word.add(lx.src[lx.pos+1:lx.pos+2], SingleQuoted)
+			}
+			lx.pos += 2
+		case lx.isDollar():
+			text, err := lx.dollar()
+			if err != nil {
+				return err
+			}
+			word.add(text, DoubleQuoted)
+		default:
+			word.add(lx.src[lx.pos:lx.pos+1], DoubleQuoted)
+			lx.pos++
+		}
+	}
+
+	return fmt.Errorf("%w while looking for matching `\"'", ErrIncomplete)
+}
+
+func (lx *Lexer) isDollar() bool {
+	return strings.HasPrefix(lx.src[lx.pos:], "$(") || strings.HasPrefix(lx.src[lx.pos:], "${")
+}
+
+// dollar читает ${...} или $(...) целиком, не раскрывая. Внутри $(...)
+// скобки и кавычки учитываются, поэтому $(echo ")") - одна подстановка.
+func (lx *Lexer) dollar() (string, error) {
+	start := lx.pos
+
+	if lx.src[lx.pos+1] == '{' {
+		end := strings.IndexByte(lx.src[lx.pos:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w while looking for matching `}'", ErrIncomplete)
+		}
+		lx.pos += end + 1
+		return lx.src[start:lx.pos], nil
+	}
+
+	lx.pos += 2
+	depth := 0
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case tok.Kind == TokEOF:
+			return "", fmt.Errorf("%w while looking for matching `)'", ErrIncomplete)
+		case tok.Kind == TokOp && tok.Op == "(":
+			depth++
+		case tok.Kind == TokOp && tok.Op == ")":
+			if depth == 0 {
+				return lx.src[start:lx.pos], nil
+			}
+			depth--
+		}
+	}
+}
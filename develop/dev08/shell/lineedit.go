@@ -0,0 +1,477 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInterrupt - строка отменена через Ctrl-C.
+var ErrInterrupt = errors.New("interrupted")
+
+// LineReader - источник строк для Shell.Run.
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// ScanLines читает строки без редактирования: stdin не терминал.
+type ScanLines struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func NewScanLines(in io.Reader, out io.Writer) *ScanLines {
+	return &ScanLines{scanner: bufio.NewScanner(in), out: out}
+}
+
+func (s *ScanLines) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(s.out, prompt)
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+// TermLines включает raw-режим терминала только на время чтения строки,
+// команды выполняются в обычном режиме.
+type TermLines struct {
+	file   *os.File
+	editor *LineEditor
+}
+
+func (t *TermLines) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(t.file)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	return t.editor.ReadLine(prompt)
+}
+
+// Completer получает строку до курсора и возвращает начало дополняемого
+// слова (смещение в байтах) и варианты его замены.
+type Completer func(line string) (int, []string)
+
+// LineEditor - редактор строки для терминала в raw-режиме: стрелки,
+// Home/End, Ctrl-A/E/B/F/K/U/W, история (стрелки и Ctrl-P/N), поиск по
+// истории Ctrl-R и дополнение по Tab.
+type LineEditor struct {
+	In       io.Reader
+	Out      io.Writer
+	History  *History
+	Complete Completer
+
+	buf    []rune
+	pos    int
+	prompt string // последняя строка приглашения, она перерисовывается
+	unread rune
+}
+
+func ctrl(c byte) rune {
+	return rune(c & 0x1f)
+}
+
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	if e.History == nil {
+		e.History = &History{}
+	}
+
+	fmt.Fprint(e.Out, prompt)
+	e.prompt = prompt[strings.LastIndexByte(prompt, '\n')+1:]
+	e.buf, e.pos = nil, 0
+
+	entries := e.History.Entries()
+	histIdx := len(entries)
+	saved := ""
+	lastTab := false
+
+	for {
+		r, err := e.next()
+		if err != nil {
+			return "", err
+		}
+
+		tab := false
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.Out, "\r\n")
+			line := string(e.buf)
+			e.History.Add(line)
+			return line, nil
+		case ctrl('C'):
+			fmt.Fprint(e.Out, "^C\r\n")
+			return "", ErrInterrupt
+		case ctrl('D'):
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.Out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos, e.pos+1)
+		case 127, ctrl('H'):
+			e.delete(e.pos-1, e.pos)
+		case ctrl('A'):
+			e.pos = 0
+		case ctrl('E'):
+			e.pos = len(e.buf)
+		case ctrl('B'):
+			e.move(-1)
+		case ctrl('F'):
+			e.move(1)
+		case ctrl('K'):
+			e.delete(e.pos, len(e.buf))
+		case ctrl('U'):
+			e.delete(0, e.pos)
+		case ctrl('W'):
+			start := e.pos
+			for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+				start--
+			}
+			e.delete(start, e.pos)
+		case ctrl('L'):
+			fmt.Fprint(e.Out, "\x1b[H\x1b[2J")
+		case ctrl('P'), ctrl('N'):
+			delta := -1
+			if r == ctrl('N') {
+				delta = 1
+			}
+			histIdx = e.historyMove(entries, histIdx, delta, &saved)
+		case ctrl('R'):
+			if err := e.search(entries); err != nil {
+				return "", err
+			}
+		case '\t':
+			tab = true
+			e.complete(lastTab)
+		case 27:
+			key, err := e.escape()
+			if err != nil {
+				return "", err
+			}
+
+			switch key {
+			case "[A", "OA":
+				histIdx = e.historyMove(entries, histIdx, -1, &saved)
+			case "[B", "OB":
+				histIdx = e.historyMove(entries, histIdx, 1, &saved)
+			case "[C", "OC":
+				e.move(1)
+			case "[D", "OD":
+				e.move(-1)
+			case "[H", "OH", "[1~", "[7~":
+				e.pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				e.pos = len(e.buf)
+			case "[3~":
+				e.delete(e.pos, e.pos+1)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.insert([]rune{r})
+			}
+		}
+
+		lastTab = tab
+		e.refresh()
+	}
+}
+
+// next читает одну руну. Терминал читается по байту, чтобы не забрать
+// лишнее из stdin, который потом получат команды.
+func (e *LineEditor) next() (rune, error) {
+	if e.unread != 0 {
+		r := e.unread
+		e.unread = 0
+		return r, nil
+	}
+
+	buf := make([]byte, utf8.UTFMax)
+	if _, err := io.ReadFull(e.In, buf[:1]); err != nil {
+		return 0, err
+	}
+
+	size := 1
+	switch {
+	case buf[0] >= 0xf0:
+		size = 4
+	case buf[0] >= 0xe0:
+		size = 3
+	case buf[0] >= 0xc0:
+		size = 2
+	}
+	if _, err := io.ReadFull(e.In, buf[1:size]); err != nil {
+		return 0, err
+	}
+
+	r, _ := utf8.DecodeRune(buf[:size])
+	return r, nil
+}
+
+// escape читает escape-последовательность после ESC, например [A или [3~.
+func (e *LineEditor) escape() (string, error) {
+	r, err := e.next()
+	if err != nil {
+		return "", err
+	}
+	if r != '[' && r != 'O' {
+		return string(r), nil
+	}
+
+	seq := []rune{r}
+	for {
+		r, err := e.next()
+		if err != nil {
+			return "", err
+		}
+		seq = append(seq, r)
+		if r >= 0x40 && r <= 0x7e {
+			return string(seq), nil
+		}
+	}
+}
+
+func (e *LineEditor) refresh() {
+	fmt.Fprintf(e.Out, "\r%s%s\x1b[K", e.prompt, string(e.buf))
+	if back := len(e.buf) - e.pos; back > 0 {
+		fmt.Fprintf(e.Out, "\x1b[%dD", back)
+	}
+}
+
+func (e *LineEditor) move(delta int) {
+	if pos := e.pos + delta; pos >= 0 && pos <= len(e.buf) {
+		e.pos = pos
+	}
+}
+
+func (e *LineEditor) insert(text []rune) {
+	buf := append([]rune{}, e.buf[:e.pos]...)
+	buf = append(buf, text...)
+	e.buf = append(buf, e.buf[e.pos:]...)
+	e.pos += len(text)
+}
+
+func (e *LineEditor) delete(from, to int) {
+	if from < 0 || to > len(e.buf) || from >= to {
+		return
+	}
+	e.buf = append(e.buf[:from], e.buf[to:]...)
+	if e.pos >= to {
+		e.pos -= to - from
+	} else if e.pos > from {
+		e.pos = from
+	}
+}
+
+func (e *LineEditor) setLine(line string) {
+	e.buf = []rune(line)
+	e.pos = len(e.buf)
+}
+
+// historyMove листает историю. Редактируемая строка запоминается в saved,
+// чтобы вернуться к ней, пролистав историю вниз до конца.
+func (e *LineEditor) historyMove(entries []string, idx int, delta int, saved *string) int {
+	next := idx + delta
+	if next < 0 || next > len(entries) {
+		return idx
+	}
+
+	if idx == len(entries) {
+		*saved = string(e.buf)
+	}
+	if next == len(entries) {
+		e.setLine(*saved)
+	} else {
+		e.setLine(entries[next])
+	}
+	return next
+}
+
+// search - инкрементальный поиск по истории (Ctrl-R). Повторный Ctrl-R
+// ищет более раннее совпадение, Ctrl-G отменяет поиск. Любая другая
+// клавиша оставляет найденную строку и обрабатывается как обычно.
+func (e *LineEditor) search(entries []string) error {
+	query := ""
+	match := string(e.buf)
+	idx := len(entries)
+	orig := string(e.buf)
+
+	find := func(from int) {
+		if from >= len(entries) {
+			from = len(entries) - 1
+		}
+		for i := from; i >= 0; i-- {
+			if strings.Contains(entries[i], query) {
+				idx, match = i, entries[i]
+				return
+			}
+		}
+	}
+
+	for {
+		fmt.Fprintf(e.Out, "\r(reverse-i-search)`%s': %s\x1b[K", query, match)
+
+		r, err := e.next()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case r == ctrl('R'):
+			find(idx - 1)
+		case r == ctrl('G'):
+			e.setLine(orig)
+			return nil
+		case r == 127 || r == ctrl('H'):
+			if query != "" {
+				_, size := utf8.DecodeLastRuneInString(query)
+				query = query[:len(query)-size]
+				idx = len(entries)
+				find(idx)
+			}
+		case unicode.IsPrint(r):
+			query += string(r)
+			if !strings.Contains(match, query) || idx == len(entries) {
+				find(idx)
+			}
+		default:
+			e.setLine(match)
+			e.unread = r
+			return nil
+		}
+	}
+}
+
+// complete дополняет слово под курсором общим префиксом вариантов.
+// Если дополнять нечего, второй Tab подряд печатает варианты.
+func (e *LineEditor) complete(again bool) {
+	if e.Complete == nil {
+		return
+	}
+
+	line := string(e.buf[:e.pos])
+	start, candidates := e.Complete(line)
+	if len(candidates) == 0 {
+		fmt.Fprint(e.Out, "\a")
+		return
+	}
+
+	word := line[start:]
+	prefix := commonPrefix(candidates)
+	if len(candidates) == 1 && !strings.HasSuffix(prefix, "/") {
+		prefix += " "
+	}
+
+	if strings.HasPrefix(prefix, word) && len(prefix) > len(word) {
+		e.insert([]rune(prefix[len(word):]))
+		return
+	}
+
+	if !again {
+		fmt.Fprint(e.Out, "\a")
+		return
+	}
+
+	fmt.Fprint(e.Out, "\r\n")
+	names := []string{}
+	width := 0
+	for _, candidate := range candidates {
+		name := strings.TrimSuffix(candidate, "/")
+		name = candidate[strings.LastIndexByte(name, '/')+1:]
+		names = append(names, name)
+		if n := utf8.RuneCountInString(name) + 2; n > width {
+			width = n
+		}
+	}
+
+	columns := 80 / width
+	if columns == 0 {
+		columns = 1
+	}
+	for idx, name := range names {
+		fmt.Fprintf(e.Out, "%-*s", width, name)
+		if (idx+1)%columns == 0 || idx+1 == len(names) {
+			fmt.Fprint(e.Out, "\r\n")
+		}
+	}
+}
+
+func commonPrefix(items []string) string {
+	prefix := items[0]
+	for _, item := range items[1:] {
+		for !strings.HasPrefix(item, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// History - история команд. Если задан файл, каждая строка дописывается
+// в него сразу, так история переживает аварийное завершение шелла.
+type History struct {
+	entries []string
+	path    string
+	limit   int
+}
+
+// LoadHistory читает историю из файла. Отсутствие файла - не ошибка.
+func LoadHistory(path string, limit int) (*History, error) {
+	h := &History{path: path, limit: limit}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	} else if err != nil {
+		return h, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+
+	// файл только растет, поэтому при загрузке обрезается до лимита
+	if len(h.entries) > limit {
+		h.entries = h.entries[len(h.entries)-limit:]
+		err = os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+	}
+	return h, err
+}
+
+// Add добавляет строку. Пустые строки и повтор предыдущей не сохраняются.
+func (h *History) Add(line string) {
+	if strings.TrimSpace(line) == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == line) {
+		return
+	}
+
+	h.entries = append(h.entries, line)
+	if h.limit > 0 && len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+func (h *History) Entries() []string {
+	return append([]string{}, h.entries...)
+}
@@ -0,0 +1,165 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineEditor(t *testing.T) {
+	history := []string{"echo first", "ls -l", "echo second"}
+	complete := func(line string) (int, []string) {
+		start := strings.LastIndexByte(line, ' ') + 1
+		candidates := []string{}
+		for _, name := range []string{"alpha", "alpine", "beta/"} {
+			if strings.HasPrefix(name, line[start:]) {
+				candidates = append(candidates, name)
+			}
+		}
+		return start, candidates
+	}
+
+	tests := []struct {
+		input string
+		line  string
+		err   error
+	}{
+		{"echo hi\r", "echo hi", nil},
+		{"héllo\n", "héllo", nil},
+		{"ac\x1b[Db\r", "abc", nil},
+		{"bc\x01a\x05d\r", "abcd", nil},
+		{"abcd\x02\x02\x0b\r", "ab", nil},
+		{"abcd\x02\x02\x15\r", "cd", nil},
+		{"echo foo bar\x17\x17\r", "echo ", nil},
+		{"abc\x7f\x7f\r", "a", nil},
+		{"abc\x1b[H\x1b[3~\x1b[F!\r", "bc!", nil},
+		{"\x1b[A\r", "echo second", nil},
+		{"\x1b[A\x1b[A\x1b[A\x1b[A\r", "echo first", nil},
+		{"new\x1b[A\x1b[A\x1b[B\x1b[B\r", "new", nil},
+		{"\x10\x10\x0e\r", "echo second", nil},
+		{"\x12ls\r", "ls -l", nil},
+		{"\x12echo\x12\r", "echo first", nil},
+		{"\x12echo\x12\x05!\r", "echo first!", nil},
+		{"typed\x12ls\x07\r", "typed", nil},
+		{"\x12zzz\r", "", nil},
+		{"\x12nz\r", "echo second", nil},
+		{"al\t\r", "alp", nil},
+		{"alph\t\r", "alpha ", nil},
+		{"cd b\tx\r", "cd beta/x", nil},
+		{"z\t\t\r", "z", nil},
+		{"abc\x03", "", ErrInterrupt},
+		{"\x04", "", io.EOF},
+		{"a\x04\r", "a", nil},
+		{"abc", "", io.EOF},
+	}
+
+	for _, test := range tests {
+		editor := &LineEditor{
+			In:       strings.NewReader(test.input),
+			Out:      io.Discard,
+			History:  &History{entries: append([]string{}, history...)},
+			Complete: complete,
+		}
+
+		line, err := editor.ReadLine("$ ")
+		if !errors.Is(err, test.err) && err != test.err {
+			t.Errorf("%q: expected error %v, got %v", test.input, test.err, err)
+			continue
+		}
+		if line != test.line {
+			t.Errorf("%q: expected %q, got %q", test.input, test.line, line)
+		}
+	}
+}
+
+func TestLineEditorListsCandidates(t *testing.T) {
+	out := &strings.Builder{}
+	editor := &LineEditor{
+		In:  strings.NewReader("cat a\t\t\r"),
+		Out: out,
+		Complete: func(line string) (int, []string) {
+			return 4, []string{"a/one", "a/two/"}
+		},
+	}
+
+	if _, err := editor.ReadLine("\n$ "); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\r\none   two/  \r\n") {
+		t.Errorf("candidates are not listed: %q", out.String())
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h, err := LoadHistory(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"a", "b", "b", " ", "c", "d"} {
+		h.Add(line)
+	}
+	if got := fmt.Sprint(h.Entries()); got != "[b c d]" {
+		t.Errorf("unexpected entries %s", got)
+	}
+
+	h, err = LoadHistory(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(h.Entries()); got != "[b c d]" {
+		t.Errorf("unexpected loaded entries %s", got)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "b\nc\nd\n" {
+		t.Errorf("history file is not truncated: %q", data)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin")
+	os.MkdirAll(filepath.Join(dir, "src", "deep"), 0755)
+	os.MkdirAll(bin, 0755)
+	os.WriteFile(filepath.Join(dir, "src", "main.go"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "my file"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, ".hidden"), nil, 0644)
+	os.WriteFile(filepath.Join(bin, "echoer"), nil, 0755)
+	os.WriteFile(filepath.Join(bin, "echo-data"), nil, 0644)
+
+	sh := NewExecShell(t)
+	sh.Vars["PWD"] = dir
+	sh.Vars["PATH"] = bin
+
+	tests := []struct {
+		line       string
+		start      int
+		candidates string
+	}{
+		{"ec", 0, `[echo echoer]`},
+		{"ls; ech", 4, `[echo echoer]`},
+		{"echo s", 5, `[src/]`},
+		{"echo src/", 5, `[src/deep/ src/main.go]`},
+		{"cat my", 4, `[my\ file]`},
+		{"cat my\\ f", 4, `[my\ file]`},
+		{"cat .h", 4, `[.hidden]`},
+		{"cat ", 4, `[bin/ my\ file src/]`},
+		{"cat < s", 6, `[src/]`},
+		{"./s", 0, `[./src/]`},
+		{"cat " + dir + "/sr", 4, "[" + dir + "/src/]"},
+		{"cat missing/", 4, `[]`},
+	}
+
+	for _, test := range tests {
+		start, candidates := sh.Complete(test.line)
+		if start != test.start || fmt.Sprint(candidates) != test.candidates {
+			t.Errorf("%q: expected %d %s, got %d %s", test.line, test.start, test.candidates, start, candidates)
+		}
+	}
+}
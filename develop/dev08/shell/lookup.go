@@ -0,0 +1,159 @@
+package shell
+
+import (
+	"fmt"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// Как шелл находит команду: алиас, затем функция или builtin из
+// Shell.Commands, затем программа из PATH, если есть exec.
+const (
+	kindAlias    = "alias"
+	kindFunction = "function"
+	kindBuiltin  = "builtin"
+	kindFile     = "file"
+)
+
+// resolve возвращает вид команды name и ее значение: текст алиаса или
+// путь программы. hashed - путь взят из кэша поиска.
+func (sh *Shell) resolve(ctx *command.Context, name string) (kind, value string, hashed bool) {
+	if value, ok := sh.Aliases[name]; ok {
+		return kindAlias, value, false
+	}
+
+	if cmd, ok := sh.Commands[name]; ok {
+		if _, ok := cmd.(*Function); ok {
+			return kindFunction, "", false
+		}
+		return kindBuiltin, "", false
+	}
+
+	if _, ok := sh.Commands["exec"]; !ok {
+		return "", "", false
+	}
+	if path, ok := sh.paths.Hashed(name, ctx.Env["PATH"]); ok {
+		return kindFile, path, true
+	}
+	if path, err := command.LookPath(name, ctx.Env["PATH"], ctx.Dir); err == nil {
+		return kindFile, path, false
+	}
+	return "", "", false
+}
+
+// TypeCmd объясняет, во что раскрывается имя команды: type в стиле bash
+// (-t - только вид, -p - только путь программы), which - кратко.
+type TypeCmd struct {
+	sh    *Shell
+	Which bool
+}
+
+func (c *TypeCmd) Run(ctx *command.Context, args []string) int {
+	name := "type"
+	if c.Which {
+		name = "which"
+	}
+
+	kindOnly, pathOnly := false, false
+	for len(args) > 0 && !c.Which {
+		if args[0] == "-t" {
+			kindOnly = true
+		} else if args[0] == "-p" {
+			pathOnly = true
+		} else {
+			break
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(ctx.Stderr, "%s: usage: %s name [name ...]\n", name, name)
+		return 2
+	}
+
+	status := 0
+	for _, arg := range args {
+		kind, value, hashed := c.sh.resolve(ctx, arg)
+		switch {
+		case kind == "":
+			status = 1
+			if c.Which {
+				fmt.Fprintf(ctx.Stdout, "%s not found\n", arg)
+			} else if !kindOnly && !pathOnly {
+				fmt.Fprintf(ctx.Stderr, "type: %s: not found\n", arg)
+			}
+		case kindOnly:
+			fmt.Fprintln(ctx.Stdout, kind)
+		case pathOnly:
+			if kind == kindFile {
+				fmt.Fprintln(ctx.Stdout, value)
+			}
+		case c.Which:
+			switch kind {
+			case kindAlias:
+				fmt.Fprintf(ctx.Stdout, "%s: aliased to %s\n", arg, value)
+			case kindFunction:
+				fmt.Fprintf(ctx.Stdout, "%s: shell function\n", arg)
+			case kindBuiltin:
+				fmt.Fprintf(ctx.Stdout, "%s: shell built-in command\n", arg)
+			default:
+				fmt.Fprintln(ctx.Stdout, value)
+			}
+		default:
+			switch {
+			case kind == kindAlias:
+				fmt.Fprintf(ctx.Stdout, "%s is aliased to `%s'\n", arg, value)
+			case kind == kindFunction:
+				fmt.Fprintf(ctx.Stdout, "%s is a function\n", arg)
+			case kind == kindBuiltin:
+				fmt.Fprintf(ctx.Stdout, "%s is a shell builtin\n", arg)
+			case hashed:
+				fmt.Fprintf(ctx.Stdout, "%s is hashed (%s)\n", arg, value)
+			default:
+				fmt.Fprintf(ctx.Stdout, "%s is %s\n", arg, value)
+			}
+		}
+	}
+	return status
+}
+
+// HashCmd - кэш поиска программ шелла: без аргументов печатает его,
+// -r очищает, имена - ищет и запоминает.
+type HashCmd struct {
+	sh *Shell
+}
+
+func (c *HashCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) > 0 && args[0] == "-r" {
+		c.sh.paths.Reset()
+		args = args[1:]
+		if len(args) == 0 {
+			return 0
+		}
+	}
+
+	if len(args) == 0 {
+		entries := c.sh.paths.Entries(ctx.Env["PATH"])
+		if len(entries) == 0 {
+			fmt.Fprintln(ctx.Stderr, "hash: hash table empty")
+			return 0
+		}
+		fmt.Fprintln(ctx.Stdout, "hits\tcommand")
+		for _, entry := range entries {
+			fmt.Fprintf(ctx.Stdout, "%4d\t%s\n", entry.Hits, entry.Path)
+		}
+		return 0
+	}
+
+	status := 0
+	for _, name := range args {
+		// builtins и функции не ищутся в PATH
+		if _, ok := c.sh.Commands[name]; ok {
+			continue
+		}
+		if _, err := c.sh.paths.Remember(name, ctx.Env["PATH"], ctx.Dir); err != nil {
+			fmt.Fprintf(ctx.Stderr, "hash: %s: not found\n", name)
+			status = 1
+		}
+	}
+	return status
+}
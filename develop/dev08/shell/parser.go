@@ -0,0 +1,612 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Грамматика (подмножество POSIX sh):
+
+	list      := and_or ((';' | '&' | newline) and_or)* [';' | '&']
+	and_or    := pipeline (('&&' | '||') newline* pipeline)*
+	pipeline  := command ('|' newline* command)*
+	command   := simple | compound redirect* | funcdef
+	compound  := '(' list ')' | '{' list '}' | if | while | until | for | case
+	if        := 'if' list 'then' list ('elif' list 'then' list)* ['else' list] 'fi'
+	while     := ('while' | 'until') list 'do' list 'done'
+	for       := 'for' NAME ['in' WORD*] [';'] newline* 'do' list 'done'
+	case      := 'case' WORD newline* 'in' newline* (['('] WORD ('|' WORD)* ')' list [';;'] newline*)* 'esac'
+	funcdef   := NAME '(' ')' newline* compound
+	simple    := (WORD | redirect)+
+	redirect  := [N] ('<' | '>' | '>>' | '<&' | '>&' | '<<' | '<<-') WORD
+
+Ключевые слова распознаются только без кавычек и в начале команды:
+echo fi - обычная команда с аргументом fi.
+*/
+
+type Node interface {
+	node()
+}
+
+// List - последовательность and_or, каждый может быть запущен в фоне.
+type List struct {
+	Items []*ListItem
+}
+
+type ListItem struct {
+	AndOr      *AndOr
+	Background bool
+	Text       string // исходный текст, показывается в jobs
+}
+
+type AndOr struct {
+	First *Pipeline
+	Rest  []AndOrItem
+}
+
+type AndOrItem struct {
+	Op       string // && или ||
+	Pipeline *Pipeline
+}
+
+type Pipeline struct {
+	Commands []Node
+	Text     string // исходный текст, показывается в jobs для остановленного конвейера
+}
+
+type SimpleCommand struct {
+	Args      []Word
+	Redirects []*Redirect
+}
+
+type Subshell struct {
+	Body      *List
+	Redirects []*Redirect
+}
+
+// BraceGroup - { list; }, выполняется в текущем шелле.
+type BraceGroup struct {
+	Body      *List
+	Redirects []*Redirect
+}
+
+type If struct {
+	Clauses   []IfClause // if и elif
+	Else      *List
+	Redirects []*Redirect
+}
+
+type IfClause struct {
+	Cond *List
+	Body *List
+}
+
+// Loop - while, или until при Until.
+type Loop struct {
+	Until     bool
+	Cond      *List
+	Body      *List
+	Redirects []*Redirect
+}
+
+// For перебирает Words, а без in - позиционные параметры.
+type For struct {
+	Var       string
+	Words     []Word
+	HasIn     bool
+	Body      *List
+	Redirects []*Redirect
+}
+
+type Case struct {
+	Word      Word
+	Items     []CaseItem
+	Redirects []*Redirect
+}
+
+type CaseItem struct {
+	Patterns []Word
+	Body     *List
+}
+
+// FuncDef - определение функции name() compound.
+type FuncDef struct {
+	Name string
+	Body Node
+}
+
+// Redirect - перенаправление дескриптора Fd. Для here-doc Target содержит
+// разделитель, а Body - текст документа.
+type Redirect struct {
+	Op     string
+	Fd     int
+	Target Word
+	Body   string
+}
+
+func (*List) node()          {}
+func (*AndOr) node()         {}
+func (*Pipeline) node()      {}
+func (*SimpleCommand) node() {}
+func (*Subshell) node()      {}
+func (*BraceGroup) node()    {}
+func (*If) node()            {}
+func (*Loop) node()          {}
+func (*For) node()           {}
+func (*Case) node()          {}
+func (*FuncDef) node()       {}
+
+// Compound - составная команда, после которой можно указать перенаправления.
+type Compound interface {
+	Node
+	redirects() *[]*Redirect
+}
+
+func (n *Subshell) redirects() *[]*Redirect   { return &n.Redirects }
+func (n *BraceGroup) redirects() *[]*Redirect { return &n.Redirects }
+func (n *If) redirects() *[]*Redirect         { return &n.Redirects }
+func (n *Loop) redirects() *[]*Redirect       { return &n.Redirects }
+func (n *For) redirects() *[]*Redirect        { return &n.Redirects }
+func (n *Case) redirects() *[]*Redirect       { return &n.Redirects }
+
+// слова, завершающие список внутри составной команды
+var terminators = []string{"then", "elif", "else", "fi", "do", "done", "esac", "}"}
+
+type Parser struct {
+	src    string
+	tokens []Token
+	pos    int
+}
+
+// Parse разбирает исходный текст в AST. Если текст оборван (незакрытые кавычки,
+// скобки или оператор в конце), ошибка оборачивает ErrIncomplete.
+func Parse(src string) (*List, error) {
+	tokens, err := Lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Parser{src: src, tokens: tokens}
+	list, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.Kind != TokEOF {
+		return nil, p.unexpected(tok)
+	}
+
+	return list, nil
+}
+
+func (p *Parser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *Parser) advance() Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != TokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *Parser) isOp(ops ...string) bool {
+	tok := p.peek()
+	if tok.Kind != TokOp {
+		return false
+	}
+	for _, op := range ops {
+		if tok.Op == op {
+			return true
+		}
+	}
+	return false
+}
+
+// isWord проверяет, что следующий токен - ключевое слово из words.
+func (p *Parser) isWord(words ...string) bool {
+	tok := p.peek()
+	if tok.Kind != TokWord || tok.Word.Quoted() {
+		return false
+	}
+	for _, word := range words {
+		if tok.Word.String() == word {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) expectWord(word string) error {
+	if !p.isWord(word) {
+		return p.unexpected(p.peek())
+	}
+	p.advance()
+	return nil
+}
+
+func (p *Parser) expectOp(op string) error {
+	if !p.isOp(op) {
+		return p.unexpected(p.peek())
+	}
+	p.advance()
+	return nil
+}
+
+func (p *Parser) skipNewlines() {
+	for p.peek().Kind == TokNewline {
+		p.advance()
+	}
+}
+
+func (p *Parser) unexpected(tok Token) error {
+	if tok.Kind == TokEOF {
+		return fmt.Errorf("sh: syntax error: %w", ErrIncomplete)
+	}
+	return fmt.Errorf("sh: syntax error near unexpected token `%s'", tok)
+}
+
+// list читает and_or до конца ввода или закрывающей скобки.
+func (p *Parser) list() (*List, error) {
+	list := &List{}
+	p.skipNewlines()
+
+	for (p.peek().Kind == TokWord && !p.isWord(terminators...)) || p.isOp("(") || p.isRedirect() {
+		start := p.peek().Pos
+		andOr, err := p.andOr()
+		if err != nil {
+			return nil, err
+		}
+
+		item := &ListItem{AndOr: andOr}
+		item.Text = strings.TrimSpace(p.src[start:p.tokens[p.pos-1].End])
+		list.Items = append(list.Items, item)
+
+		switch {
+		case p.isOp("&"):
+			item.Background = true
+			p.advance()
+		case p.isOp(";"):
+			p.advance()
+		case p.peek().Kind == TokNewline:
+		default:
+			return list, nil
+		}
+		p.skipNewlines()
+	}
+
+	return list, nil
+}
+
+func (p *Parser) andOr() (*AndOr, error) {
+	first, err := p.pipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	andOr := &AndOr{First: first}
+	for p.isOp("&&", "||") {
+		op := p.advance().Op
+		p.skipNewlines()
+
+		next, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		andOr.Rest = append(andOr.Rest, AndOrItem{Op: op, Pipeline: next})
+	}
+
+	return andOr, nil
+}
+
+func (p *Parser) pipeline() (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	start := p.peek().Pos
+	for {
+		cmd, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Commands = append(pipeline.Commands, cmd)
+
+		if !p.isOp("|") {
+			pipeline.Text = strings.TrimSpace(p.src[start:p.tokens[p.pos-1].End])
+			return pipeline, nil
+		}
+		p.advance()
+		p.skipNewlines()
+	}
+}
+
+func (p *Parser) command() (Node, error) {
+	if p.isFuncDef() {
+		return p.funcDef()
+	}
+
+	var cmd Compound
+	var err error
+	switch {
+	case p.isOp("("):
+		cmd, err = p.subshell()
+	case p.isWord("{"):
+		cmd, err = p.braceGroup()
+	case p.isWord("if"):
+		cmd, err = p.ifCommand()
+	case p.isWord("while", "until"):
+		cmd, err = p.loop()
+	case p.isWord("for"):
+		cmd, err = p.forCommand()
+	case p.isWord("case"):
+		cmd, err = p.caseCommand()
+	default:
+		return p.simple()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isRedirect() {
+		redirect, err := p.redirect()
+		if err != nil {
+			return nil, err
+		}
+		*cmd.redirects() = append(*cmd.redirects(), redirect)
+	}
+	return cmd, nil
+}
+
+func (p *Parser) simple() (Node, error) {
+	cmd := &SimpleCommand{}
+	for p.peek().Kind == TokWord || p.isRedirect() {
+		if p.peek().Kind == TokWord {
+			cmd.Args = append(cmd.Args, p.advance().Word)
+			continue
+		}
+
+		redirect, err := p.redirect()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Redirects = append(cmd.Redirects, redirect)
+	}
+
+	if len(cmd.Args) == 0 && len(cmd.Redirects) == 0 {
+		return nil, p.unexpected(p.peek())
+	}
+
+	return cmd, nil
+}
+
+// body читает непустой список команд.
+func (p *Parser) body() (*List, error) {
+	list, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, p.unexpected(p.peek())
+	}
+	return list, nil
+}
+
+func (p *Parser) subshell() (Compound, error) {
+	p.advance()
+	body, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	if len(body.Items) == 0 {
+		return nil, p.unexpected(p.tokens[p.pos-1])
+	}
+	return &Subshell{Body: body}, nil
+}
+
+func (p *Parser) braceGroup() (Compound, error) {
+	p.advance()
+	body, err := p.body()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectWord("}"); err != nil {
+		return nil, err
+	}
+	return &BraceGroup{Body: body}, nil
+}
+
+func (p *Parser) ifCommand() (Compound, error) {
+	cmd := &If{}
+
+	for p.isWord("if", "elif") {
+		p.advance()
+		cond, err := p.body()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectWord("then"); err != nil {
+			return nil, err
+		}
+		body, err := p.body()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Clauses = append(cmd.Clauses, IfClause{Cond: cond, Body: body})
+	}
+
+	if p.isWord("else") {
+		p.advance()
+		body, err := p.body()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Else = body
+	}
+
+	if err := p.expectWord("fi"); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func (p *Parser) loop() (Compound, error) {
+	cmd := &Loop{Until: p.advance().Word.String() == "until"}
+
+	var err error
+	if cmd.Cond, err = p.body(); err != nil {
+		return nil, err
+	}
+	if cmd.Body, err = p.doGroup(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// doGroup читает do list done.
+func (p *Parser) doGroup() (*List, error) {
+	if err := p.expectWord("do"); err != nil {
+		return nil, err
+	}
+	body, err := p.body()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectWord("done"); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (p *Parser) forCommand() (Compound, error) {
+	p.advance()
+
+	tok := p.peek()
+	if tok.Kind != TokWord || tok.Word.Quoted() || !IsName(tok.Word.String()) {
+		return nil, p.unexpected(tok)
+	}
+	cmd := &For{Var: p.advance().Word.String()}
+
+	p.skipNewlines()
+	if p.isWord("in") {
+		p.advance()
+		cmd.HasIn = true
+		for p.peek().Kind == TokWord {
+			cmd.Words = append(cmd.Words, p.advance().Word)
+		}
+	}
+	if p.isOp(";") {
+		p.advance()
+	}
+	p.skipNewlines()
+
+	var err error
+	if cmd.Body, err = p.doGroup(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func (p *Parser) caseCommand() (Compound, error) {
+	p.advance()
+
+	if p.peek().Kind != TokWord {
+		return nil, p.unexpected(p.peek())
+	}
+	cmd := &Case{Word: p.advance().Word}
+
+	p.skipNewlines()
+	if err := p.expectWord("in"); err != nil {
+		return nil, err
+	}
+	p.skipNewlines()
+
+	for !p.isWord("esac") {
+		if p.isOp("(") {
+			p.advance()
+		}
+
+		item := CaseItem{}
+		for {
+			if p.peek().Kind != TokWord {
+				return nil, p.unexpected(p.peek())
+			}
+			item.Patterns = append(item.Patterns, p.advance().Word)
+			if !p.isOp("|") {
+				break
+			}
+			p.advance()
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if item.Body, err = p.list(); err != nil {
+			return nil, err
+		}
+		cmd.Items = append(cmd.Items, item)
+
+		if !p.isOp(";;") {
+			break
+		}
+		p.advance()
+		p.skipNewlines()
+	}
+
+	if err := p.expectWord("esac"); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func (p *Parser) isFuncDef() bool {
+	if p.pos+2 >= len(p.tokens) {
+		return false
+	}
+	name, open, closing := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	return name.Kind == TokWord && !name.Word.Quoted() && IsName(name.Word.String()) &&
+		open.Kind == TokOp && open.Op == "(" && closing.Kind == TokOp && closing.Op == ")"
+}
+
+func (p *Parser) funcDef() (Node, error) {
+	name := p.advance().Word.String()
+	p.advance()
+	p.advance()
+	p.skipNewlines()
+
+	if p.peek().Kind == TokWord && !p.isWord("{", "if", "while", "until", "for", "case") {
+		return nil, p.unexpected(p.peek())
+	}
+	body, err := p.command()
+	if err != nil {
+		return nil, err
+	}
+	return &FuncDef{Name: name, Body: body}, nil
+}
+
+func (p *Parser) isRedirect() bool {
+	tok := p.peek()
+	return tok.Kind == TokOp && IsRedirectOp(tok.Op)
+}
+
+func (p *Parser) redirect() (*Redirect, error) {
+	op := p.advance()
+	if tok := p.peek(); tok.Kind == TokEOF {
+		// в отличие от "a |", после "a >" строка не продолжается
+		return nil, fmt.Errorf("sh: syntax error near unexpected token `newline'")
+	} else if tok.Kind != TokWord {
+		return nil, p.unexpected(tok)
+	}
+
+	redirect := &Redirect{Op: op.Op, Fd: op.Fd, Target: p.advance().Word, Body: op.Body}
+	if redirect.Fd < 0 {
+		redirect.Fd = 1
+		if strings.HasPrefix(op.Op, "<") {
+			redirect.Fd = 0
+		}
+	}
+	return redirect, nil
+}
@@ -0,0 +1,255 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Dump печатает AST в компактном виде для сравнения в тестах.
+func Dump(node Node) string {
+	switch n := node.(type) {
+	case *List:
+		items := []string{}
+		for _, item := range n.Items {
+			s := Dump(item.AndOr)
+			if item.Background {
+				s += " &"
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, "; ")
+	case *AndOr:
+		s := Dump(n.First)
+		for _, item := range n.Rest {
+			s += " " + item.Op + " " + Dump(item.Pipeline)
+		}
+		return s
+	case *Pipeline:
+		cmds := []string{}
+		for _, cmd := range n.Commands {
+			cmds = append(cmds, Dump(cmd))
+		}
+		return strings.Join(cmds, " | ")
+	case *SimpleCommand:
+		args := []string{}
+		for _, arg := range n.Args {
+			args = append(args, fmt.Sprintf("%q", arg.String()))
+		}
+		return "[" + strings.Join(args, " ") + "]" + DumpRedirects(n.Redirects)
+	case *Subshell:
+		return "(" + Dump(n.Body) + ")" + DumpRedirects(n.Redirects)
+	case *BraceGroup:
+		return "{ " + Dump(n.Body) + " }" + DumpRedirects(n.Redirects)
+	case *If:
+		s := ""
+		for _, clause := range n.Clauses {
+			s += "if " + Dump(clause.Cond) + " then " + Dump(clause.Body) + " "
+		}
+		if n.Else != nil {
+			s += "else " + Dump(n.Else) + " "
+		}
+		return s + "fi" + DumpRedirects(n.Redirects)
+	case *Loop:
+		s := "while "
+		if n.Until {
+			s = "until "
+		}
+		return s + Dump(n.Cond) + " do " + Dump(n.Body) + " done" + DumpRedirects(n.Redirects)
+	case *For:
+		s := "for " + n.Var
+		if n.HasIn {
+			s += " in"
+			for _, word := range n.Words {
+				s += fmt.Sprintf(" %q", word.String())
+			}
+		}
+		return s + " do " + Dump(n.Body) + " done" + DumpRedirects(n.Redirects)
+	case *Case:
+		items := []string{}
+		for _, item := range n.Items {
+			patterns := []string{}
+			for _, pattern := range item.Patterns {
+				patterns = append(patterns, fmt.Sprintf("%q", pattern.String()))
+			}
+			items = append(items, strings.Join(patterns, "|")+") "+Dump(item.Body))
+		}
+		return fmt.Sprintf("case %q in ", n.Word.String()) + strings.Join(items, " ;; ") + " esac" + DumpRedirects(n.Redirects)
+	case *FuncDef:
+		return n.Name + "() " + Dump(n.Body)
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+func DumpRedirects(redirects []*Redirect) string {
+	s := ""
+	for _, r := range redirects {
+		s += fmt.Sprintf(" %d%s%q", r.Fd, r.Op, r.Target.String())
+		if r.Body != "" {
+			s += fmt.Sprintf("%q", r.Body)
+		}
+	}
+	return s
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		src    string
+		tokens []string
+	}{
+		{`echo hello   world`, []string{"echo", "hello", "world"}},
+		{`echo "a b" 'c d'`, []string{"echo", "a b", "c d"}},
+		{`echo a\ b`, []string{"echo", "a b"}},
+		{`echo "a\"b" 'a\"b'`, []string{"echo", `a"b`, `a\"b`}},
+		{`echo "\a"`, []string{"echo", `\a`}},
+		{`echo "" ''`, []string{"echo", "", ""}},
+		{`echo pre"mid"'post'`, []string{"echo", "premidpost"}},
+		{`a|b&&c||d;e&`, []string{"a", "|", "b", "&&", "c", "||", "d", ";", "e", "&"}},
+		{`(a)`, []string{"(", "a", ")"}},
+		{`echo a # comment`, []string{"echo", "a"}},
+		{`echo a#b`, []string{"echo", "a#b"}},
+		{"echo a \\\nb", []string{"echo", "a", "b"}},
+		{"a\nb", []string{"a", "newline", "b"}},
+		{`a>b 2>>c <d`, []string{"a", ">", "b", ">>", "c", "<", "d"}},
+		{`a 2>&1 a2>b`, []string{"a", ">&", "1", "a2", ">", "b"}},
+		{`echo "a>b" a\>b`, []string{"echo", "a>b", "a>b"}},
+		{`echo $(a | (b)) ${X:-a b}x "$(c ")")"`, []string{"echo", "$(a | (b))", "${X:-a b}x", `$(c ")")`}},
+		{"cat <<EOF\nx\nEOF\nls", []string{"cat", "<<", "EOF", "newline", "ls"}},
+	}
+
+	for _, test := range tests {
+		tokens, err := Lex(test.src)
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+
+		got := []string{}
+		for _, tok := range tokens[:len(tokens)-1] {
+			got = append(got, tok.String())
+		}
+
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", test.tokens) {
+			t.Errorf("%q:\nexpected: %q\ngot: %q", test.src, test.tokens, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src string
+		ast string
+	}{
+		{``, ``},
+		{`# only comment`, ``},
+		{`echo "a b"`, `["echo" "a b"]`},
+		{`a|b`, `["a"] | ["b"]`},
+		{`a | b | c`, `["a"] | ["b"] | ["c"]`},
+		{`a && b || c`, `["a"] && ["b"] || ["c"]`},
+		{`a; b & c`, `["a"]; ["b"] &; ["c"]`},
+		{`a &`, `["a"] &`},
+		{`(a; b) | c`, `(["a"]; ["b"]) | ["c"]`},
+		{`(a && (b))`, `(["a"] && (["b"]))`},
+		{"a\nb\n\nc", `["a"]; ["b"]; ["c"]`},
+		{"a &&\nb", `["a"] && ["b"]`},
+		{"a |\n b", `["a"] | ["b"]`},
+		{`a > out`, `["a"] 1>"out"`},
+		{`a 2>err <in b`, `["a" "b"] 2>"err" 0<"in"`},
+		{`a >out 2>&1`, `["a"] 1>"out" 2>&"1"`},
+		{`>out`, `[] 1>"out"`},
+		{`(a; b) >>log | c`, `(["a"]; ["b"]) 1>>"log" | ["c"]`},
+		{"cat <<EOF\nline $x\n  EOF\nEOF\nls", `["cat"] 0<<"EOF""line $x\n  EOF\n"; ["ls"]`},
+		{"cat <<-END\n\t\tx\n\tEND", `["cat"] 0<<-"END""x\n"`},
+		{"cat <<A <<B\na\nA\nb\nB", `["cat"] 0<<"A""a\n" 0<<"B""b\n"`},
+		{`{ a; b; } > out`, `{ ["a"]; ["b"] } 1>"out"`},
+		{`if a; then b; fi`, `if ["a"] then ["b"] fi`},
+		{"if a\nthen\n b\nelif c; then d\nelse e; fi | f", `if ["a"] then ["b"] if ["c"] then ["d"] else ["e"] fi | ["f"]`},
+		{`echo if then fi`, `["echo" "if" "then" "fi"]`},
+		{`"if" a`, `["if" "a"]`},
+		{`while a; do b; done`, `while ["a"] do ["b"] done`},
+		{`until a && b; do c; done 2>err`, `until ["a"] && ["b"] do ["c"] done 2>"err"`},
+		{`for x in a "b c" $y; do echo $x; done`, `for x in "a" "b c" "$y" do ["echo" "$x"] done`},
+		{"for x\ndo a; done", `for x do ["a"] done`},
+		{`for x in; do a; done`, `for x in do ["a"] done`},
+		{"case $x in\n a|b) c;;\n (*) d; e ;;\nesac", `case "$x" in "a"|"b") ["c"] ;; "*") ["d"]; ["e"] esac`},
+		{`case x in a) b; esac`, `case "x" in "a") ["b"] esac`},
+		{`case x in esac`, `case "x" in  esac`},
+		{"f() { echo $1; }; f a", `f() { ["echo" "$1"] }; ["f" "a"]`},
+		{"f()\n(a)", `f() (["a"])`},
+		{`f() if a; then b; fi`, `f() if ["a"] then ["b"] fi`},
+	}
+
+	for _, test := range tests {
+		list, err := Parse(test.src)
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		if got := Dump(list); got != test.ast {
+			t.Errorf("%q:\nexpected: %s\ngot: %s", test.src, test.ast, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src        string
+		incomplete bool
+	}{
+		{`echo "a`, true},
+		{`echo 'a`, true},
+		{`echo a\`, true},
+		{`a |`, true},
+		{`a &&`, true},
+		{`(a`, true},
+		{"cat <<EOF", true},
+		{`echo $(a`, true},
+		{`echo ${a`, true},
+		{"cat <<EOF\nline", true},
+		{`a >`, false},
+		{`a > | b`, false},
+		{`| a`, false},
+		{`a ;;`, false},
+		{`a )`, false},
+		{`()`, false},
+		{`a & & b`, false},
+		{`if a; then b`, true},
+		{`if a; then`, true},
+		{"while a\ndo", true},
+		{`for x in a b`, true},
+		{`case x in a) b`, true},
+		{`f()`, true},
+		{`{ a`, true},
+		{`if a; fi`, false},
+		{`if; then a; fi`, false},
+		{`while a; do done`, false},
+		{`for "x" in a; do b; done`, false},
+		{`case x in a b) c;; esac`, false},
+		{`f() a`, false},
+		{`fi`, false},
+		{`a; done`, false},
+		{`{ }`, false},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.src)
+		if err == nil {
+			t.Errorf("%q: expected error", test.src)
+			continue
+		}
+		if errors.Is(err, ErrIncomplete) != test.incomplete {
+			t.Errorf("%q: incomplete expected %v, got %v", test.src, test.incomplete, err)
+		}
+	}
+}
+
+func TestJobText(t *testing.T) {
+	list, err := Parse(`sleep 1 &&  echo "x y" & ls`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := list.Items[0].Text; text != `sleep 1 &&  echo "x y"` {
+		t.Errorf("unexpected job text %q", text)
+	}
+}
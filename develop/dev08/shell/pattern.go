@@ -0,0 +1,144 @@
+package shell
+
+import "strings"
+
+// MatchPattern сопоставляет строку с шаблоном sh: * - любая строка,
+// ? - любой символ, [abc], [a-z], [!a] - классы, \ экранирует следующий
+// символ. В отличие от path.Match, * совпадает и с /.
+func MatchPattern(pattern, s string) bool {
+	return matchPattern([]rune(pattern), []rune(s))
+}
+
+func matchPattern(p, s []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			for len(p) > 0 && p[0] == '*' {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchPattern(p, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			p, s = p[1:], s[1:]
+			continue
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			if matched, n := matchClass(p, s[0]); n > 0 {
+				if !matched {
+					return false
+				}
+				p, s = p[n:], s[1:]
+				continue
+			}
+		case '\\':
+			if len(p) > 1 {
+				p = p[1:]
+			}
+		}
+
+		// обычный символ или [ без закрывающей скобки
+		if len(s) == 0 || p[0] != s[0] {
+			return false
+		}
+		p, s = p[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass проверяет символ по классу [...] в начале p и возвращает
+// длину класса. Если класс не закрыт, длина 0: [ - обычный символ.
+func matchClass(p []rune, c rune) (bool, int) {
+	i := 1
+	negate := i < len(p) && (p[i] == '!' || p[i] == '^')
+	if negate {
+		i++
+	}
+
+	matched := false
+	for first := true; i < len(p); first = false {
+		if p[i] == ']' && !first {
+			return matched != negate, i + 1
+		}
+
+		lo := p[i]
+		if lo == '\\' && i+1 < len(p) {
+			i++
+			lo = p[i]
+		}
+		hi := lo
+		if i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']' {
+			hi = p[i+2]
+			i += 2
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+		i++
+	}
+	return false, 0
+}
+
+// patternWord раскрывает слово как шаблон: символы из кавычек
+// экранируются и совпадают только сами с собой.
+func (sh *Shell) patternWord(word Word, stdio IO) (string, error) {
+	b := strings.Builder{}
+	for _, part := range word {
+		text := part.Text
+		if part.Quote != SingleQuoted {
+			var err error
+			if text, err = sh.expandText(text, false, stdio); err != nil {
+				return "", err
+			}
+		}
+
+		if part.Quote == Unquoted {
+			b.WriteString(text)
+		} else {
+			b.WriteString(escapePattern(text))
+		}
+	}
+	return b.String(), nil
+}
+
+// escapePattern экранирует символы шаблона: строка совпадет только сама с собой.
+func escapePattern(s string) string {
+	b := strings.Builder{}
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// hasGlobMeta сообщает, есть ли в шаблоне неэкранированные *, ? или
+// закрытый класс [...].
+func hasGlobMeta(pattern string) bool {
+	p := []rune(pattern)
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case '*', '?':
+			return true
+		case '[':
+			if _, n := matchClass(p[i:], 0); n > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+const usage = `usage: gosh [-c command [name [args...]] | -s [-a commands] [-e vars] [-t idle] address | script [args...]]`
+
+// Main разбирает аргументы командной строки и выбирает режим:
+//
+//	gosh -c 'cmd' [name [args...]]  - выполнить строку, $0 = name
+//	gosh -s [-a ...] [-e ...] addr  - удаленный шелл на host:port или unix:/path
+//	gosh script.sh [args...]        - выполнить файл
+//	gosh < script.sh                - stdin не терминал: читать команды без приглашений
+//	gosh                            - интерактивный режим
+//
+// Возвращает код возврата последней команды или переданный в exit.
+func (sh *Shell) Main(args []string, stdin io.Reader, stdout io.WriteCloser, stderr io.Writer) int {
+	stdio := IO{stdin, stdout, stderr}
+	sh.SetArgs("gosh", nil)
+
+	switch {
+	case len(args) > 0 && args[0] == "-c":
+		if len(args) < 2 {
+			fmt.Fprintf(stderr, "gosh: -c: option requires an argument\n%s\n", usage)
+			return 2
+		}
+		if len(args) > 2 {
+			sh.SetArgs(args[2], args[3:])
+		}
+		return sh.Interpret(NewScanLines(strings.NewReader(args[1]), io.Discard), false, stdio)
+	case len(args) > 0 && args[0] == "-s":
+		return sh.serve(args[1:], stderr)
+	case len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "-":
+		fmt.Fprintf(stderr, "gosh: %s: invalid option\n%s\n", args[0], usage)
+		return 2
+	case len(args) > 0:
+		f, err := os.Open(sh.path(args[0]))
+		if err != nil {
+			fmt.Fprintf(stderr, "gosh: %s: %v\n", args[0], errors.Unwrap(err))
+			return 127
+		}
+		defer f.Close()
+
+		sh.SetArgs(args[0], args[1:])
+		return sh.Interpret(NewScanLines(f, io.Discard), false, stdio)
+	}
+
+	if f, ok := stdin.(*os.File); !ok || !isTerminal(f) {
+		return sh.Interpret(NewScanLines(stdin, io.Discard), false, stdio)
+	}
+	return sh.Run(stdin, stdout, stderr)
+}
+
+// Interpret читает и выполняет команды, пока не кончится ввод или не будет
+// вызван exit. Строки с незакрытыми кавычками или оператором в конце
+// дочитываются. Вне интерактивного режима синтаксическая ошибка
+// прерывает выполнение с кодом 2, как в sh.
+func (sh *Shell) Interpret(lines LineReader, interactive bool, stdio IO) int {
+	prompt := func() string {
+		if !interactive {
+			return ""
+		}
+		return sh.prompt(stdio.Stdout)
+	}
+
+	status := 0
+	pending := ""
+	current := prompt()
+
+	for {
+		text, err := lines.ReadLine(current)
+		if errors.Is(err, ErrInterrupt) {
+			pending = ""
+			current = prompt()
+			continue
+		}
+		if err != nil {
+			if pending != "" {
+				fmt.Fprintln(stdio.Stderr, "sh: syntax error: unexpected end of file")
+				return sh.setStatus(2)
+			}
+			if interactive {
+				fmt.Fprintln(stdio.Stdout, "Goodbye! :(")
+			}
+			return status
+		}
+
+		line := pending + text
+		list, err := Parse(line)
+		if errors.Is(err, ErrIncomplete) {
+			pending = line + "\n"
+			if interactive {
+				current = "> "
+			}
+			continue
+		}
+		pending = ""
+
+		if err != nil {
+			fmt.Fprintln(stdio.Stderr, err)
+			status = sh.setStatus(2)
+			if !interactive {
+				return status
+			}
+		} else {
+			status = sh.RunList(list, stdio)
+		}
+		if interactive {
+			// Ctrl-C прерывает только выполняемую строку
+			sh.ctl.clearInterrupt()
+		}
+
+		if sh.exited {
+			if interactive {
+				fmt.Fprintln(stdio.Stdout, "Goodbye! :(")
+			}
+			return sh.exitCode
+		}
+		// return или break в файле, выполняемом source
+		if sh.stopped() {
+			return status
+		}
+		current = prompt()
+	}
+}
+
+// Source выполняет файл в текущем шелле: переменные и exit действуют на него,
+// return завершает только файл.
+func (sh *Shell) Source(name string, stdio IO) int {
+	f, err := os.Open(sh.path(name))
+	if err != nil {
+		fmt.Fprintf(stdio.Stderr, "sh: %s: %v\n", name, errors.Unwrap(err))
+		return 1
+	}
+	defer f.Close()
+
+	sh.callDepth++
+	status := sh.Interpret(NewScanLines(f, io.Discard), false, stdio)
+	sh.callDepth--
+	sh.returning = false
+	return status
+}
+
+func (sh *Shell) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(sh.Vars["PWD"], name)
+}
+
+// SetArgs задает $0 и позиционные параметры $1, $2, ..., $#.
+func (sh *Shell) SetArgs(name string, args []string) {
+	sh.Vars["0"] = name
+	setPositional(sh.Vars, args)
+}
+
+func setPositional(vars map[string]string, args []string) {
+	for name := range vars {
+		if n, err := strconv.Atoi(name); err == nil && n > 0 {
+			delete(vars, name)
+		}
+	}
+	for idx, arg := range args {
+		vars[strconv.Itoa(idx+1)] = arg
+	}
+	vars["#"] = strconv.Itoa(len(args))
+}
+
+func positional(vars map[string]string) []string {
+	n, _ := strconv.Atoi(vars["#"])
+	args := []string{}
+	for idx := 1; idx <= n; idx++ {
+		args = append(args, vars[strconv.Itoa(idx)])
+	}
+	return args
+}
+
+// ExitCmd завершает шелл с кодом из аргумента, без аргумента - с $?.
+// В подоболочке завершается только она.
+type ExitCmd struct {
+	sh *Shell
+}
+
+func (c *ExitCmd) Run(ctx *command.Context, args []string) int {
+	code, _ := strconv.Atoi(ctx.Vars["?"])
+	if len(args) > 0 {
+		var err error
+		if code, err = strconv.Atoi(args[0]); err != nil {
+			fmt.Fprintf(ctx.Stderr, "exit: %s: numeric argument required\n", args[0])
+			code = 2
+		}
+	}
+
+	c.sh.exited = true
+	c.sh.exitCode = code & 0xff
+	return c.sh.exitCode
+}
+
+// SourceCmd - source file [args...] и . file [args...].
+type SourceCmd struct {
+	sh *Shell
+}
+
+func (c *SourceCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(ctx.Stderr, "source: filename argument required")
+		return 2
+	}
+
+	if len(args) > 1 {
+		saved := positional(ctx.Vars)
+		setPositional(ctx.Vars, args[1:])
+		defer setPositional(ctx.Vars, saved)
+	}
+
+	return c.sh.Source(args[0], IO{ctx.Stdin, ctx.Stdout, ctx.Stderr})
+}
+
+// ShiftCmd сдвигает позиционные параметры на n (по умолчанию 1).
+type ShiftCmd struct{}
+
+func (c *ShiftCmd) Run(ctx *command.Context, args []string) int {
+	n := 1
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 0 {
+			fmt.Fprintf(ctx.Stderr, "shift: %s: numeric argument required\n", args[0])
+			return 1
+		}
+	}
+
+	params := positional(ctx.Vars)
+	if n > len(params) {
+		return 1
+	}
+	setPositional(ctx.Vars, params[n:])
+	return 0
+}
@@ -0,0 +1,82 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellMain(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "args.sh"), []byte("echo $0 $# $1\nshift\necho \"[$@]\"\nexit $#\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.sh"), []byte("echo before\necho )\necho after\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "lib.sh"), []byte("LIB=loaded\nstatus 7\n"), 0644)
+
+	tests := []struct {
+		args   []string
+		stdin  string
+		stdout string
+		status int
+	}{
+		{[]string{"-c", "echo a; status 3"}, "", "a", 3},
+		{[]string{"-c", "echo $0 $1 $2", "name", "x", "y z"}, "", "name x y z", 0},
+		{[]string{"-c", "echo a )"}, "", "", 2},
+		{[]string{"-c"}, "", "", 2},
+		{[]string{"-x"}, "", "", 2},
+		{[]string{"args.sh", "a b", "c", "d"}, "", "args.sh 3 a b[c d]", 2},
+		{[]string{"args.sh"}, "", "args.sh 0[]", 0},
+		{[]string{"broken.sh"}, "", "before", 2},
+		{[]string{"missing.sh"}, "", "", 127},
+		{nil, "echo a\nstatus 4\n", "a", 4},
+		{nil, "echo \"multi\nline\"\n", "multi\nline", 0},
+		{nil, "echo a; exit 5; echo b\necho c\n", "a", 5},
+		{nil, "status 6\nexit\n", "", 6},
+		{nil, "(exit 3); echo $?\n", "3", 0},
+		{nil, "exit 3 && echo no\n", "", 3},
+		{nil, "exit abc\n", "", 2},
+		{nil, "echo \"open\n", "", 2},
+		{nil, "source lib.sh; echo $LIB $?\n", "loaded 7", 0},
+		{nil, ". lib.sh x; echo $#\n", "0", 0},
+		{nil, "set -- 1 2 3; shift 2; echo $1 $#; shift 5\n", "3 1", 1},
+		{nil, "set -- 'a b' c; setvar N \"$@\"; echo $N; echo \"<$*>\"\n", "a b<a b c>", 0},
+		{nil, "greet() {\n  for x in \"$@\"\n  do\n    echo $x\n  done\n}\ngreet a b\n", "ab", 0},
+		{nil, "if false\nthen echo a\nelse\n  status 3\nfi\n", "", 3},
+		{nil, "while true; do\n", "", 2},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		sh.Vars["PWD"] = dir
+		out := &SafeBuffer{}
+
+		status := sh.Main(test.args, strings.NewReader(test.stdin), out, io.Discard)
+		if status != test.status {
+			t.Errorf("%q %q: expected status %d, got %d", test.args, test.stdin, test.status, status)
+		}
+		if out.String() != test.stdout {
+			t.Errorf("%q %q: expected stdout %q, got %q", test.args, test.stdin, test.stdout, out.String())
+		}
+	}
+}
+
+func TestRunRC(t *testing.T) {
+	home := t.TempDir()
+	os.WriteFile(filepath.Join(home, ".goshrc"), []byte("GREETING=hi\n"), 0644)
+
+	sh := NewExecShell(t)
+	sh.Vars["HOME"] = home
+	out := &SafeBuffer{}
+
+	status := sh.Run(strings.NewReader("echo $GREETING\nexit 4\necho unreachable\n"), out, io.Discard)
+	if status != 4 {
+		t.Errorf("expected status 4, got %d", status)
+	}
+	if !strings.Contains(out.String(), "$ hi\n/ $ Goodbye! :(\n") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	if strings.Contains(out.String(), "unreachable") {
+		t.Error("commands after exit were executed")
+	}
+}
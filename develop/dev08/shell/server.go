@@ -0,0 +1,251 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// SafeCommands - команды удаленной сессии по умолчанию: без запуска
+// программ, чтения и изменения файлов, сигналов и сети. Подключиться к
+// серверу может любой, поэтому cd, ls, cat и другие команды с файлами
+// включаются только явно через -a.
+var SafeCommands = []string{
+	"echo", "pwd", "help",
+	"true", "false", ":", "test", "[",
+	"jobs", "fg", "bg", "wait", "export", "unset", "set", "shift", "exit",
+	"local", "return", "break", "continue", "alias", "unalias", "type", "which", "hash",
+}
+
+// Server - режим удаленного шелла. Каждое соединение получает свой Shell:
+// свои переменные, функции и таблицу задач. Закрытие соединения или
+// остановка сервера прерывает команды сессии и убивает ее процессы.
+type Server struct {
+	Vars     map[string]string  // начальные переменные сессии, копируются
+	Commands map[string]Command // команды шелла, копируются
+	Allow    []string           // разрешенные команды, nil - все из Commands
+	Idle     time.Duration      // сессия без ввода дольше Idle закрывается, 0 - без ограничения
+	Log      *log.Logger        // журнал сессий и команд, nil - не вести
+
+	sessions int64 // последний номер сессии
+}
+
+// Listen открывает адрес сервера: host:port для tcp или unix:/path для
+// unix-сокета.
+func Listen(addr string) (net.Listener, error) {
+	if strings.HasPrefix(addr, "unix:") {
+		return net.Listen("unix", strings.TrimPrefix(addr, "unix:"))
+	}
+	return net.Listen("tcp", addr)
+}
+
+// Serve принимает соединения, пока не отменен ctx, и закрывает ln.
+// После отмены Serve прерывает открытые сессии и дожидается их завершения.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// NewSession создает шелл сессии с копиями переменных и команд сервера,
+// оставляя только разрешенные команды.
+func (s *Server) NewSession() (*Shell, error) {
+	vars := map[string]string{}
+	for name, value := range s.Vars {
+		vars[name] = value
+	}
+
+	commands := map[string]Command{}
+	for name, cmd := range s.Commands {
+		commands[name] = cmd
+	}
+
+	sh, err := NewShell(vars, commands)
+	if err != nil {
+		return nil, err
+	}
+	if s.Allow != nil {
+		sh.Restrict(s.Allow)
+	}
+	return sh, nil
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	id := atomic.AddInt64(&s.sessions, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// чтение команды прерывается только закрытием соединения
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	start := time.Now()
+	s.logf("session %d: connected from %s", id, remoteAddr(conn))
+
+	sh, err := s.NewSession()
+	if err != nil {
+		fmt.Fprintln(conn, "gosh:", err)
+		s.logf("session %d: %v", id, err)
+		return
+	}
+	sh.SetContext(ctx)
+
+	in := &idleReader{conn: conn, idle: s.Idle}
+	lines := &sessionLines{lines: NewScanLines(in, conn), server: s, id: id}
+	status := sh.Interpret(lines, true, IO{in, conn, conn})
+
+	reason := "closed"
+	if in.expired() {
+		fmt.Fprintln(conn, "\ngosh: idle timeout")
+		reason = "idle timeout"
+	} else if ctx.Err() != nil {
+		reason = "server stopped"
+	}
+	s.logf("session %d: %s, status %d, %s", id, reason, status, time.Since(start).Round(time.Millisecond))
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Log != nil {
+		s.Log.Printf(format, args...)
+	}
+}
+
+// remoteAddr - адрес клиента для журнала: у клиента unix-сокета адреса
+// нет, пишется путь сокета.
+func remoteAddr(conn net.Conn) string {
+	if conn.LocalAddr().Network() == "unix" {
+		return "unix:" + conn.LocalAddr().String()
+	}
+	return conn.RemoteAddr().String()
+}
+
+// sessionLines записывает в журнал сервера каждую прочитанную команду.
+type sessionLines struct {
+	lines  LineReader
+	server *Server
+	id     int64
+}
+
+func (l *sessionLines) ReadLine(prompt string) (string, error) {
+	line, err := l.lines.ReadLine(prompt)
+	if err == nil {
+		l.server.logf("session %d: $ %s", l.id, line)
+	}
+	return line, err
+}
+
+// idleReader продлевает срок чтения соединения перед каждым чтением:
+// клиент, не присылающий ввод дольше idle, отключается.
+type idleReader struct {
+	conn    net.Conn
+	idle    time.Duration
+	timeout int32
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.idle > 0 {
+		r.conn.SetReadDeadline(time.Now().Add(r.idle))
+	}
+
+	n, err := r.conn.Read(p)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		atomic.StoreInt32(&r.timeout, 1)
+	}
+	return n, err
+}
+
+func (r *idleReader) expired() bool {
+	return atomic.LoadInt32(&r.timeout) != 0
+}
+
+// SessionVars - начальные переменные сессий: $PWD и перечисленные names.
+// Остальное окружение сервера не передается: в нем могут быть секреты.
+func SessionVars(vars map[string]string, names []string) map[string]string {
+	session := map[string]string{"PWD": vars["PWD"]}
+	for _, name := range names {
+		if value, ok := vars[name]; ok {
+			session[name] = value
+		}
+	}
+	return session
+}
+
+// serve - режим gosh -s: сервер с командами этого шелла, работает до
+// SIGINT или SIGTERM. Сессии получают только $PWD и переменные из -e.
+func (sh *Shell) serve(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gosh -s", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	allow := flags.String("a", strings.Join(SafeCommands, ","), "allowed commands, comma separated")
+	export := flags.String("e", "", "variables passed to sessions, comma separated")
+	idle := flags.Duration("t", 10*time.Minute, "idle timeout, 0 - no limit")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(stderr, "gosh: -s: address required\n%s\n", usage)
+		return 2
+	}
+
+	ln, err := Listen(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "gosh:", err)
+		return 1
+	}
+
+	logger := log.New(stderr, "gosh: ", log.LstdFlags)
+	logger.Printf("listening on %s %s", ln.Addr().Network(), ln.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &Server{
+		Vars:     SessionVars(sh.Vars, strings.Split(*export, ",")),
+		Commands: sh.Commands,
+		Allow:    strings.Split(*allow, ","),
+		Idle:     *idle,
+		Log:      logger,
+	}
+	if err := server.Serve(ctx, ln); err != nil {
+		fmt.Fprintln(stderr, "gosh:", err)
+		return 1
+	}
+	logger.Print("stopped")
+	return 0
+}
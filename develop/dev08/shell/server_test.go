@@ -0,0 +1,234 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+func StartServer(t *testing.T, server *Server, addr string) (net.Addr, *SafeBuffer) {
+	ln, err := Listen(addr)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	logs := &SafeBuffer{}
+	server.Log = log.New(logs, "", 0)
+	if server.Commands == nil {
+		server.Commands = map[string]Command{
+			"echo": &command.Echo{},
+			"cd":   &command.ChangeDir{},
+			"pwd":  &command.ProcessWD{},
+			"exec": &command.Exec{},
+			"tail": &command.Tail{Poll: 10 * time.Millisecond},
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(ctx, ln)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("serve: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("server is not stopped")
+		}
+	})
+	return ln.Addr(), logs
+}
+
+// Session отправляет строки серверу, закрывает запись и возвращает весь ответ.
+func Session(t *testing.T, addr net.Addr, input string) string {
+	conn, err := net.Dial(addr.Network(), addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte(input))
+	if c, ok := conn.(interface{ CloseWrite() error }); ok {
+		c.CloseWrite()
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, _ := io.ReadAll(conn)
+	return string(data)
+}
+
+func TestServerSessions(t *testing.T) {
+	root := t.TempDir()
+	server := &Server{Vars: map[string]string{"PWD": root}, Allow: []string{"echo", "cd", "pwd", "export"}}
+	addr, logs := StartServer(t, server, "127.0.0.1:0")
+
+	tests := []struct {
+		input    string
+		expected []string
+		missing  []string
+	}{
+		{"X=1; cd /; echo x$X\npwd\n", []string{"x1", "/ $ /\n", "Goodbye"}, nil},
+		// переменные и каталог предыдущей сессии не видны
+		{"echo x$X; pwd\n", []string{"x" + root + "\n"}, []string{"x1"}},
+		{"ls\nexec ls\n(source f)\necho ok\n", []string{"ls: command not found", "exec: command not found", "source: command not found", "ok"}, nil},
+		{"f() { echo in f; }; f\n", []string{"in f"}, nil},
+		{"echo 'unterminated\n", []string{"syntax error"}, nil},
+		// сессия не меняет файлы
+		{"echo owned > f; echo more >> f; echo x > /dev/null; echo ok\n", []string{"f: restricted: cannot redirect output", "ok"}, nil},
+	}
+
+	for _, test := range tests {
+		out := Session(t, addr, test.input)
+		for _, part := range test.expected {
+			if !strings.Contains(out, part) {
+				t.Errorf("%q: expected %q in %q", test.input, part, out)
+			}
+		}
+		for _, part := range test.missing {
+			if strings.Contains(out, part) {
+				t.Errorf("%q: unexpected %q in %q", test.input, part, out)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "f")); err == nil {
+		t.Errorf("restricted session created a file")
+	}
+
+	for _, part := range []string{"session 1: connected from 127.0.0.1:", "session 1: $ pwd", "session 2: closed, status 0"} {
+		if !strings.Contains(logs.String(), part) {
+			t.Errorf("expected %q in log %q", part, logs.String())
+		}
+	}
+}
+
+func TestServerDefaultCommands(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("hunter2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// команды есть в шелле, но без -a сессия не может ими читать файлы
+	server := &Server{
+		Vars: map[string]string{"PWD": root},
+		Commands: map[string]Command{
+			"echo": &command.Echo{},
+			"cd":   &command.ChangeDir{},
+			"ls":   &command.Ls{},
+			"cat":  &command.Cat{},
+			"head": &command.Head{},
+		},
+		Allow: SafeCommands,
+	}
+	addr, _ := StartServer(t, server, "127.0.0.1:0")
+
+	input := fmt.Sprintf("cat %[1]s\nhead %[1]s\nls %[2]s\ncd %[2]s\necho x < %[1]s\necho ok\n", secret, outside)
+	out := Session(t, addr, input)
+	for _, part := range []string{
+		"cat: command not found", "head: command not found", "ls: command not found", "cd: command not found",
+		secret + ": restricted: cannot redirect input", "ok",
+	} {
+		if !strings.Contains(out, part) {
+			t.Errorf("expected %q in %q", part, out)
+		}
+	}
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("session read a file outside its directory: %q", out)
+	}
+}
+
+func TestSessionVars(t *testing.T) {
+	vars := map[string]string{"PWD": "/srv", "HOME": "/root", "SECRET_TOKEN": "hunter2"}
+	tests := []struct {
+		names    []string
+		expected string
+	}{
+		{[]string{""}, "map[PWD:/srv]"},
+		{[]string{"HOME", "MISSING"}, "map[HOME:/root PWD:/srv]"},
+	}
+
+	for _, test := range tests {
+		if got := fmt.Sprint(SessionVars(vars, test.names)); got != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.names, test.expected, got)
+		}
+	}
+}
+
+func TestServerIdle(t *testing.T) {
+	server := &Server{Vars: map[string]string{"PWD": "/"}, Idle: 100 * time.Millisecond}
+	addr, logs := StartServer(t, server, "unix:"+filepath.Join(t.TempDir(), "sock"))
+
+	conn, err := net.Dial(addr.Network(), addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("echo hi\n"))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, _ := io.ReadAll(conn)
+	if !strings.Contains(string(data), "hi") || !strings.Contains(string(data), "idle timeout") {
+		t.Errorf("unexpected output %q", data)
+	}
+	if !strings.Contains(logs.String(), "session 1: connected from unix:") || !strings.Contains(logs.String(), "session 1: idle timeout") {
+		t.Errorf("unexpected log %q", logs.String())
+	}
+}
+
+// Остановка сервера прерывает выполняемые команды сессий.
+func TestServerStop(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "log"), []byte("line\n"), 0644)
+
+	ln, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	server := &Server{Vars: map[string]string{"PWD": root}, Commands: map[string]Command{
+		"tail": &command.Tail{Poll: 10 * time.Millisecond},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(ctx, ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("tail -f log\n"))
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server is not stopped")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, _ := io.ReadAll(conn)
+	if !strings.Contains(string(data), "line") {
+		t.Errorf("unexpected output %q", data)
+	}
+}
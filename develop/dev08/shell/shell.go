@@ -0,0 +1,238 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// Command - команда шелла: builtin, функция или запуск программы.
+type Command interface {
+	Run(ctx *command.Context, args []string) int
+}
+
+// Starter - команда, запускающая процесс ОС, например command.Exec.
+// Шелл запускает подготовленный Command процесс сам: помещает его в группу
+// процессов задачи и запоминает pid для kill %N.
+type Starter interface {
+	Command(ctx *command.Context, args []string) (*exec.Cmd, int)
+}
+
+type Shell struct {
+	Vars     map[string]string
+	Exported map[string]bool
+	Commands map[string]Command
+	Aliases  map[string]string
+	Jobs     *JobTable
+
+	ctx         context.Context // отмена прерывает команды шелла, nil - не отменяется
+	job         *Job            // задача, в которой выполняется подоболочка: фоновая или конвейер
+	background  *Job            // последняя фоновая задача, для $!
+	ctl         *jobControl     // управление задачами, nil - выключено
+	allowed     map[string]bool // разрешенные команды, nil - все
+	paths       *command.PathCache
+	substStatus int  // код возврата последней подстановки $(...)
+	exited      bool // выполнен exit: оставшиеся команды пропускаются
+	exitCode    int
+
+	locals     []map[string]*string // прежние значения local-переменных вызванных функций
+	callDepth  int                  // вложенность функций и source: где допустим return
+	loopDepth  int                  // вложенность циклов: где допустимы break и continue
+	returning  bool                 // выполнен return
+	breaking   int                  // сколько циклов осталось прервать break
+	continuing int                  // на сколько циклов вверх действует continue
+}
+
+// NewShell создает шелл. Переданные переменные считаются окружением
+// и экспортируются в запускаемые процессы.
+func NewShell(vars map[string]string, commands map[string]Command) (*Shell, error) {
+	sh := &Shell{
+		Vars:     vars,
+		Exported: map[string]bool{},
+		Commands: commands,
+		Aliases:  map[string]string{},
+		Jobs:     NewJobTable(),
+		paths:    command.NewPathCache(),
+	}
+
+	for name := range vars {
+		sh.Exported[name] = true
+	}
+
+	sh.registerBuiltins()
+	return sh, nil
+}
+
+// registerBuiltins добавляет команды, привязанные к состоянию этого шелла:
+// jobs/fg/bg/wait, export/unset, exit/source, return/break/local,
+// alias/type/hash и kill, понимающий %N. Команды с такими именами, заданные пользователем, не
+// заменяются. Функции перепривязываются к этому шеллу.
+func (sh *Shell) registerBuiltins() {
+	for name, cmd := range map[string]Command{
+		"jobs":     &JobsCmd{sh.Jobs},
+		"fg":       &FgCmd{sh.Jobs, sh.ctl},
+		"bg":       &BgCmd{sh.Jobs},
+		"wait":     &WaitCmd{sh.Jobs},
+		"export":   &ExportCmd{sh},
+		"unset":    &UnsetCmd{sh},
+		"set":      &SetCmd{},
+		"shift":    &ShiftCmd{},
+		"exit":     &ExitCmd{sh},
+		"source":   &SourceCmd{sh},
+		".":        &SourceCmd{sh},
+		"local":    &LocalCmd{sh},
+		"return":   &ReturnCmd{sh},
+		"break":    &BreakCmd{sh: sh},
+		"continue": &BreakCmd{sh: sh, Continue: true},
+		"alias":    &AliasCmd{sh},
+		"unalias":  &UnaliasCmd{sh},
+		"type":     &TypeCmd{sh: sh},
+		"which":    &TypeCmd{sh: sh, Which: true},
+		"hash":     &HashCmd{sh},
+	} {
+		if sh.allowed != nil && !sh.allowed[name] {
+			continue
+		}
+		switch sh.Commands[name].(type) {
+		case nil, *JobsCmd, *FgCmd, *BgCmd, *WaitCmd, *ExportCmd, *UnsetCmd, *SetCmd, *ShiftCmd, *ExitCmd, *SourceCmd,
+			*LocalCmd, *ReturnCmd, *BreakCmd, *AliasCmd, *UnaliasCmd, *TypeCmd, *HashCmd:
+			sh.Commands[name] = cmd
+		}
+	}
+
+	for name, cmd := range sh.Commands {
+		if fn, ok := cmd.(*Function); ok && fn.sh != sh {
+			sh.Commands[name] = &Function{Body: fn.Body, sh: sh}
+		}
+	}
+
+	if _, ok := sh.Commands["kill"].(*command.Kill); ok {
+		sh.Commands["kill"] = &command.Kill{Jobs: sh.Jobs.Pids}
+	}
+}
+
+// SetContext задает контекст шелла. После его отмены оставшиеся команды
+// пропускаются, builtin прерываются, а запущенные процессы убиваются.
+func (sh *Shell) SetContext(ctx context.Context) {
+	sh.ctx = ctx
+}
+
+func (sh *Shell) baseContext() context.Context {
+	if sh.ctx == nil {
+		return context.Background()
+	}
+	return sh.ctx
+}
+
+// foregroundContext - контекст builtin: на переднем плане интерактивного
+// шелла, в том числе в конвейере, его дополнительно отменяет Ctrl-C.
+func (sh *Shell) foregroundContext() context.Context {
+	if sh.ctl != nil && (sh.job == nil || sh.job == sh.ctl.foreground()) {
+		return sh.ctl.context()
+	}
+	return sh.baseContext()
+}
+
+// cancelled сообщает, что выполнение прервано: нажат Ctrl-C или отменен
+// контекст шелла.
+func (sh *Shell) cancelled() bool {
+	return sh.ctl.isInterrupted() || sh.baseContext().Err() != nil
+}
+
+// commandContext собирает контекст вызова команды: каталог - $PWD шелла.
+func (sh *Shell) commandContext(ctx context.Context, env map[string]string, stdio IO) *command.Context {
+	return &command.Context{
+		Context: ctx,
+		Dir:     sh.Vars["PWD"],
+		Vars:    sh.Vars,
+		Env:     env,
+		Paths:   sh.paths,
+		Stdin:   stdio.Stdin,
+		Stdout:  stdio.Stdout,
+		Stderr:  stdio.Stderr,
+	}
+}
+
+// Restrict оставляет шеллу только перечисленные команды, включая builtins
+// управления задачами и переменными. Без exec внешние программы не
+// запускаются, а перенаправления в файлы и из файлов запрещены, кроме
+// /dev/null.
+// Функции, объявленные в шелле, разрешены: они выполняют только
+// разрешенные команды.
+func (sh *Shell) Restrict(names []string) {
+	sh.allowed = map[string]bool{}
+	for _, name := range names {
+		sh.allowed[name] = true
+	}
+
+	for name, cmd := range sh.Commands {
+		if _, ok := cmd.(*Function); !ok && !sh.allowed[name] {
+			delete(sh.Commands, name)
+		}
+	}
+}
+
+func (sh *Shell) Prompt(stdout io.Writer) {
+	fmt.Fprint(stdout, sh.prompt(stdout))
+}
+
+// prompt печатает уведомления о завершенных задачах и возвращает приглашение.
+func (sh *Shell) prompt(stdout io.Writer) string {
+	sh.Jobs.Reap(stdout)
+	return fmt.Sprintf("\n%s $ ", sh.Vars["PWD"])
+}
+
+// LineReader возвращает редактор строки, если stdin - терминал,
+// иначе построчное чтение без приглашений редактора.
+func (sh *Shell) LineReader(stdin io.Reader, stdout io.Writer, stderr io.Writer) LineReader {
+	f, ok := stdin.(*os.File)
+	if !ok || !isTerminal(f) {
+		return NewScanLines(stdin, stdout)
+	}
+
+	path := sh.Vars["HISTFILE"]
+	if path == "" {
+		path = filepath.Join(sh.Vars["HOME"], ".gosh_history")
+	}
+	history, err := LoadHistory(path, 1000)
+	if err != nil {
+		fmt.Fprintln(stderr, "history:", err)
+	}
+
+	return &TermLines{file: f, editor: &LineEditor{
+		In:       f,
+		Out:      stdout,
+		History:  history,
+		Complete: sh.Complete,
+	}}
+}
+
+// Run - интерактивный режим: приветствие, ~/.goshrc, приглашение и
+// редактор строки. Возвращает код, с которым нужно завершить процесс.
+func (sh *Shell) Run(stdin io.Reader, stdout io.WriteCloser, stderr io.Writer) int {
+	if f, ok := stdin.(*os.File); ok && isTerminal(f) {
+		sh.EnableJobControl(f)
+	}
+
+	stdio := IO{stdin, stdout, stderr}
+	if prog, ok := sh.Commands["help"]; ok {
+		prog.Run(sh.commandContext(sh.baseContext(), sh.Environ(), stdio), []string{})
+	}
+
+	if home := sh.Vars["HOME"]; home != "" {
+		rc := filepath.Join(home, ".goshrc")
+		if _, err := os.Stat(rc); err == nil {
+			sh.Source(rc, stdio)
+			if sh.exited {
+				return sh.exitCode
+			}
+		}
+	}
+
+	return sh.Interpret(sh.LineReader(stdin, stdout, stderr), true, stdio)
+}
@@ -0,0 +1,304 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// Status - команда, возвращающая код из первого аргумента.
+type Status struct{}
+
+func (c *Status) Run(ctx *command.Context, args []string) int {
+	code, _ := strconv.Atoi(args[0])
+	return code
+}
+
+// Upper копирует stdin в stdout в верхнем регистре.
+type Upper struct{}
+
+func (c *Upper) Run(ctx *command.Context, args []string) int {
+	data, _ := io.ReadAll(ctx.Stdin)
+	ctx.Stdout.Write(bytes.ToUpper(data))
+	return 0
+}
+
+// SetVar записывает переменную: set NAME VALUE.
+type SetVar struct{}
+
+func (c *SetVar) Run(ctx *command.Context, args []string) int {
+	ctx.Vars[args[0]] = args[1]
+	return 0
+}
+
+func NewExecShell(t *testing.T) *Shell {
+	sh, err := NewShell(map[string]string{"PWD": "/"}, map[string]Command{
+		"echo":   &command.Echo{},
+		"status": &Status{},
+		"upper":  &Upper{},
+		"setvar": &SetVar{},
+		"true":   &command.Status{Code: 0},
+		"false":  &command.Status{Code: 1},
+		"[":      &command.Test{Bracket: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sh
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{`echo "a  b" c`, "a  b c", 0},
+		{`echo a|upper`, "A", 0},
+		{`echo a | upper | upper`, "A", 0},
+		{`echo a; echo b`, "ab", 0},
+		{`status 0 && echo yes`, "yes", 0},
+		{`status 1 && echo yes`, "", 1},
+		{`status 1 || echo no`, "no", 0},
+		{`status 0 || echo no`, "", 0},
+		{`status 1 && echo a || echo b`, "b", 0},
+		{`status 0 && status 3 || echo c`, "c", 0},
+		{`status 0 && status 3`, "", 3},
+		{`status 2; status 0`, "", 0},
+		{`status 5 | status 0`, "", 0},
+		{`status 0 | status 5`, "", 5},
+		{`(echo a; echo b) | upper`, "AB", 0},
+		{`(status 4)`, "", 4},
+		{`echo 'it''s' # comment`, "its", 0},
+		{`echo "unterminated`, "", 2},
+		{`| echo`, "", 2},
+	}
+
+	for _, test := range tests {
+		sh := NewExecShell(t)
+		out := &bytes.Buffer{}
+
+		status := sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if status != test.status {
+			t.Errorf("%q: expected status %d, got %d", test.line, test.status, status)
+		}
+		if sh.Vars["?"] != strconv.Itoa(test.status) {
+			t.Errorf("%q: expected $? %d, got %q", test.line, test.status, sh.Vars["?"])
+		}
+		if out.String() != test.stdout {
+			t.Errorf("%q: expected stdout %q, got %q", test.line, test.stdout, out.String())
+		}
+	}
+}
+
+func TestSubshellVars(t *testing.T) {
+	sh := NewExecShell(t)
+
+	sh.Execute(`(setvar X inner); setvar Y outer`, strings.NewReader(""), io.Discard, io.Discard)
+	if _, ok := sh.Vars["X"]; ok {
+		t.Error("subshell variable leaked to parent")
+	}
+	if sh.Vars["Y"] != "outer" {
+		t.Error("variable is not set in parent")
+	}
+}
+
+func TestRunContinuation(t *testing.T) {
+	sh := NewExecShell(t)
+	out := &SafeBuffer{}
+
+	sh.Run(strings.NewReader("echo \"a\nb\" |\nupper\nexit\n"), out, io.Discard)
+
+	if !strings.Contains(out.String(), "> > A\nB") {
+		t.Errorf("continuation lines are not joined: %q", out.String())
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		line   string
+		stdout string
+		stderr string
+		files  map[string]string
+		status int
+	}{
+		{`echo a > out`, "", "", map[string]string{"out": "a"}, 0},
+		{`echo a > out; echo b >> out`, "", "", map[string]string{"out": "ab"}, 0},
+		{`echo a > out; echo b > out`, "", "", map[string]string{"out": "b"}, 0},
+		{`echo a > out; upper < out`, "A", "", nil, 0},
+		{`>out`, "", "", map[string]string{"out": ""}, 0},
+		{`echo a 2>err`, "a", "", map[string]string{"err": ""}, 0},
+		{`upper < missing`, "", "sh: missing: no such file or directory\n", nil, 1},
+		{`echo a >&2`, "", "a", nil, 0},
+		{`(echo a >&2) 2>&1`, "a", "", nil, 0},
+		{`(echo a >&2) >out 2>&1`, "", "", map[string]string{"out": "a"}, 0},
+		{`(echo a >&2) 2>&1 >out`, "a", "", map[string]string{"out": ""}, 0},
+		{`(echo a; echo b) > out | upper`, "", "", map[string]string{"out": "ab"}, 0},
+		{"upper <<EOF\nhello\n  world\nEOF", "HELLO\n  WORLD\n", "", nil, 0},
+		{"upper <<-EOF\n\thello\n\tEOF\necho done", "HELLO\ndone", "", nil, 0},
+		{`echo a >&5`, "", "sh: 5: bad file descriptor\n", nil, 1},
+		{`echo a > nodir/out`, "", "sh: nodir/out: no such file or directory\n", nil, 1},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		sh := NewExecShell(t)
+		sh.Vars["PWD"] = dir
+		out := &bytes.Buffer{}
+		errOut := &bytes.Buffer{}
+
+		status := sh.Execute(test.line, strings.NewReader(""), out, errOut)
+		if status != test.status {
+			t.Errorf("%q: expected status %d, got %d", test.line, test.status, status)
+		}
+		if out.String() != test.stdout {
+			t.Errorf("%q: expected stdout %q, got %q", test.line, test.stdout, out.String())
+		}
+		if errOut.String() != test.stderr {
+			t.Errorf("%q: expected stderr %q, got %q", test.line, test.stderr, errOut.String())
+		}
+		for name, content := range test.files {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Errorf("%q: %v", test.line, err)
+				continue
+			}
+			if string(data) != content {
+				t.Errorf("%q: expected %s to contain %q, got %q", test.line, name, content, data)
+			}
+		}
+	}
+}
+
+func TestFileBuiltins(t *testing.T) {
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{"mkdir -p a/b && echo hi > a/b/f && cp -r a c && ls -R c", "c:\nb\n\nc/b:\nf\n", 0},
+		{"cat <<EOF > f\n1\n2\n3\nEOF\nhead -n 2 f | tail -n 1; cat f | wc -l", "2\n3\n", 0},
+		{"echo x > f; mv f g && ls; rm g; ls", "g\n", 0},
+		{"mkdir d; rm d", "", 1},
+	}
+
+	for _, test := range tests {
+		sh, err := NewShell(map[string]string{"PWD": t.TempDir(), "PATH": ""}, map[string]Command{
+			"echo":  &command.Echo{},
+			"ls":    &command.Ls{},
+			"cat":   &command.Cat{},
+			"head":  &command.Head{},
+			"tail":  &command.Tail{},
+			"wc":    &command.WordCount{},
+			"mkdir": &command.MakeDir{},
+			"rm":    &command.Remove{},
+			"cp":    &command.Copy{},
+			"mv":    &command.Move{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := &bytes.Buffer{}
+
+		status := sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		if status != test.status || out.String() != test.stdout {
+			t.Errorf("%q: expected status %d and %q, got %d and %q", test.line, test.status, test.stdout, status, out.String())
+		}
+	}
+}
+
+// Шеллы в одном процессе не делят текущий каталог: cd меняет только $PWD.
+func TestConcurrentShells(t *testing.T) {
+	wd, _ := os.Getwd()
+	dirs := []string{t.TempDir(), t.TempDir()}
+
+	var wg sync.WaitGroup
+	outs := make([]bytes.Buffer, len(dirs))
+	for i, dir := range dirs {
+		os.Mkdir(filepath.Join(dir, "sub"), 0755)
+		sh, err := NewShell(map[string]string{"PWD": dir}, map[string]Command{
+			"cd":   &command.ChangeDir{},
+			"pwd":  &command.ProcessWD{},
+			"echo": &command.Echo{},
+			"cat":  &command.Cat{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sh.Execute("cd sub; echo "+strconv.Itoa(i)+" > f; pwd; cat f", strings.NewReader(""), &outs[i], io.Discard)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, dir := range dirs {
+		expected := filepath.Join(dir, "sub") + "\n" + strconv.Itoa(i)
+		if outs[i].String() != expected {
+			t.Errorf("shell %d: expected %q, got %q", i, expected, outs[i].String())
+		}
+	}
+	if now, _ := os.Getwd(); now != wd {
+		t.Errorf("process directory is changed: %s", now)
+	}
+}
+
+// Отмена контекста шелла прерывает builtin и внешнюю команду и
+// пропускает оставшиеся команды.
+func TestShellContext(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "log"), []byte("line\n"), 0644)
+
+	tests := []struct {
+		line   string
+		stdout string
+		status int
+	}{
+		{"tail -f log; echo after", "line\n", command.Interrupted},
+		{"sleep 5; echo after", "", 128 + int(syscall.SIGKILL)},
+		// cat завершается сам, когда tail закрывает канал
+		{"tail -f log | cat; echo after", "line\n", 0},
+	}
+
+	for _, test := range tests {
+		sh, err := NewShell(map[string]string{"PWD": root, "PATH": os.Getenv("PATH")}, map[string]Command{
+			"echo": &command.Echo{},
+			"cat":  &command.Cat{},
+			"tail": &command.Tail{Poll: 10 * time.Millisecond},
+			"exec": &command.Exec{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		sh.SetContext(ctx)
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		out := &SafeBuffer{}
+		status := make(chan int, 1)
+		go func() {
+			status <- sh.Execute(test.line, strings.NewReader(""), out, io.Discard)
+		}()
+
+		select {
+		case code := <-status:
+			if code != test.status || out.String() != test.stdout {
+				t.Errorf("%q: expected status %d and %q, got %d and %q", test.line, test.status, test.stdout, code, out.String())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q: is not cancelled", test.line)
+		}
+	}
+}
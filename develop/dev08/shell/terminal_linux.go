@@ -0,0 +1,62 @@
+package shell
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func getTermios(f *os.File) (*syscall.Termios, error) {
+	termios := &syscall.Termios{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return nil, errno
+	}
+	return termios, nil
+}
+
+func setTermios(f *os.File, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	_, err := getTermios(f)
+	return err == nil
+}
+
+// makeRaw отключает канонический режим, эхо и сигналы от клавиатуры.
+// Обработка вывода (OPOST) остается: \n по-прежнему переводит строку.
+func makeRaw(f *os.File) (func(), error) {
+	old, err := getTermios(f)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := setTermios(f, &raw); err != nil {
+		return nil, err
+	}
+	return func() { setTermios(f, old) }, nil
+}
+
+// setForeground делает группу процессов pgid группой переднего плана
+// терминала: ей достаются ввод и сигналы от Ctrl-C и Ctrl-Z.
+func setForeground(f *os.File, pgid int) error {
+	pgrp := int32(pgid)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package shell
+
+import (
+	"errors"
+	"os"
+)
+
+// На других системах редактор строки не поддерживается: Run читает stdin построчно.
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported")
+}
+
+func setForeground(f *os.File, pgid int) error {
+	return errors.New("job control is not supported")
+}
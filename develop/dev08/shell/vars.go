@@ -0,0 +1,131 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pgeowng/wb-l2/develop/dev08/command"
+)
+
+// Environ возвращает экспортированные переменные - окружение запускаемых
+// процессов. PWD передается всегда: по нему выставляется рабочий каталог.
+func (sh *Shell) Environ() map[string]string {
+	env := map[string]string{}
+	for name := range sh.Exported {
+		if value, ok := sh.Vars[name]; ok {
+			env[name] = value
+		}
+	}
+	env["PWD"] = sh.Vars["PWD"]
+	return env
+}
+
+type assignment struct {
+	name  string
+	value Word
+}
+
+// splitAssignment распознает слово NAME=value. Имя должно быть без кавычек.
+func splitAssignment(word Word) (string, Word, bool) {
+	if len(word) == 0 || word[0].Quote != Unquoted {
+		return "", nil, false
+	}
+
+	idx := strings.IndexByte(word[0].Text, '=')
+	if idx <= 0 || !IsName(word[0].Text[:idx]) {
+		return "", nil, false
+	}
+
+	value := Word{{Text: word[0].Text[idx+1:], Quote: Unquoted}}
+	return word[0].Text[:idx], append(value, word[1:]...), true
+}
+
+// shellQuote заключает значение в одинарные кавычки, чтобы вывод
+// export и set можно было выполнить повторно.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func sortedNames(vars map[string]string) []string {
+	names := []string{}
+	for name := range vars {
+		if IsName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Builtins для переменных. Как и управление задачами, им нужен доступ
+// к шеллу: список экспортированных переменных хранится в нем.
+
+type ExportCmd struct {
+	sh *Shell
+}
+
+func (c *ExportCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) == 0 {
+		env := c.sh.Environ()
+		for _, name := range sortedNames(env) {
+			fmt.Fprintf(ctx.Stdout, "export %s=%s\n", name, shellQuote(env[name]))
+		}
+		return 0
+	}
+
+	status := 0
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !IsName(name) {
+			fmt.Fprintf(ctx.Stderr, "export: `%s': not a valid identifier\n", arg)
+			status = 1
+			continue
+		}
+
+		if hasValue {
+			ctx.Vars[name] = value
+		}
+		c.sh.Exported[name] = true
+	}
+	return status
+}
+
+type UnsetCmd struct {
+	sh *Shell
+}
+
+func (c *UnsetCmd) Run(ctx *command.Context, args []string) int {
+	status := 0
+	for _, name := range args {
+		if !IsName(name) {
+			fmt.Fprintf(ctx.Stderr, "unset: `%s': not a valid identifier\n", name)
+			status = 1
+			continue
+		}
+
+		delete(ctx.Vars, name)
+		delete(c.sh.Exported, name)
+	}
+	return status
+}
+
+// SetCmd без аргументов печатает переменные, с аргументами - задает
+// позиционные параметры $1, $2, ... и $#.
+type SetCmd struct{}
+
+func (c *SetCmd) Run(ctx *command.Context, args []string) int {
+	if len(args) == 0 {
+		for _, name := range sortedNames(ctx.Vars) {
+			fmt.Fprintf(ctx.Stdout, "%s=%s\n", name, shellQuote(ctx.Vars[name]))
+		}
+		return 0
+	}
+
+	if args[0] == "--" {
+		args = args[1:]
+	}
+
+	setPositional(ctx.Vars, args)
+	return 0
+}
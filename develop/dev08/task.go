@@ -1,15 +1,12 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"io"
 	"os"
-	"os/signal"
 	"strings"
-	"sync"
 
 	"github.com/pgeowng/wb-l2/develop/dev08/command"
+	"github.com/pgeowng/wb-l2/develop/dev08/shell"
 )
 
 /*
@@ -26,153 +23,6 @@ import (
 Программа должна проходить все тесты. Код должен проходить проверки go vet и golint.
 */
 
-type Command interface {
-	Run(args []string, vars map[string]string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
-}
-
-type Shell struct {
-	Vars          map[string]string
-	Commands      map[string]Command
-	TokenizeSplit func(line string) []string
-}
-
-func NewShell(vars map[string]string, commands map[string]Command) (*Shell, error) {
-	return &Shell{
-		Vars:     vars,
-		Commands: commands,
-		TokenizeSplit: func(line string) []string {
-			return strings.Fields(line)
-		},
-	}, nil
-}
-
-type PipeItem struct {
-	cmd  string
-	args []string
-}
-type Pipeline struct {
-	fork bool
-	pipe []PipeItem
-}
-
-// Парсим команды разбивая & и |.
-func (sh *Shell) Tokenize(line string) (actions []Pipeline, err error) {
-	tokens := sh.TokenizeSplit(line)
-
-	actions = append(actions, Pipeline{})
-	idx := 0
-
-	for i := 0; i < len(tokens); i++ {
-		if actions[idx].fork {
-			actions = append(actions, Pipeline{})
-		}
-
-		cmd := tokens[i]
-		if cmd == "|" || cmd == "&" {
-			err = fmt.Errorf("sh: parse error near %d: %v", i, cmd)
-			return
-		}
-
-		i++
-		args := []string{}
-
-		for ; i < len(tokens) && tokens[i] != "|" && tokens[i] != "&"; i++ {
-			args = append(args, tokens[i])
-		}
-
-		actions[idx].pipe = append(actions[idx].pipe, PipeItem{cmd, args})
-
-		if i < len(tokens) && tokens[i] == "&" {
-			actions[idx].fork = true
-		}
-	}
-
-	return
-}
-
-// Игнорируем ctrl+c
-func HandleInterrupt(cb func(chan os.Signal)) {
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
-	go func() {
-		for range interrupt {
-			cb(interrupt)
-		}
-		signal.Stop(interrupt)
-	}()
-}
-
-func (sh *Shell) Run(stdin io.Reader, stdout io.WriteCloser, stderr io.Writer) {
-	HandleInterrupt(func(_ chan os.Signal) {
-		fmt.Fprintf(stdout, "\n%s $ ", sh.Vars["PWD"])
-	})
-
-	prog, ok := sh.Commands["help"]
-	if ok {
-		prog.Run([]string{}, sh.Vars, stdin, stdout, stderr)
-	}
-	fmt.Fprintf(stdout, "\n%s $ ", sh.Vars["PWD"])
-
-	scanner := bufio.NewScanner(stdin)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if line == "exit" {
-			fmt.Fprintln(stdout, "Goodbye! :(")
-			return
-		}
-
-		actions, err := sh.Tokenize(line)
-		if err != nil {
-			fmt.Fprintln(stderr, err)
-		}
-
-		// При использовании |, связывает cmd1.stdout -> cmd2.stdin, через io.Pipe
-		for _, action := range actions {
-			var dest io.WriteCloser = stdout
-			var src io.Reader = stdin
-			var nextSrc io.Reader = stdin
-			wg := sync.WaitGroup{}
-
-			for idx, item := range action.pipe {
-				isLast := idx+1 == len(action.pipe)
-				if !isLast {
-					pr, pw := io.Pipe()
-					nextSrc = pr
-					dest = pw
-				} else {
-					dest = stdout
-				}
-
-				prog, ok := sh.Commands[item.cmd]
-				if !ok {
-					prog = sh.Commands["exec"]
-					item.args = append([]string{item.cmd}, item.args...)
-				}
-
-				wg.Add(1)
-				go func(args []string, stdin io.Reader, stdout io.WriteCloser, mustClose bool) {
-					defer wg.Done()
-					exitCode := prog.Run(args, sh.Vars, stdin, stdout, stderr)
-					if exitCode != 0 {
-						fmt.Fprintf(stderr, "%s: exit code %d", item.cmd, exitCode)
-					}
-					if mustClose {
-						stdout.Close()
-					}
-				}(item.args, src, dest, !isLast)
-				src = nextSrc
-
-			}
-
-			if !action.fork {
-				wg.Wait()
-			}
-		}
-		fmt.Fprintf(stdout, "\n%s $ ", sh.Vars["PWD"])
-	}
-}
-
 func main() {
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -186,37 +36,101 @@ func main() {
 		os.Exit(1)
 	}
 
-	vars := map[string]string{
-		"PWD":  pwd,
-		"HOME": home,
+	vars := map[string]string{}
+	for _, env := range os.Environ() {
+		if name, value, ok := strings.Cut(env, "="); ok {
+			vars[name] = value
+		}
 	}
-
-	commands := map[string]Command{
-		"cd":   &command.ChangeDir{},
-		"pwd":  &command.ProcessWD{},
-		"echo": &command.Echo{},
-		"exec": &command.Exec{},
-		"help": &command.Help{},
-		"kill": &command.Kill{},
+	vars["PWD"] = pwd
+	vars["HOME"] = home
+
+	commands := map[string]shell.Command{
+		"cd":    &command.ChangeDir{},
+		"pwd":   &command.ProcessWD{},
+		"echo":  &command.Echo{},
+		"exec":  &command.Exec{},
+		"help":  &command.Help{},
+		"kill":  &command.Kill{},
+		"ps":    &command.Ps{},
+		"nc":    &command.Netcat{},
+		"ls":    &command.Ls{},
+		"cat":   &command.Cat{},
+		"head":  &command.Head{},
+		"tail":  &command.Tail{},
+		"wc":    &command.WordCount{},
+		"mkdir": &command.MakeDir{},
+		"rm":    &command.Remove{},
+		"cp":    &command.Copy{},
+		"mv":    &command.Move{},
+		"true":  &command.Status{Code: 0},
+		"false": &command.Status{Code: 1},
+		":":     &command.Status{Code: 0},
+		"test":  &command.Test{},
+		"[":     &command.Test{Bracket: true},
 	}
 
-	prog, err := NewShell(vars, commands)
+	prog, err := shell.NewShell(vars, commands)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	prog.Run(os.Stdin, os.Stdout, os.Stderr)
+	os.Exit(prog.Main(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
 
 // $ go run .
 // Hello from go-shell! You can use:
 //   help                  - show this message
-//   exit                  - exit shell :(
+//   exit [N]              - exit shell :(
+//   Tab / Up, Down / ^R   - complete, browse history, search history
+//   ^C / ^Z               - interrupt or stop foreground command
 //   cd [path]             - change directory
 //   pwd                   - current directory
 //   echo [...args]        - prints to stdout args
-//   kill <pid>            - kill process by id
+//   kill [-SIG] <pid|%N>  - send signal (TERM by default) to processes or job
+//   ps [-p pid,...]       - list processes (--ppid, -C name, -s state filters)
+//   ls [-alR] [path...]   - list directory (long format, hidden files, recursive)
+//   cat [file...]         - print files or stdin
+//   head, tail [-n N]     - first or last N lines (tail -f follows file)
+//   wc [-lwc] [file...]   - count lines, words, bytes
+//   mkdir [-p] <dir...>   - create directories
+//   rm [-rf] <path...>    - remove files (-r directories)
+//   cp [-r] <src..> <dst> - copy files (-r directories)
+//   mv <src..> <dst>      - move or rename files
+//   nc [-u] host port     - send stdin to tcp/udp connection (-l listen, -z scan, -w secs)
+//   jobs                  - list background jobs
+//   fg [%N]               - wait for job in foreground
+//   bg [%N]               - resume stopped job in background
+//   wait [%N]             - wait for background jobs
+//   export [NAME[=value]] - export variable to child processes
+//   unset NAME            - remove variable
+//   set [-- args]         - list variables or set $1, $2, ...
+//   shift [N]             - drop first N positional parameters
+//   source <file> [args]  - run <file> in current shell (also ". <file>")
+//   alias [name=value]    - define or list aliases (unalias name)
+//   type, which <name>    - show how name is resolved: alias, function, builtin or path
+//   hash [-r] [name...]   - remembered program paths (-r forgets them)
+//   true, false, :        - succeed or fail
+//   test <expr>           - check files (-f -d ...), strings, numbers (also "[ <expr> ]")
 //   <any PATH executable> - execute file from PATH
 //   <cmd1> | <cmd2>       - pipe <cmd1> stdout to <cmd2> stdin
 //   <cmd1> &              - run <cmd1> in background
+//   <cmd1> ; <cmd2>       - run <cmd1>, then <cmd2>
+//   <cmd1> && <cmd2>      - run <cmd2> if <cmd1> succeeded
+//   <cmd1> || <cmd2>      - run <cmd2> if <cmd1> failed
+//   ( <cmds> )            - run <cmds> in subshell
+//   <cmd1> > <file>       - write stdout to <file> (>> appends)
+//   <cmd1> < <file>       - read stdin from <file>
+//   <cmd1> 2> <file>      - write stderr to <file>, 2>&1 joins it with stdout
+//   <cmd1> <<EOF          - read stdin from following lines until EOF
+//   $VAR ${VAR:-default}  - variable value, $? $$ $! are special
+//   $(<cmds>)             - output of <cmds>
+//   *.go ? [a-z] **/x     - file names matching pattern
+//   ~ ~user {a,b} {1..5}  - home directory, alternatives, sequence
+//   NAME=value [cmd]      - set variable (only for cmd, if given)
+//   if <cmds>; then <cmds>; [elif ...;] [else <cmds>;] fi
+//   while|until <cmds>; do <cmds>; done (break [N], continue [N])
+//   for NAME [in <words>]; do <cmds>; done
+//   case <word> in <pattern>[|...]) <cmds> ;; ... esac
+//   name() { <cmds>; }    - define function (local NAME[=value], return [N])
 // /home/dt/gohigh/wb/wb-l2/develop/dev08 $
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// атрибуты со ссылками, которые переписывает -k
+var linkAttrs = map[string]bool{"href": true, "src": true, "poster": true}
+
+// ConvertLinks переписывает ссылки в сохраненных HTML и CSS для
+// просмотра без сети, как wget -k: ссылка на скачанный файл становится
+// относительным путем к нему, остальные - абсолютными URL.
+func (w *Wget) ConvertLinks() error {
+	keys := []string{}
+	for key := range w.saved {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		file := w.saved[key]
+		kind := fileKind(file)
+		// файл из прошлого запуска мог уже быть переписан
+		if kind != "text/html" && kind != "text/css" || file.converted {
+			continue
+		}
+
+		original, err := os.ReadFile(file.path)
+		if err != nil {
+			return err
+		}
+		var data []byte
+		if kind == "text/html" {
+			data = w.convertHTML(original, file)
+		} else {
+			data = []byte(rewriteCSS(string(original), w.linkConverter(file, file.url)))
+		}
+
+		// неизмененный файл не трогаем: дата файла нужна для -N
+		if bytes.Equal(data, original) {
+			continue
+		}
+
+		fmt.Println("converting: ", file.path)
+		if err := os.WriteFile(file.path, data, 0644); err != nil {
+			return err
+		}
+		file.converted = true
+	}
+	return nil
+}
+
+// fileKind - тип сохраненного файла по Content-Type, а без него по расширению.
+func fileKind(file *savedFile) string {
+	if kind, _, err := mime.ParseMediaType(file.contentType); err == nil {
+		return kind
+	}
+
+	switch strings.ToLower(filepath.Ext(file.path)) {
+	case ".html", ".htm":
+		return "text/html"
+	case ".css":
+		return "text/css"
+	}
+	return ""
+}
+
+// linkConverter возвращает функцию, переписывающую ссылку из файла file,
+// относительную base.
+func (w *Wget) linkConverter(file *savedFile, base *url.URL) func(string) string {
+	return func(ref string) string {
+		trimmed := strings.TrimSpace(ref)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			return ref
+		}
+
+		u, err := base.Parse(trimmed)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return ref
+		}
+
+		target, ok := w.saved[urlKey(u)]
+		if !ok {
+			return u.String()
+		}
+
+		rel, err := filepath.Rel(filepath.Dir(file.path), target.path)
+		if err != nil {
+			return u.String()
+		}
+		local := &url.URL{Path: filepath.ToSlash(rel), Fragment: u.Fragment}
+		return local.String()
+	}
+}
+
+// convertHTML переписывает ссылки страницы: атрибуты href, src, poster,
+// srcset, стили в атрибуте style и в <style>. Неизмененные теги
+// копируются как есть, <base> удаляется: ссылки уже не зависят от него.
+func (w *Wget) convertHTML(data []byte, file *savedFile) []byte {
+	out := bytes.Buffer{}
+	z := html.NewTokenizer(bytes.NewReader(data))
+	base := file.url
+	inStyle := false
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				out.Write(z.Raw())
+			}
+			break
+		}
+		raw := append([]byte{}, z.Raw()...)
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if token.Data == "base" {
+				for _, attr := range token.Attr {
+					if u, err := file.url.Parse(attr.Val); attr.Key == "href" && err == nil {
+						base = u
+					}
+				}
+				continue
+			}
+			inStyle = token.Data == "style" && tt == html.StartTagToken
+
+			if convertAttrs(token.Attr, w.linkConverter(file, base)) {
+				out.WriteString(token.String())
+				continue
+			}
+		case html.TextToken:
+			if inStyle {
+				out.WriteString(rewriteCSS(string(raw), w.linkConverter(file, base)))
+				continue
+			}
+		case html.EndTagToken:
+			inStyle = false
+		}
+		out.Write(raw)
+	}
+	return out.Bytes()
+}
+
+// convertAttrs переписывает ссылки в атрибутах тега и сообщает, изменилось ли что-то.
+func convertAttrs(attrs []html.Attribute, convert func(string) string) bool {
+	changed := false
+	for i, attr := range attrs {
+		value := attr.Val
+		switch {
+		case linkAttrs[attr.Key]:
+			value = convert(attr.Val)
+		case attr.Key == "srcset":
+			value = rewriteSrcset(attr.Val, convert)
+		case attr.Key == "style":
+			value = rewriteCSS(attr.Val, convert)
+		}
+
+		if value != attr.Val {
+			attrs[i].Val = value
+			changed = true
+		}
+	}
+	return changed
+}
+
+// srcsetURLs разбирает srcset: через запятую URL и необязательный
+// дескриптор ширины или плотности.
+func srcsetURLs(srcset string) []string {
+	urls := []string{}
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+func rewriteSrcset(srcset string, convert func(string) string) string {
+	candidates := []string{}
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = convert(fields[0])
+		candidates = append(candidates, strings.Join(fields, " "))
+	}
+	return strings.Join(candidates, ", ")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertLinks(t *testing.T) {
+	site := NewSite(t, map[string]string{
+		"/": `<html><head><link rel="stylesheet" href="/style.css"></head><body>
+<a href="/about">About</a> <a href="{{site}}/about#team">Team</a> <a href="#top">Top</a>
+<a href="https://elsewhere.invalid/x">Out</a> <a href="mailto:a@b.c">Mail</a>
+<img srcset="logo.png 1x, /missing.png 2x" style="background: url(/missing.png)">
+<style>body { background: url('/missing.png') }</style>
+</body></html>`,
+		"/about":       `<html><body><a href="../">Home</a><a href="/docs/a.html">A</a></body></html>`,
+		"/docs/a.html": `<base href="/about/"><a href="../style.css">css</a>`,
+		"/style.css":   `@import "other.css"; body { background: url( "/about" ) }`,
+	})
+	dir := t.TempDir()
+
+	cfg := NewTestConfig(t, site.URL, dir)
+	cfg.convertLinks = true
+	if err := NewWget(cfg).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(site.URL, "http://")
+	hostname := strings.Split(host, ":")[0]
+	missing := site.URL + "/missing.png"
+
+	tests := []struct {
+		file     string
+		expected []string
+	}{
+		{"index.html", []string{
+			`href="style.css"`, `<a href="about/index.html">About</a>`, `<a href="about/index.html#team">Team</a>`, `<a href="#top">`,
+			`href="https://elsewhere.invalid/x"`, `href="mailto:a@b.c"`,
+			`srcset="` + site.URL + `/logo.png 1x, ` + missing + ` 2x"`,
+			`style="background: url(` + missing + `)"`, `url('` + missing + `')`,
+		}},
+		{"about/index.html", []string{`<a href="../index.html">Home</a>`, `<a href="../docs/a.html">A</a>`}},
+		{"docs/a.html", []string{`<a href="../style.css">css</a>`}},
+		// other.css не скачан: ссылка становится абсолютной
+		{"style.css", []string{`@import "` + site.URL + `/other.css";`, `url( "about/index.html" )`}},
+	}
+
+	for _, test := range tests {
+		data := ReadSaved(t, dir, hostname+"/"+test.file)
+		for _, part := range test.expected {
+			if !strings.Contains(data, part) {
+				t.Errorf("%s: expected %q in %q", test.file, part, data)
+			}
+		}
+	}
+	if data := ReadSaved(t, dir, hostname+"/docs/a.html"); strings.Contains(data, "<base") {
+		t.Errorf("base is not removed: %q", data)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+)
+
+// cssRef - ссылка в CSS: url(...) или строка @import. Start и End -
+// границы значения в исходном тексте, без кавычек и пробелов.
+type cssRef struct {
+	URL        string
+	Start, End int
+}
+
+// cssRefs находит ссылки в таблице стилей. Комментарии пропускаются,
+// строки учитываются только после @import.
+func cssRefs(css string) []cssRef {
+	refs := []cssRef{}
+	for i := 0; i < len(css); {
+		switch {
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return refs
+			}
+			i += end + 4
+		case css[i] == '"' || css[i] == '\'':
+			end := stringEnd(css, i)
+			if afterImport(css, i) {
+				refs = append(refs, cssRef{URL: css[i+1 : end], Start: i + 1, End: end})
+			}
+			i = end + 1
+		case hasPrefixFold(css[i:], "url(") && (i == 0 || !isIdentChar(css[i-1])):
+			ref, next := parseURL(css, i+len("url("))
+			if ref.URL != "" {
+				refs = append(refs, ref)
+			}
+			i = next
+		default:
+			i++
+		}
+	}
+	return refs
+}
+
+// afterImport - стоит ли перед позицией i @import. Пробелы пропускаются
+// с конца, без копирования начала таблицы стилей.
+func afterImport(css string, i int) bool {
+	for i > 0 && isSpace(css[i-1]) {
+		i--
+	}
+	return i >= len("@import") && strings.EqualFold(css[i-len("@import"):i], "@import")
+}
+
+// parseURL разбирает значение url( начиная с позиции i и возвращает
+// позицию после закрывающей скобки.
+func parseURL(css string, i int) (cssRef, int) {
+	for i < len(css) && isSpace(css[i]) {
+		i++
+	}
+	if i < len(css) && (css[i] == '"' || css[i] == '\'') {
+		end := stringEnd(css, i)
+		ref := cssRef{URL: css[i+1 : end], Start: i + 1, End: end}
+		if paren := strings.IndexByte(css[end:], ')'); paren >= 0 {
+			return ref, end + paren + 1
+		}
+		return ref, len(css)
+	}
+
+	paren := strings.IndexByte(css[i:], ')')
+	if paren < 0 {
+		return cssRef{}, len(css)
+	}
+	end := i + paren
+	for end > i && isSpace(css[end-1]) {
+		end--
+	}
+	return cssRef{URL: css[i:end], Start: i, End: end}, i + paren + 1
+}
+
+// stringEnd возвращает позицию закрывающей кавычки строки, начатой в i.
+func stringEnd(css string, i int) int {
+	quote := css[i]
+	for j := i + 1; j < len(css); j++ {
+		switch css[j] {
+		case '\\':
+			j++
+		case quote, '\n':
+			return j
+		}
+	}
+	return len(css)
+}
+
+// rewriteCSS заменяет ссылки таблицы стилей значениями rewrite.
+func rewriteCSS(css string, rewrite func(ref string) string) string {
+	b := strings.Builder{}
+	last := 0
+	for _, ref := range cssRefs(css) {
+		b.WriteString(css[last:ref.Start])
+		b.WriteString(rewrite(ref.URL))
+		last = ref.End
+	}
+	b.WriteString(css[last:])
+	return b.String()
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '-' || c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
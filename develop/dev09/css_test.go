@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCSSRefs(t *testing.T) {
+	tests := []struct {
+		css      string
+		expected []string
+	}{
+		{`a { background: url(img/a.png) }`, []string{"img/a.png"}},
+		{`a { background: URL( "b c.png" ) no-repeat, url('d.png') }`, []string{"b c.png", "d.png"}},
+		{`@import "base.css"; @import url(print.css) print; @IMPORT 'x.css';`, []string{"base.css", "print.css", "x.css"}},
+		{"\"no.css\" @import\n\t \"y.css\"; import \"no.css\"", []string{"y.css"}},
+		{`/* url(skip.png) @import "skip.css"; */ a { content: "url(no.png)" }`, []string{}},
+		{`a { font-family: "x\"url(no)"; b: myurl(no) }`, []string{}},
+		{`a { background: url() url("") }`, []string{}},
+		{`a { background: url(unclosed`, []string{}},
+	}
+
+	for _, test := range tests {
+		urls := []string{}
+		for _, ref := range cssRefs(test.css) {
+			urls = append(urls, ref.URL)
+			if test.css[ref.Start:ref.End] != ref.URL {
+				t.Errorf("%q: bad bounds for %q", test.css, ref.URL)
+			}
+		}
+		if fmt.Sprintf("%q", urls) != fmt.Sprintf("%q", test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.css, test.expected, urls)
+		}
+	}
+}
+
+func TestRewriteCSS(t *testing.T) {
+	css := `@import "a.css"; b { background: url( 'b.png' ) } /* url(c.png) */`
+	got := rewriteCSS(css, strings.ToUpper)
+	expected := `@import "A.CSS"; b { background: url( 'B.PNG' ) } /* url(c.png) */`
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// partSuffix - окончание файла, в который большой ответ пишется по мере
+// скачивания: после обрыва -c продолжает его запросом Range.
+const partSuffix = ".part"
+
+// partialMin - с какого размера ответ пишется в файл .part.
+var partialMin int64 = 1 << 20
+
+// notModifiedHeader помечает ответ, собранный из сохраненного файла, когда
+// сервер ответил на условный запрос -N кодом 304.
+const notModifiedHeader = "X-Wget-Not-Modified"
+
+// transport - HTTP-транспорт коллекторов: продолжает начатые файлы (-c) и
+// спрашивает сервер, изменились ли сохраненные (-N). Коллектор получает
+// обычный ответ 200 с файлом целиком.
+type transport struct {
+	w    *Wget
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := t.w.localPath(req.URL)
+	part := path + partSuffix
+	sent := req.Clone(req.Context())
+
+	offset := int64(0)
+	if info, err := os.Stat(part); err == nil && t.w.cfg.resume {
+		offset = info.Size()
+		sent.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	} else if t.w.cfg.timestamping {
+		t.w.setConditional(sent.Header, req.URL, path)
+	}
+
+	resp, err := t.base.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset > 0 && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable) {
+		if resp.StatusCode == http.StatusPartialContent && strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return continuePartial(resp, part, offset)
+		}
+		// начатый файл не подходит к ответу: скачиваем заново
+		resp.Body.Close()
+		os.Remove(part)
+		return t.RoundTrip(req)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return t.notModified(resp, req.URL, path), nil
+	case resp.StatusCode == http.StatusOK && resp.ContentLength >= partialMin:
+		return writePartial(resp, part), nil
+	}
+	return resp, nil
+}
+
+// setConditional добавляет в запрос дату сохраненного файла и ETag
+// прошлого ответа. Файлы, переписанные -k, скачиваются заново: ссылки в
+// них уже не те, что на сервере.
+func (w *Wget) setConditional(header http.Header, u *url.URL, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	file := w.saved[urlKey(u)]
+	w.mu.Unlock()
+	if file != nil && file.converted {
+		return
+	}
+
+	header.Set("If-Modified-Since", info.ModTime().UTC().Format(http.TimeFormat))
+	if file != nil && file.etag != "" {
+		header.Set("If-None-Match", file.etag)
+	}
+}
+
+// notModified заменяет ответ 304 сохраненным файлом: ссылки неизмененной
+// страницы тоже нужно обойти.
+func (t *transport) notModified(resp *http.Response, u *url.URL, path string) *http.Response {
+	file, err := os.Open(path)
+	if err != nil {
+		return resp
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return resp
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	t.w.mu.Lock()
+	if saved, ok := t.w.saved[urlKey(u)]; ok && saved.contentType != "" {
+		contentType = saved.contentType
+	}
+	t.w.mu.Unlock()
+
+	resp.Body.Close()
+	resp.StatusCode = http.StatusOK
+	resp.Status = "200 OK"
+	resp.Body = file
+	resp.ContentLength = info.Size()
+	resp.Header.Set("Content-Type", contentType)
+	resp.Header.Set(notModifiedHeader, "1")
+	return resp
+}
+
+// writePartial пишет тело ответа в файл part по мере чтения.
+func writePartial(resp *http.Response, part string) *http.Response {
+	os.MkdirAll(filepath.Dir(part), os.ModePerm)
+	file, err := os.Create(part)
+	if err != nil {
+		return resp
+	}
+
+	body := resp.Body
+	resp.Body = &partBody{Reader: io.TeeReader(body, file), closers: []io.Closer{body, file}}
+	return resp
+}
+
+// continuePartial дописывает ответ 206 в файл part и отдает тело целиком:
+// начало с диска, остальное из сети.
+func continuePartial(resp *http.Response, part string, offset int64) (*http.Response, error) {
+	head, err := os.Open(part)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	tail, err := os.OpenFile(part, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		head.Close()
+		resp.Body.Close()
+		return nil, err
+	}
+
+	fmt.Println("continuing: ", part)
+	body := resp.Body
+	resp.Body = &partBody{
+		Reader:  io.MultiReader(io.LimitReader(head, offset), io.TeeReader(body, tail)),
+		closers: []io.Closer{body, head, tail},
+	}
+	resp.StatusCode = http.StatusOK
+	resp.Status = "200 OK"
+	if resp.ContentLength >= 0 {
+		resp.ContentLength += offset
+	}
+	resp.Header.Del("Content-Range")
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// partBody - тело ответа, которое при чтении пишется в файл .part.
+type partBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *partBody) Close() error {
+	var err error
+	for _, closer := range b.closers {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
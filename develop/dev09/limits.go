@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	colly "github.com/gocolly/colly/v2"
+)
+
+// activeRequest - идущий запрос: занятое место на хосте и ссылка в очереди.
+type activeRequest struct {
+	slot  chan struct{}
+	key   string
+	depth int
+}
+
+// StartRequest ждет свободного места для запроса к хосту (-parallel) и
+// отменяет запрос, если бюджет скачивания (-max-pages, -Q) исчерпан.
+// Проверка после ожидания: к этому времени учтены байты предыдущих ответов.
+func (w *Wget) StartRequest(r *colly.Request) {
+	slot := w.hostSlots(r.URL.Host)
+	slot <- struct{}{}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.overBudget() {
+		w.exceeded = true
+		<-slot
+		r.Abort()
+		return
+	}
+	w.pages++
+	key := urlKey(r.URL)
+	w.active[r] = &activeRequest{slot: slot, key: key, depth: w.queue[key].Depth}
+}
+
+// FinishRequest освобождает место запроса после паузы -w, а законченную
+// ссылку (done) убирает из очереди. Вызывается и после ответа, и после
+// ошибки: повторный вызов ничего не делает.
+func (w *Wget) FinishRequest(r *colly.Request, done bool) {
+	w.mu.Lock()
+	active, ok := w.active[r]
+	delete(w.active, r)
+	if ok && done {
+		delete(w.queue, active.key)
+	}
+	w.mu.Unlock()
+
+	if ok {
+		time.Sleep(w.wait())
+		<-active.slot
+	}
+}
+
+// depth - глубина страницы запроса r для -l.
+func (w *Wget) depth(r *colly.Request) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if active, ok := w.active[r]; ok {
+		return active.depth
+	}
+	return 0
+}
+
+// hostSlots - места для одновременных запросов к хосту.
+func (w *Wget) hostSlots(host string) chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	slots, ok := w.hosts[host]
+	if !ok {
+		parallel := w.cfg.parallel
+		if parallel < 1 {
+			parallel = 1
+		}
+		slots = make(chan struct{}, parallel)
+		w.hosts[host] = slots
+	}
+	return slots
+}
+
+func (w *Wget) overBudget() bool {
+	return w.cfg.maxPages > 0 && w.pages >= w.cfg.maxPages ||
+		w.cfg.quota > 0 && w.bytes >= w.cfg.quota
+}
+
+// wait - пауза после запроса к хосту. С --random-wait, как в wget,
+// она случайна: от половины до полутора -w.
+func (w *Wget) wait() time.Duration {
+	if !w.cfg.randomWait || w.cfg.wait <= 0 {
+		return w.cfg.wait
+	}
+	return w.cfg.wait/2 + time.Duration(rand.Int63n(int64(w.cfg.wait)))
+}
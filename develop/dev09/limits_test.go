@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRobots(t *testing.T) {
+	site := NewSite(t, map[string]string{
+		"/robots.txt": "User-agent: blocked\nDisallow: /\n\nUser-agent: *\nDisallow: /private\n",
+		"/":           `<a href="/public">public</a> <a href="/private">private</a>`,
+		"/public":     "public",
+		"/private":    "private",
+	})
+
+	tests := []struct {
+		robots    bool
+		userAgent string
+		saved     []string
+		skipped   []string
+		err       bool
+	}{
+		{true, "Go-wget/1.0", []string{"index.html", "public/index.html"}, []string{"private/index.html"}, false},
+		{false, "Go-wget/1.0", []string{"index.html", "public/index.html", "private/index.html"}, nil, false},
+		{true, "blocked", nil, []string{"index.html"}, true},
+		{false, "blocked", []string{"index.html", "private/index.html"}, nil, false},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		cfg := NewTestConfig(t, site.URL, dir)
+		cfg.robots = test.robots
+		cfg.userAgent = test.userAgent
+
+		err := NewWget(cfg).Run()
+		if (err != nil) != test.err {
+			t.Errorf("robots=%v, %s: expected error %v, got %v", test.robots, test.userAgent, test.err, err)
+		}
+		for _, name := range test.saved {
+			ReadSaved(t, dir, filepath.Join("127.0.0.1", name))
+		}
+		for _, name := range test.skipped {
+			if _, err := os.Stat(filepath.Join(dir, "127.0.0.1", name)); err == nil {
+				t.Errorf("robots=%v, %s: %s is saved", test.robots, test.userAgent, name)
+			}
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	mu := sync.Mutex{}
+	inFlight, maxInFlight, requests := 0, 0, 0
+	userAgents := map[string]bool{}
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		requests++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		userAgents[r.UserAgent()] = true
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path == "/" {
+			for i := 0; i < 6; i++ {
+				fmt.Fprintf(w, `<a href="/%d">%d</a>`, i, i)
+			}
+		}
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer site.Close()
+
+	tests := []struct {
+		parallel int
+		wait     time.Duration
+		minTime  time.Duration
+	}{
+		{1, 0, 7 * 20 * time.Millisecond},
+		{2, 0, 4 * 20 * time.Millisecond},
+		{1, 10 * time.Millisecond, 7*20*time.Millisecond + 6*10*time.Millisecond},
+		{3, 30 * time.Millisecond, 3*20*time.Millisecond + 2*30*time.Millisecond},
+	}
+
+	for _, test := range tests {
+		inFlight, maxInFlight, requests = 0, 0, 0
+		userAgents = map[string]bool{}
+
+		cfg := NewTestConfig(t, site.URL, t.TempDir())
+		cfg.parallel = test.parallel
+		cfg.wait = test.wait
+		cfg.userAgent = "test-agent"
+
+		start := time.Now()
+		if err := NewWget(cfg).Run(); err != nil {
+			t.Fatal(err)
+		}
+		elapsed := time.Since(start)
+
+		if requests != 7 || maxInFlight > test.parallel || elapsed < test.minTime {
+			t.Errorf("parallel=%d, wait=%v: expected 7 requests, at most %d at once and at least %v, got %d, %d and %v",
+				test.parallel, test.wait, test.parallel, test.minTime, requests, maxInFlight, elapsed)
+		}
+		if len(userAgents) != 1 || !userAgents["test-agent"] {
+			t.Errorf("expected User-Agent test-agent, got %v", userAgents)
+		}
+	}
+}
+
+func TestRandomWait(t *testing.T) {
+	w := &Wget{cfg: &Config{wait: 100 * time.Millisecond, randomWait: true}}
+	for i := 0; i < 100; i++ {
+		if wait := w.wait(); wait < 50*time.Millisecond || wait >= 150*time.Millisecond {
+			t.Fatalf("expected random wait from 50ms to 150ms, got %v", wait)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	root := `<a href="/1">1</a><a href="/2">2</a><a href="/3">3</a><a href="/4">4</a>`
+	site := NewSite(t, map[string]string{
+		"/":  root,
+		"/1": strings.Repeat("1", 100),
+		"/2": strings.Repeat("2", 100),
+		"/3": strings.Repeat("3", 100),
+		"/4": strings.Repeat("4", 100),
+	})
+
+	tests := []struct {
+		maxPages int
+		quota    int64
+		files    int
+	}{
+		{0, 0, 5},
+		{3, 0, 3},
+		{1, 0, 1},
+		// квота проверяется перед запросом: превысивший ее файл скачивается целиком
+		{0, int64(len(root)), 1},
+		{0, int64(len(root)) + 1, 2},
+		{0, int64(len(root)) + 101, 3},
+		{2, int64(len(root)) + 101, 2},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		cfg := NewTestConfig(t, site.URL, dir)
+		cfg.maxPages = test.maxPages
+		cfg.quota = test.quota
+		if err := NewWget(cfg).Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		files := 0
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files++
+			}
+			return nil
+		})
+		if files != test.files {
+			t.Errorf("max-pages=%d, quota=%d: expected %d files, got %d", test.maxPages, test.quota, test.files, files)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		value string
+		size  int64
+		err   bool
+	}{
+		{"100", 100, false},
+		{"2k", 2048, false},
+		{"3M", 3 << 20, false},
+		{"1g", 1 << 30, false},
+		{"", 0, true},
+		{"k", 0, true},
+		{"-1", 0, true},
+		{"1.5m", 0, true},
+	}
+
+	for _, test := range tests {
+		var size byteSize
+		err := size.Set(test.value)
+		if (err != nil) != test.err || int64(size) != test.size {
+			t.Errorf("%q: expected %d and error %v, got %d and %v", test.value, test.size, test.err, size, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageRequisites(t *testing.T) {
+	other := NewSite(t, map[string]string{
+		"/logo.png": "png",
+		// файл страницы с другого хоста скачивается, но ссылки в нем - нет
+		"/frame":  `<a href="/secret">secret</a><img src="/secret.png">`,
+		"/secret": "secret",
+	})
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	site := NewSite(t, map[string]string{
+		"/": `<html><head>
+<link rel="stylesheet" href="/style.css"><link rel="Shortcut Icon" href="/favicon.ico">
+<link rel="alternate" href="/feed">
+<style>@import "/print.css";</style>
+</head><body>
+<img src="/img/a.png"> <img srcset="/img/b.png 2x, /img/b1.png 1x">
+<picture><source srcset="/img/e.webp"></picture>
+<script src="/app.js"></script> <video poster="/img/poster.jpg"><source src="/movie.mp4"></video>
+<div style="background: url('/img/c.png')"></div>
+<img src="` + otherURL + `/logo.png"> <img src="` + otherURL + `/frame">
+<a href="/next">next</a>
+</body></html>`,
+		"/style.css":      `@import url(more.css); body { font: url(fonts/f.woff) }`,
+		"/more.css":       `p { background: url("/img/d.png") }`,
+		"/print.css":      `p { color: black }`,
+		"/favicon.ico":    "ico",
+		"/feed":           "feed",
+		"/img/a.png":      "a",
+		"/img/b.png":      "b",
+		"/img/b1.png":     "b1",
+		"/img/c.png":      "c",
+		"/img/d.png":      "d",
+		"/img/e.webp":     "e",
+		"/img/poster.jpg": "poster",
+		"/movie.mp4":      "movie",
+		"/app.js":         "js",
+		"/fonts/f.woff":   "font",
+		"/next":           "next",
+	})
+	dir := t.TempDir()
+
+	// файлы страницы скачиваются и за пределами глубины
+	cfg := NewTestConfig(t, site.URL, dir)
+	cfg.depth = 1
+	cfg.pageRequisites = true
+	cfg.convertLinks = true
+	if err := NewWget(cfg).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := []string{
+		"127.0.0.1/index.html", "127.0.0.1/style.css", "127.0.0.1/more.css", "127.0.0.1/print.css",
+		"127.0.0.1/favicon.ico", "127.0.0.1/img/a.png", "127.0.0.1/img/b.png", "127.0.0.1/img/b1.png",
+		"127.0.0.1/img/c.png", "127.0.0.1/img/d.png", "127.0.0.1/img/e.webp", "127.0.0.1/img/poster.jpg",
+		"127.0.0.1/movie.mp4", "127.0.0.1/app.js", "127.0.0.1/fonts/f.woff",
+		"localhost/logo.png", "localhost/frame/index.html",
+	}
+	for _, name := range saved {
+		ReadSaved(t, dir, name)
+	}
+
+	// с -k файлы с других хостов тоже открываются локально
+	page := ReadSaved(t, dir, "127.0.0.1/index.html")
+	for _, part := range []string{`src="img/a.png"`, `src="../localhost/logo.png"`, `srcset="img/b.png 2x, img/b1.png 1x"`, `href="` + site.URL + `/next"`} {
+		if !strings.Contains(page, part) {
+			t.Errorf("expected %q in %q", part, page)
+		}
+	}
+
+	for _, name := range []string{"127.0.0.1/next", "127.0.0.1/feed", "localhost/secret", "localhost/secret.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s is saved", name)
+		}
+	}
+}
+
+func TestImageLinks(t *testing.T) {
+	site := NewSite(t, map[string]string{
+		"/":       `<img src="/a.png"><script src="/app.js"></script>`,
+		"/a.png":  "a",
+		"/app.js": "js",
+	})
+	dir := t.TempDir()
+
+	if err := NewWget(NewTestConfig(t, site.URL, dir)).Run(); err != nil {
+		t.Fatal(err)
+	}
+	ReadSaved(t, dir, "127.0.0.1/a.png")
+	// без -p скрипты не скачиваются
+	if _, err := os.Stat(filepath.Join(dir, "127.0.0.1/app.js")); err == nil {
+		t.Error("app.js is saved without -p")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// inScope - переходить ли по ссылке u со страницы. Хост: без -same-host,
+// -s и -domains - любой, иначе начальный, с -s еще его поддомены, с
+// -domains - перечисленные домены и их поддомены. Затем -np, -A,
+// -accept-regex и явные исключения excluded.
+func (w *Wget) inScope(u *url.URL) bool {
+	if w.excluded(u) || !w.hostInScope(u.Hostname()) {
+		return false
+	}
+
+	// -np: не выше каталога начальной страницы на ее хосте
+	if w.cfg.noParent && strings.EqualFold(u.Host, w.start.Host) && !strings.HasPrefix(pathOf(u), parentDir(w.start)) {
+		return false
+	}
+
+	if w.cfg.acceptRegex != nil && !w.cfg.acceptRegex.MatchString(u.String()) {
+		return false
+	}
+	// страницы скачиваются и без -A: по ним идет обход, Save их не сохранит
+	return isPage(u) || w.accepted(u)
+}
+
+// excluded - исключена ли ссылка явно: -exclude-domains, -R и
+// -reject-regex. Проверяется и для файлов страниц с -p.
+func (w *Wget) excluded(u *url.URL) bool {
+	for _, domain := range w.cfg.excludeDomains {
+		if matchDomain(u.Hostname(), domain) {
+			return true
+		}
+	}
+	if len(w.cfg.reject) > 0 && matchSuffix(pathOf(u), w.cfg.reject) {
+		return true
+	}
+	return w.cfg.rejectRegex != nil && w.cfg.rejectRegex.MatchString(u.String())
+}
+
+// accepted - сохранять ли файл по -A.
+func (w *Wget) accepted(u *url.URL) bool {
+	return len(w.cfg.accept) == 0 || matchSuffix(pathOf(u), w.cfg.accept)
+}
+
+func (w *Wget) hostInScope(host string) bool {
+	if !w.cfg.sameHost && !w.cfg.onlySubdomains && len(w.cfg.domains) == 0 {
+		return true
+	}
+
+	if strings.EqualFold(strings.TrimSuffix(host, "."), w.cfg.hostname) ||
+		w.cfg.onlySubdomains && matchDomain(host, w.cfg.hostname) {
+		return true
+	}
+	for _, domain := range w.cfg.domains {
+		if matchDomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain - host это domain или его поддомен: сравниваются целые
+// метки, evil-example.com и example.com.attacker.net не подходят к
+// example.com.
+func matchDomain(host, domain string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// matchSuffix - подходит ли имя файла к списку -A/-R: суффикс вроде jpg
+// или .jpg, а с *, ? или [ - шаблон имени.
+func matchSuffix(p string, patterns []string) bool {
+	name := strings.ToLower(path.Base(p))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if strings.ContainsAny(pattern, "*?[") {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		} else if strings.HasSuffix(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPage - ссылка на страницу, а не на файл: без расширения или .html.
+func isPage(u *url.URL) bool {
+	switch strings.ToLower(path.Ext(pathOf(u))) {
+	case "", ".html", ".htm":
+		return true
+	}
+	return false
+}
+
+func pathOf(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// parentDir - каталог страницы для -np: /a/b/ для /a/b/ и /a/b/page.
+func parentDir(u *url.URL) string {
+	p := pathOf(u)
+	return p[:strings.LastIndex(p, "/")+1]
+}
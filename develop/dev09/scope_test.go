@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestInScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		links map[string]bool
+	}{
+		{"any host", Config{}, map[string]bool{
+			"https://example.com/a": true, "https://other.org/": true,
+		}},
+		{"same host", Config{sameHost: true}, map[string]bool{
+			"https://example.com/a": true, "http://EXAMPLE.com./b": true, "https://www.example.com/": false,
+		}},
+		// точки - не шаблон, метки сравниваются целиком
+		{"subdomains", Config{onlySubdomains: true}, map[string]bool{
+			"https://example.com/a": true, "https://www.example.com/": true, "https://a.b.example.com/": true,
+			"https://evil-example.com/": false, "https://example.com.attacker.net/": false, "https://exampleXcom/": false,
+		}},
+		{"domains", Config{domains: []string{"cdn.net", ".static.org"}}, map[string]bool{
+			"https://example.com/": true, "https://cdn.net/x": true, "https://img.cdn.net/x": true,
+			"https://www.static.org/": true, "https://www.example.com/": false, "https://notcdn.net/": false,
+		}},
+		{"exclude domains", Config{onlySubdomains: true, excludeDomains: []string{"ads.example.com"}}, map[string]bool{
+			"https://www.example.com/": true, "https://ads.example.com/": false, "https://x.ads.example.com/": false,
+		}},
+		{"no parent", Config{noParent: true}, map[string]bool{
+			"https://example.com/docs/": true, "https://example.com/docs/a/b": true, "https://example.com/docs": false,
+			"https://example.com/": false, "https://example.com/docsx/": false, "https://other.org/": true,
+		}},
+		// страницы не по -A скачиваются для обхода ссылок
+		{"accept", Config{accept: []string{"jpg", "*.PNG"}}, map[string]bool{
+			"https://example.com/a.jpg": true, "https://example.com/b.png": true, "https://example.com/c.gif": false,
+			"https://example.com/page": true, "https://example.com/page.html": true,
+		}},
+		{"reject", Config{reject: []string{".gif", "page*"}}, map[string]bool{
+			"https://example.com/a.jpg": true, "https://example.com/c.gif": false, "https://example.com/page.html": false,
+		}},
+		{"regexp", Config{acceptRegex: regexp.MustCompile(`/docs/`), rejectRegex: regexp.MustCompile(`\?print`)}, map[string]bool{
+			"https://example.com/docs/a": true, "https://example.com/blog/a": false, "https://example.com/docs/a?print=1": false,
+		}},
+	}
+
+	for _, test := range tests {
+		cfg := test.cfg
+		cfg.url = "https://example.com/docs/index.html"
+		cfg.hostname = "example.com"
+		w := NewWget(&cfg)
+
+		for link, expected := range test.links {
+			u, err := url.Parse(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.inScope(u); got != expected {
+				t.Errorf("%s: %s: expected %v, got %v", test.name, link, expected, got)
+			}
+		}
+	}
+}
+
+func TestScopedCrawl(t *testing.T) {
+	site := NewSite(t, map[string]string{
+		"/docs/":      `<a href="a.jpg">a</a> <a href="b.gif">b</a> <a href="more">more</a> <a href="/up">up</a>`,
+		"/docs/a.jpg": "a",
+		"/docs/b.gif": "b",
+		"/docs/more":  `<a href="c.jpg">c</a>`,
+		"/docs/c.jpg": "c",
+		"/up":         "up",
+	})
+	dir := t.TempDir()
+
+	cfg := NewTestConfig(t, site.URL+"/docs/", dir)
+	cfg.noParent = true
+	cfg.accept = []string{"jpg"}
+	if err := NewWget(cfg).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"docs/a.jpg", "docs/c.jpg"} {
+		ReadSaved(t, dir, filepath.Join("127.0.0.1", name))
+	}
+	for _, name := range []string{"docs/index.html", "docs/b.gif", "docs/more/index.html", "up/index.html"} {
+		if _, err := os.Stat(filepath.Join(dir, "127.0.0.1", name)); err == nil {
+			t.Errorf("%s is saved", name)
+		}
+	}
+}
+
+func TestRedirectScope(t *testing.T) {
+	foreignRequests := 0
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignRequests++
+		fmt.Fprint(w, "foreign")
+	}))
+	defer foreign.Close()
+	foreignURL := strings.Replace(foreign.URL, "127.0.0.1", "localhost", 1)
+
+	redirects := map[string]string{
+		"/moved":   foreignURL + "/page",
+		"/old":     "/new",
+		"/pic.png": foreignURL + "/pic.png",
+	}
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target, ok := redirects[r.URL.Path]; ok {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<a href="/moved">moved</a> <a href="/old">old</a> <img src="/pic.png">`)
+		case "/new":
+			fmt.Fprint(w, "new")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	tests := []struct {
+		name  string
+		cfg   func(*Config)
+		saved []string
+	}{
+		// страница не уходит на чужой хост и через перенаправление
+		{"same host", func(cfg *Config) { cfg.onlySubdomains, cfg.sameHost = false, true }, nil},
+		// файлы страницы с -p берутся с любого хоста, кроме исключенных
+		{"requisites", func(cfg *Config) { cfg.pageRequisites = true }, []string{"localhost/pic.png"}},
+		{"excluded requisites", func(cfg *Config) {
+			cfg.pageRequisites = true
+			cfg.excludeDomains = []string{"localhost"}
+		}, nil},
+	}
+
+	for _, test := range tests {
+		foreignRequests = 0
+		dir := t.TempDir()
+		cfg := NewTestConfig(t, site.URL, dir)
+		test.cfg(cfg)
+		if err := NewWget(cfg).Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		ReadSaved(t, dir, "127.0.0.1/index.html")
+		if data := ReadSaved(t, dir, "127.0.0.1/new/index.html"); data != "new" {
+			t.Errorf("%s: expected redirect on the same host to be followed, got %q", test.name, data)
+		}
+		for _, name := range test.saved {
+			ReadSaved(t, dir, name)
+		}
+		if foreignRequests != len(test.saved) {
+			t.Errorf("%s: expected %d requests to foreign host, got %d", test.name, len(test.saved), foreignRequests)
+		}
+		for _, name := range []string{"127.0.0.1/moved", "127.0.0.1/moved/index.html", "localhost/page/index.html"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				t.Errorf("%s: %s is saved", test.name, name)
+			}
+		}
+	}
+}
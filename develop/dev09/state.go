@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// stateFile - состояние скачивания в каталоге -P: очередь, посещенные
+// ссылки и сохраненные файлы. По нему -c продолжает прерванное
+// скачивание, а -N делает условные запросы. Без -c и -N оно не пишется:
+// обычное зеркало не содержит лишних файлов.
+const stateFile = ".wget-state.json"
+
+type crawlState struct {
+	URL     string      `json:"url"`
+	Visited []string    `json:"visited"`
+	Queue   []queued    `json:"queue"`
+	Files   []fileState `json:"files"`
+}
+
+// fileState - сохраненный файл. Путь относительно каталога -P.
+type fileState struct {
+	URL          string `json:"url"`
+	Path         string `json:"path"`
+	ContentType  string `json:"content_type,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Converted    bool   `json:"converted,omitempty"`
+}
+
+func (w *Wget) statePath() string {
+	return filepath.Join(w.cfg.dir, stateFile)
+}
+
+// LoadState читает состояние прошлого запуска: с -c все, с -N только
+// сохраненные файлы. Возвращает, продолжается ли прошлое скачивание.
+func (w *Wget) LoadState() (bool, error) {
+	if !w.cfg.resume && !w.cfg.timestamping {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(w.statePath())
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	state := crawlState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return false, fmt.Errorf("%s: %w", w.statePath(), err)
+	}
+	if w.cfg.resume && state.URL != w.cfg.url {
+		return false, fmt.Errorf("%s: saved download of %s, not %s", w.statePath(), state.URL, w.cfg.url)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, file := range state.Files {
+		u, err := url.Parse(file.URL)
+		if err != nil {
+			continue
+		}
+		w.saved[urlKey(u)] = &savedFile{
+			url:          u,
+			path:         filepath.Join(w.cfg.dir, file.Path),
+			contentType:  file.ContentType,
+			etag:         file.ETag,
+			lastModified: file.LastModified,
+			converted:    file.Converted,
+		}
+	}
+
+	if !w.cfg.resume {
+		return false, nil
+	}
+	for _, key := range state.Visited {
+		w.visited[key] = struct{}{}
+	}
+	for _, link := range state.Queue {
+		if u, err := url.Parse(link.URL); err == nil {
+			w.visited[urlKey(u)] = struct{}{}
+			w.queue[urlKey(u)] = link
+		}
+	}
+	return true, nil
+}
+
+// SaveState с -c или -N записывает состояние через временный файл:
+// прерывание во время записи не портит прошлое состояние. Возвращает,
+// записано ли состояние.
+func (w *Wget) SaveState() (bool, error) {
+	if !w.cfg.resume && !w.cfg.timestamping {
+		return false, nil
+	}
+
+	w.mu.Lock()
+	state := crawlState{URL: w.cfg.url, Visited: []string{}, Queue: []queued{}, Files: []fileState{}}
+	for key := range w.visited {
+		if _, ok := w.queue[key]; !ok {
+			state.Visited = append(state.Visited, key)
+		}
+	}
+	for _, link := range w.queue {
+		state.Queue = append(state.Queue, link)
+	}
+	for _, file := range w.saved {
+		path, err := filepath.Rel(w.cfg.dir, file.path)
+		if err != nil {
+			continue
+		}
+		state.Files = append(state.Files, fileState{
+			URL:          file.url.String(),
+			Path:         path,
+			ContentType:  file.contentType,
+			ETag:         file.etag,
+			LastModified: file.lastModified,
+			Converted:    file.converted,
+		})
+	}
+	w.mu.Unlock()
+
+	sort.Strings(state.Visited)
+	sort.Slice(state.Queue, func(i, j int) bool { return state.Queue[i].URL < state.Queue[j].URL })
+	sort.Slice(state.Files, func(i, j int) bool { return state.Files[i].URL < state.Files[j].URL })
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(w.cfg.dir, os.ModePerm); err != nil {
+		return false, err
+	}
+	tmp := w.statePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, w.statePath()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NewCountingSite - сайт с подсчетом запросов к путям.
+func NewCountingSite(t *testing.T, handler http.HandlerFunc) (*httptest.Server, func() map[string]int) {
+	mu := sync.Mutex{}
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+		counts := requests
+		requests = map[string]int{}
+		return counts
+	}
+}
+
+func TestResume(t *testing.T) {
+	site, requests := NewCountingSite(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<a href="/1">1</a><a href="/2">2</a><a href="/3">3</a>`)
+		}
+		fmt.Fprint(w, r.URL.Path)
+	})
+	dir := t.TempDir()
+
+	// прерванное бюджетом скачивание с -c оставляет ссылки в очереди
+	cfg := NewTestConfig(t, site.URL, dir)
+	cfg.maxPages = 2
+	cfg.resume = true
+	if err := NewWget(cfg).Run(); err != nil {
+		t.Fatal(err)
+	}
+	first := requests()
+
+	cfg = NewTestConfig(t, site.URL, dir)
+	cfg.resume = true
+	if err := NewWget(cfg).Run(); err != nil {
+		t.Fatal(err)
+	}
+	second := requests()
+
+	for _, path := range []string{"/", "/1", "/2", "/3"} {
+		if first[path]+second[path] != 1 {
+			t.Errorf("%s: expected 1 request, got %d and %d", path, first[path], second[path])
+		}
+	}
+	for _, name := range []string{"index.html", "1/index.html", "2/index.html", "3/index.html"} {
+		ReadSaved(t, dir, filepath.Join("127.0.0.1", name))
+	}
+
+	// законченное скачивание нечего продолжать
+	if err := NewWget(cfg).Run(); err != nil || len(requests()) != 0 {
+		t.Errorf("expected no requests after finished download, got %v", err)
+	}
+
+	cfg = NewTestConfig(t, site.URL+"/other", dir)
+	cfg.resume = true
+	if err := NewWget(cfg).Run(); err == nil {
+		t.Errorf("expected error for state of another url")
+	}
+}
+
+func TestContinuePartial(t *testing.T) {
+	defer func(min int64) { partialMin = min }(partialMin)
+	partialMin = 10
+
+	content := bytes.Repeat([]byte("0123456789"), 10)
+	ranges := []string{}
+	broken := true
+	site, _ := NewCountingSite(t, func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if !broken {
+			http.ServeContent(w, r, "big.bin", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		// обрыв соединения посреди файла
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n%s", len(content), content[:40])
+		buf.Flush()
+		conn.Close()
+	})
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "127.0.0.1", "big.bin")
+
+	cfg := NewTestConfig(t, site.URL+"/big.bin", dir)
+	if err := NewWget(cfg).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(saved + partSuffix); err != nil || !bytes.Equal(data, content[:40]) {
+		t.Fatalf("expected partial file with 40 bytes, got %q, %v", data, err)
+	}
+
+	broken = false
+	cfg.resume = true
+	if err := NewWget(cfg).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if data := ReadSaved(t, dir, filepath.Join("127.0.0.1", "big.bin")); data != string(content) {
+		t.Errorf("expected %q, got %q", content, data)
+	}
+	if _, err := os.Stat(saved + partSuffix); err == nil {
+		t.Errorf("partial file is not removed")
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=40-" {
+		t.Errorf("expected Range bytes=40-, got %q", ranges)
+	}
+}
+
+func TestTimestamping(t *testing.T) {
+	modified := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	statuses := []int{}
+	site, _ := NewCountingSite(t, func(w http.ResponseWriter, r *http.Request) {
+		pages := map[string]string{"/": `<a href="/a.html">a</a>`, "/a.html": "a"}
+		name := "index.html"
+		if r.URL.Path != "/" {
+			name = r.URL.Path
+		}
+
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, modified.Unix()))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		http.ServeContent(recorder, r, name, modified, bytes.NewReader([]byte(pages[r.URL.Path])))
+		statuses = append(statuses, recorder.status)
+	})
+
+	tests := []struct {
+		timestamping bool
+		convertLinks bool
+		changed      bool
+		statuses     []int
+	}{
+		{true, false, false, []int{200, 200}},
+		{true, false, false, []int{304, 304}},
+		{true, false, true, []int{200, 200}},
+		// переписанная -k страница скачивается заново, файл без ссылок - нет
+		{true, true, false, []int{304, 304}},
+		{true, false, false, []int{200, 304}},
+	}
+
+	dir := t.TempDir()
+	for i, test := range tests {
+		if test.changed {
+			modified = modified.Add(time.Hour)
+		}
+		statuses = []int{}
+
+		cfg := NewTestConfig(t, site.URL, dir)
+		cfg.timestamping = test.timestamping
+		cfg.convertLinks = test.convertLinks
+		if err := NewWget(cfg).Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprint(statuses) != fmt.Sprint(test.statuses) {
+			t.Errorf("%d: expected statuses %v, got %v", i, test.statuses, statuses)
+		}
+		if data := ReadSaved(t, dir, "127.0.0.1/a.html"); data != "a" {
+			t.Errorf("%d: expected a.html to be %q, got %q", i, "a", data)
+		}
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func TestSaveStateFlags(t *testing.T) {
+	tests := []struct {
+		resume, timestamping bool
+		saved                bool
+	}{
+		{false, false, false},
+		{true, false, true},
+		{false, true, true},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		cfg := NewTestConfig(t, "http://127.0.0.1/", dir)
+		cfg.resume, cfg.timestamping = test.resume, test.timestamping
+
+		saved, err := NewWget(cfg).SaveState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, statErr := os.Stat(filepath.Join(dir, stateFile))
+		if saved != test.saved || (statErr == nil) != test.saved {
+			t.Errorf("-c=%v -N=%v: expected saved %v, got %v and %v", test.resume, test.timestamping, test.saved, saved, statErr)
+		}
+	}
+}
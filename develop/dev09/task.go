@@ -6,10 +6,12 @@ import (
 	"log"
 	"net/url"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"regexp"
-
-	colly "github.com/gocolly/colly/v2"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 /*
@@ -25,14 +27,57 @@ type Config struct {
 	hostname string
 
 	depth          int
+	sameHost       bool
 	onlySubdomains bool
+	convertLinks   bool
+	pageRequisites bool
+	dir            string
+
+	robots     bool
+	userAgent  string
+	wait       time.Duration
+	randomWait bool
+	parallel   int
+	maxPages   int
+	quota      int64
+
+	resume       bool
+	timestamping bool
+
+	domains        []string
+	excludeDomains []string
+	noParent       bool
+	accept         []string
+	reject         []string
+	acceptRegex    *regexp.Regexp
+	rejectRegex    *regexp.Regexp
 }
 
 func NewConfig() *Config {
 	cfg := &Config{}
 
 	flag.IntVar(&cfg.depth, "l", 0, "Max depth level. 0 means infinite")
-	flag.BoolVar(&cfg.onlySubdomains, "s", false, "Only match subdomains")
+	flag.BoolVar(&cfg.sameHost, "same-host", false, "Only follow links to the start host")
+	flag.BoolVar(&cfg.onlySubdomains, "s", false, "Only follow links to the start host and its subdomains")
+	flag.Var((*listFlag)(&cfg.domains), "domains", "Comma-separated domains to follow, with their subdomains")
+	flag.Var((*listFlag)(&cfg.excludeDomains), "exclude-domains", "Comma-separated domains not to follow")
+	flag.BoolVar(&cfg.noParent, "np", false, "Don't ascend above the start page directory")
+	flag.Var((*listFlag)(&cfg.accept), "A", "Comma-separated file name suffixes or patterns to save")
+	flag.Var((*listFlag)(&cfg.reject), "R", "Comma-separated file name suffixes or patterns to skip")
+	flag.Var(regexpFlag{&cfg.acceptRegex}, "accept-regex", "Follow only URLs matching the regexp")
+	flag.Var(regexpFlag{&cfg.rejectRegex}, "reject-regex", "Don't follow URLs matching the regexp")
+	flag.BoolVar(&cfg.convertLinks, "k", false, "Convert links in saved pages for offline browsing")
+	flag.BoolVar(&cfg.pageRequisites, "p", false, "Download images, scripts and stylesheets needed to display pages")
+	flag.StringVar(&cfg.dir, "P", ".", "Directory to save files to")
+	flag.BoolVar(&cfg.robots, "robots", true, "Respect robots.txt; -robots=false ignores it")
+	flag.StringVar(&cfg.userAgent, "U", "Go-wget/1.0", "User-Agent header")
+	flag.DurationVar(&cfg.wait, "w", 0, "Wait after each request to a host, e.g. 500ms")
+	flag.BoolVar(&cfg.randomWait, "random-wait", false, "Wait from 0.5 to 1.5 of -w")
+	flag.IntVar(&cfg.parallel, "parallel", 1, "Max parallel requests per host")
+	flag.IntVar(&cfg.maxPages, "max-pages", 0, "Max number of downloaded files. 0 means unlimited")
+	flag.Var((*byteSize)(&cfg.quota), "Q", "Download quota in bytes, with k, m or g suffix. 0 means unlimited")
+	flag.BoolVar(&cfg.resume, "c", false, "Continue interrupted download and partially downloaded files")
+	flag.BoolVar(&cfg.timestamping, "N", false, "Don't download files unless newer than saved ones")
 
 	flag.Parse()
 
@@ -40,6 +85,10 @@ func NewConfig() *Config {
 		fmt.Println("wget: depth is non-negative integer, where 0 means infinite depth")
 		os.Exit(1)
 	}
+	if cfg.parallel < 1 || cfg.maxPages < 0 || cfg.wait < 0 {
+		fmt.Println("wget: -parallel must be positive, -max-pages and -w non-negative")
+		os.Exit(1)
+	}
 
 	args := flag.Args()
 	if len(args) == 0 {
@@ -60,73 +109,103 @@ func NewConfig() *Config {
 	return cfg
 }
 
-type Wget struct {
-	*colly.Collector
-	visited map[string]struct{}
+// byteSize - размер для -Q: число байт с необязательным суффиксом k, m или g.
+type byteSize int64
+
+func (b *byteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
 }
 
-func NewWget(cfg *Config) *Wget {
-	opts := []colly.CollectorOption{}
+func (b *byteSize) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("empty size")
+	}
 
-	if cfg.depth > 0 {
-		opts = append(opts, colly.MaxDepth(cfg.depth))
+	multiplier := int64(1)
+	switch strings.ToLower(value[len(value)-1:]) {
+	case "k":
+		multiplier = 1 << 10
+	case "m":
+		multiplier = 1 << 20
+	case "g":
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
 	}
 
-	if cfg.onlySubdomains {
-		reg, err := regexp.Compile(`https?://.*?` + cfg.hostname)
-		if err != nil {
-			fmt.Println("bad regexp hostname", cfg.hostname)
-			os.Exit(1)
-		}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("bad size %q", value)
+	}
+	*b = byteSize(n * multiplier)
+	return nil
+}
+
+// listFlag - список через запятую; флаг можно повторять.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
 
-		opts = append(opts, colly.URLFilters(reg))
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
 	}
+	return nil
+}
+
+// regexpFlag - регулярное выражение, проверяется при разборе флагов.
+type regexpFlag struct {
+	re **regexp.Regexp
+}
 
-	return &Wget{
-		Collector: colly.NewCollector(opts...),
-		visited:   map[string]struct{}{},
+func (f regexpFlag) String() string {
+	if f.re == nil || *f.re == nil {
+		return ""
 	}
+	return (*f.re).String()
 }
 
-func (w *Wget) HandlePageLink(attr string) func(*colly.HTMLElement) {
-	return func(e *colly.HTMLElement) {
-		link := e.Request.AbsoluteURL(e.Attr(attr))
-		if _, ok := w.visited[link]; !ok {
-			w.visited[link] = struct{}{}
-			w.Visit(link)
-		}
+func (f regexpFlag) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
 	}
+	*f.re = re
+	return nil
 }
+
 func main() {
 	cfg := NewConfig()
 	wget := NewWget(cfg)
 
-	wget.OnHTML("a[href]", wget.HandlePageLink("href"))
-	wget.OnHTML("link[href]", wget.HandlePageLink("href"))
-	wget.OnHTML("img[src]", wget.HandlePageLink("href"))
-
-	wget.OnResponse(func(r *colly.Response) {
-		u := r.Request.URL
-		path := u.Path
-
-		if filepath.Ext(path) == "" {
-			path = filepath.Join(path, "index.html")
+	// с -c или -N по ^C сохраняется состояние: скачивание продолжит -c
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-interrupt
+		saved, err := wget.SaveState()
+		if err != nil {
+			log.Fatal(err)
 		}
-
-		fullpath := filepath.Join(u.Hostname(), path)
-		dir := filepath.Dir(fullpath)
-		if _, err := os.Stat(dir); err != nil {
-			os.MkdirAll(dir, os.ModePerm)
+		if saved {
+			fmt.Println("wget: interrupted, continue with -c")
 		}
 
-		fmt.Println("loading: ", fullpath)
-		r.Save(fullpath)
-	})
+		status := 1
+		if sig, ok := sig.(syscall.Signal); ok {
+			status = 128 + int(sig)
+		}
+		os.Exit(status)
+	}()
 
-	if err := wget.Visit(cfg.url); err != nil {
+	if err := wget.Run(); err != nil {
 		log.Fatal(err)
 	}
-	wget.Wait()
 }
 
 // $ go run . https://example.com
@@ -1 +1,53 @@
 package main
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// NewSite поднимает тестовый сайт: путь -> содержимое, {{site}} в
+// содержимом заменяется адресом сайта. Тип содержимого определяется по
+// расширению, без расширения - text/html.
+func NewSite(t *testing.T, pages map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		contentType := mime.TypeByExtension(filepath.Ext(r.URL.Path))
+		if contentType == "" {
+			contentType = "text/html; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(strings.ReplaceAll(body, "{{site}}", "http://"+r.Host)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// NewTestConfig - конфигурация для скачивания link в каталог dir.
+func NewTestConfig(t *testing.T, link, dir string) *Config {
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Config{url: u.String(), hostname: u.Hostname(), onlySubdomains: true, dir: dir}
+}
+
+// ReadSaved возвращает содержимое сохраненного файла или "" и ошибку теста.
+func ReadSaved(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Errorf("%s is not saved: %v", name, err)
+	}
+	return string(data)
+}
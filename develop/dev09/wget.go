@@ -0,0 +1,381 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	colly "github.com/gocolly/colly/v2"
+)
+
+type Wget struct {
+	*colly.Collector
+	assets *colly.Collector // файлы, нужные страницам (-p): с любого хоста
+	cfg    *Config
+	start  *url.URL // начальная страница: от нее считаются -same-host, -s и -np
+
+	// коллектор асинхронный: состояние ниже общее для его горутин
+	mu       sync.Mutex
+	visited  map[string]struct{}               // ссылки, уже поставленные в очередь, по urlKey
+	queue    map[string]queued                 // начатые, но не законченные скачивания
+	saved    map[string]*savedFile             // сохраненные файлы по urlKey
+	hosts    map[string]chan struct{}          // места для запросов к хосту
+	active   map[*colly.Request]*activeRequest // идущие запросы
+	pages    int                               // начатые скачивания
+	bytes    int64                             // скачанные байты
+	exceeded bool                              // бюджет исчерпан, часть ссылок пропущена
+}
+
+// savedFile - скачанный файл: откуда и куда сохранен. etag и lastModified
+// из ответа нужны для условных запросов -N.
+type savedFile struct {
+	url          *url.URL
+	path         string
+	contentType  string
+	etag         string
+	lastModified string
+	converted    bool // ссылки переписаны -k
+}
+
+// queued - ссылка в очереди. Depth - глубина для -l, у начальной страницы 1.
+type queued struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+	Asset bool   `json:"asset,omitempty"`
+}
+
+func NewWget(cfg *Config) *Wget {
+	start, err := url.Parse(cfg.url)
+	if err != nil {
+		fmt.Println("wget: bad url", err)
+		os.Exit(1)
+	}
+
+	// запросы ограничивает StartRequest, а не очередь colly; размер ответа
+	// не ограничен. colly держит тело целиком в памяти, большие файлы при
+	// этом еще копируются в .part, чтобы после обрыва их продолжил -c
+	opts := []colly.CollectorOption{colly.Async(true), colly.MaxBodySize(0)}
+	if cfg.userAgent != "" {
+		opts = append(opts, colly.UserAgent(cfg.userAgent))
+	}
+
+	w := &Wget{
+		Collector: colly.NewCollector(opts...),
+		cfg:       cfg,
+		start:     start,
+		visited:   map[string]struct{}{},
+		queue:     map[string]queued{},
+		saved:     map[string]*savedFile{},
+		hosts:     map[string]chan struct{}{},
+		active:    map[*colly.Request]*activeRequest{},
+	}
+	w.IgnoreRobotsTxt = !cfg.robots
+	w.WithTransport(&transport{w: w, base: http.DefaultTransport})
+	w.SetRedirectHandler(w.CheckRedirect)
+	w.limit(w.Collector)
+	w.OnResponse(w.Save)
+	w.OnHTML("a[href]", w.HandlePageLink("href"))
+
+	if !cfg.pageRequisites {
+		w.OnHTML("link[href]", w.HandlePageLink("href"))
+		w.OnHTML("img[src]", w.HandlePageLink("src"))
+		return w
+	}
+
+	// копия использует то же хранилище посещенных URL и тот же HTTP-клиент
+	w.assets = w.Clone()
+	w.assets.SetRedirectHandler(w.CheckRedirect)
+	w.limit(w.assets)
+	w.assets.OnResponse(w.Save)
+	w.assets.OnResponse(w.HandleStylesheet)
+	w.OnResponse(w.HandleStylesheet)
+
+	w.OnHTML("link[href]", w.HandleLink)
+	w.OnHTML("img[src], script[src], source[src], audio[src], video[src], embed[src], input[src], track[src]", w.HandleRequisite("src"))
+	w.OnHTML("img[srcset], source[srcset]", w.HandleSrcset)
+	w.OnHTML("video[poster]", w.HandleRequisite("poster"))
+	w.OnHTML("[style]", func(e *colly.HTMLElement) {
+		w.visitCSSRefs(e.Request, e.Attr("style"))
+	})
+	w.OnHTML("style", func(e *colly.HTMLElement) {
+		w.visitCSSRefs(e.Request, e.Text)
+	})
+
+	return w
+}
+
+// Run скачивает сайт, начиная с cfg.url, или с -c продолжает прошлое
+// скачивание, с -k переписывает ссылки в сохраненных страницах, а с -c
+// и -N сохраняет состояние для следующего запуска.
+func (w *Wget) Run() error {
+	resumed, err := w.LoadState()
+	if err != nil {
+		return err
+	}
+
+	if resumed {
+		w.mu.Lock()
+		queue := w.queue
+		w.queue = map[string]queued{}
+		w.mu.Unlock()
+
+		keys := []string{}
+		for key := range queue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			w.visit(key, queue[key])
+		}
+	} else {
+		// начальная страница скачивается всегда, правила -s, -np, -R и
+		// другие действуют на ссылки
+		w.visited[urlKey(w.start)] = struct{}{}
+		if err := w.visit(urlKey(w.start), queued{URL: w.cfg.url, Depth: 1}); err != nil {
+			return err
+		}
+	}
+
+	w.Wait()
+	if w.assets != nil {
+		w.assets.Wait()
+	}
+	if w.exceeded {
+		fmt.Println("wget: download quota exceeded")
+	}
+
+	if w.cfg.convertLinks {
+		if err := w.ConvertLinks(); err != nil {
+			return err
+		}
+	}
+	_, err = w.SaveState()
+	return err
+}
+
+// CheckRedirect проверяет перенаправление теми же правилами, что и ссылку:
+// страница - inScope, файл страницы с -p - только excluded. Начальная
+// страница, как и без перенаправления, скачивается всегда. Запрещенное
+// перенаправление не выполняется, ответ 3xx не сохраняется.
+func (w *Wget) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	// HTTP-клиент у коллекторов общий: страница это или файл, видно по очереди
+	key := urlKey(via[0].URL)
+	w.mu.Lock()
+	link := w.queue[key]
+	w.mu.Unlock()
+
+	if key == urlKey(w.start) {
+		return nil
+	}
+	if w.excluded(req.URL) || !link.Asset && !w.inScope(req.URL) {
+		fmt.Println("wget: not following redirect to", req.URL)
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+// limit ограничивает запросы коллектора c: места на хосте, паузы и бюджет.
+// Ссылка с ошибкой сети остается в очереди: ее повторит -c.
+func (w *Wget) limit(c *colly.Collector) {
+	c.OnRequest(w.StartRequest)
+	c.OnScraped(func(r *colly.Response) { w.FinishRequest(r.Request, true) })
+	c.OnError(func(r *colly.Response, err error) {
+		fmt.Println("wget:", r.Request.URL, err)
+		w.FinishRequest(r.Request, r.StatusCode != 0)
+	})
+}
+
+// enqueue ставит ссылку в очередь, если она еще не встречалась.
+func (w *Wget) enqueue(link queued) {
+	u, err := url.Parse(link.URL)
+	if link.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+
+	key := urlKey(u)
+	w.mu.Lock()
+	_, ok := w.visited[key]
+	w.visited[key] = struct{}{}
+	w.mu.Unlock()
+
+	if !ok {
+		w.visit(key, link)
+	}
+}
+
+// visit начинает скачивание ссылки. Она остается в очереди до конца
+// скачивания: по очереди -c продолжает прерванную загрузку.
+func (w *Wget) visit(key string, link queued) error {
+	c := w.Collector
+	if link.Asset && w.assets != nil {
+		c = w.assets
+	}
+
+	w.mu.Lock()
+	w.queue[key] = link
+	w.mu.Unlock()
+
+	err := c.Visit(link.URL)
+	if err != nil {
+		w.mu.Lock()
+		delete(w.queue, key)
+		w.mu.Unlock()
+	}
+	return err
+}
+
+func (w *Wget) HandlePageLink(attr string) func(*colly.HTMLElement) {
+	return func(e *colly.HTMLElement) {
+		// слишком глубокая ссылка не помечается: она может встретиться
+		// и ближе к начальной странице
+		depth := w.depth(e.Request) + 1
+		if w.cfg.depth > 0 && depth > w.cfg.depth {
+			return
+		}
+
+		link := e.Request.AbsoluteURL(e.Attr(attr))
+		if u, err := url.Parse(link); err != nil || !w.inScope(u) {
+			return
+		}
+		w.enqueue(queued{URL: link, Depth: depth})
+	}
+}
+
+// файлы, нужные странице, в <link rel>; остальные link - ссылки на страницы
+var requisiteRels = map[string]bool{
+	"stylesheet": true, "icon": true, "apple-touch-icon": true, "apple-touch-icon-precomposed": true,
+	"mask-icon": true, "manifest": true, "preload": true, "modulepreload": true,
+}
+
+// HandleLink с -p скачивает стили и иконки как файлы страницы.
+func (w *Wget) HandleLink(e *colly.HTMLElement) {
+	for _, rel := range strings.Fields(strings.ToLower(e.Attr("rel"))) {
+		if requisiteRels[rel] {
+			w.visitRequisite(e.Request.AbsoluteURL(e.Attr("href")))
+			return
+		}
+	}
+	w.HandlePageLink("href")(e)
+}
+
+// HandleRequisite скачивает файл из атрибута attr, даже с другого хоста и
+// за пределами глубины, но не переходит по ссылкам в нем.
+func (w *Wget) HandleRequisite(attr string) func(*colly.HTMLElement) {
+	return func(e *colly.HTMLElement) {
+		w.visitRequisite(e.Request.AbsoluteURL(e.Attr(attr)))
+	}
+}
+
+func (w *Wget) HandleSrcset(e *colly.HTMLElement) {
+	for _, link := range srcsetURLs(e.Attr("srcset")) {
+		w.visitRequisite(e.Request.AbsoluteURL(link))
+	}
+}
+
+// HandleStylesheet скачивает файлы, на которые ссылается таблица стилей:
+// @import и url().
+func (w *Wget) HandleStylesheet(r *colly.Response) {
+	file := &savedFile{path: r.Request.URL.Path, contentType: r.Headers.Get("Content-Type")}
+	if fileKind(file) == "text/css" {
+		w.visitCSSRefs(r.Request, string(r.Body))
+	}
+}
+
+// visitCSSRefs скачивает ссылки CSS, относительные странице или таблице
+// стилей req.
+func (w *Wget) visitCSSRefs(req *colly.Request, css string) {
+	for _, ref := range cssRefs(css) {
+		w.visitRequisite(req.AbsoluteURL(strings.TrimSpace(ref.URL)))
+	}
+}
+
+// visitRequisite скачивает файл страницы коллектором assets. Для него
+// действуют только явные исключения.
+func (w *Wget) visitRequisite(link string) {
+	if u, err := url.Parse(link); err != nil || w.excluded(u) {
+		return
+	}
+	w.enqueue(queued{URL: link, Asset: true})
+}
+
+// Save сохраняет ответ в cfg.dir/hostname/path. Путь без расширения
+// считается каталогом: страница сохраняется в его index.html. Файлу
+// ставится дата Last-Modified, по ней -N спрашивает, изменился ли он.
+func (w *Wget) Save(r *colly.Response) {
+	fullpath := w.localPath(r.Request.URL)
+	notModified := r.Headers.Get(notModifiedHeader) != ""
+
+	if notModified {
+		fmt.Println("not modified: ", fullpath)
+	} else {
+		w.mu.Lock()
+		w.bytes += int64(len(r.Body))
+		w.mu.Unlock()
+
+		// страница не по -A скачана только для обхода ссылок
+		if !w.accepted(r.Request.URL) {
+			return
+		}
+
+		dir := filepath.Dir(fullpath)
+		if _, err := os.Stat(dir); err != nil {
+			os.MkdirAll(dir, os.ModePerm)
+		}
+
+		fmt.Println("loading: ", fullpath)
+		if err := r.Save(fullpath); err != nil {
+			fmt.Println("wget:", err)
+			return
+		}
+		os.Remove(fullpath + partSuffix)
+		if modified, err := http.ParseTime(r.Headers.Get("Last-Modified")); err == nil {
+			os.Chtimes(fullpath, modified, modified)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := urlKey(r.Request.URL)
+	if _, ok := w.saved[key]; ok && notModified {
+		return
+	}
+	w.saved[key] = &savedFile{
+		url:          r.Request.URL,
+		path:         fullpath,
+		contentType:  r.Headers.Get("Content-Type"),
+		etag:         r.Headers.Get("ETag"),
+		lastModified: r.Headers.Get("Last-Modified"),
+	}
+}
+
+func (w *Wget) localPath(u *url.URL) string {
+	path := u.Path
+	if filepath.Ext(path) == "" {
+		path = filepath.Join(path, "index.html")
+	}
+	return filepath.Join(w.cfg.dir, u.Hostname(), path)
+}
+
+// urlKey - URL без фрагмента и с / вместо пустого пути: разные записи
+// одной ссылки ведут к одному файлу.
+func urlKey(u *url.URL) string {
+	key := *u
+	key.Fragment = ""
+	key.RawFragment = ""
+	if key.Path == "" {
+		key.Path = "/"
+		key.RawPath = ""
+	}
+	key.Host = strings.ToLower(key.Host)
+	return key.String()
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+)
+
+// APIError - ответ сервера с кодом отличным от 2xx.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server responded %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("server responded %d: %s", e.StatusCode, e.Message)
+}
+
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL string, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *Client) do(method string, path string, form url.Values, result interface{}) error {
+	var body io.Reader
+	target := c.BaseURL + path
+
+	if method == "GET" {
+		if len(form) > 0 {
+			target += "?" + form.Encode()
+		}
+	} else {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	var payload struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		json.Unmarshal(data, &payload)
+		return &APIError{StatusCode: res.StatusCode, Message: payload.Error}
+	}
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("bad response: %w", err)
+	}
+
+	if result != nil {
+		return json.Unmarshal(payload.Result, result)
+	}
+
+	return nil
+}
+
+func (c *Client) Create(user int, date time.Time, msg string) (result string, err error) {
+	form := url.Values{}
+	form.Set("user", strconv.Itoa(user))
+	form.Set("date", date.Format(time.RFC3339))
+	form.Set("msg", msg)
+
+	err = c.do("POST", "/create_event", form, &result)
+	return
+}
+
+// Update изменяет только заданные поля: нулевая дата и пустое сообщение не отправляются.
+func (c *Client) Update(user int, eid int, date time.Time, msg string) (result string, err error) {
+	form := url.Values{}
+	form.Set("user", strconv.Itoa(user))
+	form.Set("eid", strconv.Itoa(eid))
+	if !date.IsZero() {
+		form.Set("date", date.Format(time.RFC3339))
+	}
+	if msg != "" {
+		form.Set("msg", msg)
+	}
+
+	err = c.do("POST", "/update_event", form, &result)
+	return
+}
+
+func (c *Client) Delete(user int, eid int) (result string, err error) {
+	form := url.Values{}
+	form.Set("user", strconv.Itoa(user))
+	form.Set("eid", strconv.Itoa(eid))
+
+	err = c.do("POST", "/delete_event", form, &result)
+	return
+}
+
+// Events запрашивает события за период. Для calendar.All дата игнорируется.
+func (c *Client) Events(erange calendar.EventRange, user int, date time.Time) (events []calendar.Event, err error) {
+	path := map[calendar.EventRange]string{
+		calendar.All:        "/",
+		calendar.DayRange:   "/events_for_day",
+		calendar.WeekRange:  "/events_for_week",
+		calendar.MonthRange: "/events_for_month",
+	}[erange]
+
+	form := url.Values{}
+	if user > 0 {
+		form.Set("user", strconv.Itoa(user))
+	}
+	if erange != calendar.All {
+		form.Set("date", date.Format(time.RFC3339))
+	}
+
+	err = c.do("GET", path, form, &events)
+	return
+}
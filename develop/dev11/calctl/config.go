@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultURL = "http://localhost:8080"
+
+type Config struct {
+	url    string
+	token  string
+	output string
+
+	command string
+	args    []string
+}
+
+// NewConfig разбирает глобальные флаги. Приоритет: флаги, переменные окружения
+// CALCTL_URL/CALCTL_TOKEN, файл конфигурации (по умолчанию ~/.calctl).
+func NewConfig(args []string, getenv func(string) string, stderr io.Writer) (*Config, error) {
+	cfg := &Config{}
+
+	fs := flag.NewFlagSet("calctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	configPath := ""
+	if home := getenv("HOME"); home != "" {
+		configPath = filepath.Join(home, ".calctl")
+	}
+
+	fs.StringVar(&configPath, "config", configPath, "Config file with url=... and token=... lines")
+	fs.StringVar(&cfg.url, "url", "", "Calendar server base URL (default "+defaultURL+")")
+	fs.StringVar(&cfg.token, "token", "", "Bearer token sent in Authorization header")
+	fs.StringVar(&cfg.output, "o", "table", "Output format: table or json")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.output != "table" && cfg.output != "json" {
+		return nil, fmt.Errorf("unknown output format %q", cfg.output)
+	}
+
+	file, err := ReadConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range []map[string]string{
+		{"url": getenv("CALCTL_URL"), "token": getenv("CALCTL_TOKEN")},
+		file,
+		{"url": defaultURL},
+	} {
+		if cfg.url == "" {
+			cfg.url = source["url"]
+		}
+		if cfg.token == "" {
+			cfg.token = source["token"]
+		}
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return nil, fmt.Errorf("command is not specified")
+	}
+
+	cfg.command = rest[0]
+	cfg.args = rest[1:]
+
+	return cfg, nil
+}
+
+// ReadConfigFile читает строки key=value, пропуская пустые и комментарии.
+// Отсутствующий файл не является ошибкой.
+func ReadConfigFile(path string) (map[string]string, error) {
+	result := map[string]string{}
+	if path == "" {
+		return result, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key=value", path, line)
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return result, scanner.Err()
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+)
+
+/*
+=== calctl ===
+
+Консольный клиент для HTTP API календаря (dev11).
+
+Коды возврата:
+	0 - успех
+	1 - ошибка соединения или ответ, который не удалось разобрать
+	2 - неверные аргументы командной строки
+	3 - сервер вернул 400 (ошибка входных данных)
+	4 - сервер вернул 503 (ошибка бизнес-логики)
+	5 - сервер вернул 500 или другой неожиданный статус
+*/
+
+const (
+	ExitOK = iota
+	ExitError
+	ExitUsage
+	ExitBadRequest
+	ExitUnavailable
+	ExitServerError
+)
+
+const usage = `usage: calctl [-url URL] [-token TOKEN] [-o table|json] <command> [flags]
+
+commands:
+  create -user N -date DATE [-msg TEXT]           create event
+  update -user N -eid N [-date DATE] [-msg TEXT]  update event
+  delete -user N -eid N                           delete event
+  day    -date DATE [-user N]                     events for the day
+  week   -date DATE [-user N]                     events for the week
+  month  -date DATE [-user N]                     events for the month
+  export [-user N]                                all events as JSON
+
+DATE is RFC3339 (2022-04-14T15:04:05Z) or 2022-04-14.
+`
+
+// ParseDate принимает RFC3339 или короткую форму YYYY-MM-DD (полночь UTC).
+func ParseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+type dateFlag struct {
+	time.Time
+}
+
+func (d *dateFlag) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(time.RFC3339)
+}
+
+func (d *dateFlag) Set(value string) (err error) {
+	d.Time, err = ParseDate(value)
+	return
+}
+
+func ExitCode(err error) int {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return ExitError
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusBadRequest:
+		return ExitBadRequest
+	case http.StatusServiceUnavailable:
+		return ExitUnavailable
+	default:
+		return ExitServerError
+	}
+}
+
+type Program struct {
+	cfg    *Config
+	client *Client
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func Run(args []string, getenv func(string) string, stdout io.Writer, stderr io.Writer) int {
+	cfg, err := NewConfig(args, getenv, stderr)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "calctl:", err)
+		return ExitUsage
+	}
+
+	prog := &Program{
+		cfg:    cfg,
+		client: NewClient(cfg.url, cfg.token),
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	return prog.Run()
+}
+
+func (p *Program) Run() int {
+	fs := flag.NewFlagSet("calctl "+p.cfg.command, flag.ContinueOnError)
+	fs.SetOutput(p.stderr)
+
+	var user, eid int
+	var date dateFlag
+	var msg string
+
+	fs.IntVar(&user, "user", 0, "user id")
+
+	switch p.cfg.command {
+	case "create":
+		fs.Var(&date, "date", "event date")
+		fs.StringVar(&msg, "msg", "", "event message")
+	case "update":
+		fs.IntVar(&eid, "eid", 0, "event id")
+		fs.Var(&date, "date", "new event date")
+		fs.StringVar(&msg, "msg", "", "new event message")
+	case "delete":
+		fs.IntVar(&eid, "eid", 0, "event id")
+	case "day", "week", "month":
+		fs.Var(&date, "date", "any date inside the period")
+	case "export":
+	default:
+		fmt.Fprintf(p.stderr, "calctl: unknown command %q\n%s", p.cfg.command, usage)
+		return ExitUsage
+	}
+
+	if err := fs.Parse(p.cfg.args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if fs.NArg() > 0 {
+		fmt.Fprintln(p.stderr, "calctl: unexpected arguments:", fs.Args())
+		return ExitUsage
+	}
+
+	var err error
+	switch p.cfg.command {
+	case "create":
+		if user < 1 || date.IsZero() {
+			return p.usageError("create requires -user and -date")
+		}
+		err = p.printResult(p.client.Create(user, date.Time, msg))
+	case "update":
+		if user < 1 || eid < 1 {
+			return p.usageError("update requires -user and -eid")
+		}
+		if date.IsZero() && msg == "" {
+			return p.usageError("update requires -date or -msg")
+		}
+		err = p.printResult(p.client.Update(user, eid, date.Time, msg))
+	case "delete":
+		if user < 1 || eid < 1 {
+			return p.usageError("delete requires -user and -eid")
+		}
+		err = p.printResult(p.client.Delete(user, eid))
+	case "day", "week", "month":
+		if date.IsZero() {
+			return p.usageError(p.cfg.command + " requires -date")
+		}
+		erange := map[string]calendar.EventRange{
+			"day":   calendar.DayRange,
+			"week":  calendar.WeekRange,
+			"month": calendar.MonthRange,
+		}[p.cfg.command]
+		err = p.printEvents(p.client.Events(erange, user, date.Time))
+	case "export":
+		var events []calendar.Event
+		events, err = p.client.Events(calendar.All, user, time.Time{})
+		if err == nil {
+			err = p.printJSON(events)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintln(p.stderr, "calctl:", err)
+		return ExitCode(err)
+	}
+
+	return ExitOK
+}
+
+func (p *Program) usageError(msg string) int {
+	fmt.Fprintln(p.stderr, "calctl:", msg)
+	return ExitUsage
+}
+
+func (p *Program) printJSON(value interface{}) error {
+	enc := json.NewEncoder(p.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
+
+func (p *Program) printResult(result string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if p.cfg.output == "json" {
+		return p.printJSON(map[string]string{"result": result})
+	}
+
+	_, err = fmt.Fprintln(p.stdout, result)
+	return err
+}
+
+func (p *Program) printEvents(events []calendar.Event, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if p.cfg.output == "json" {
+		return p.printJSON(events)
+	}
+
+	w := tabwriter.NewWriter(p.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "EID\tDATE\tMSG")
+	for _, e := range events {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", e.Eid, e.Date.Format(time.RFC3339), e.Msg)
+	}
+	return w.Flush()
+}
+
+func main() {
+	os.Exit(Run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/routes"
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+func NewTestServer(t *testing.T) *httptest.Server {
+	srv := server.New("")
+	routes.NewRoutes(calendar.NewCalendar()).Mount(srv)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func Env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func Exec(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := Run(args, Env(env), stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	ts := NewTestServer(t)
+	env := map[string]string{"CALCTL_URL": ts.URL}
+
+	tests := []struct {
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{[]string{"create", "-user", "1", "-date", "2022-04-14T15:04:05Z", "-msg", "first"}, ExitOK, "created\n"},
+		{[]string{"create", "-user", "1", "-date", "2022-04-16", "-msg", "second"}, ExitOK, "created\n"},
+		{[]string{"create", "-user", "2", "-date", "2022-05-01", "-msg", "third"}, ExitOK, "created\n"},
+		{[]string{"create", "-user", "1"}, ExitUsage, ""},
+		{[]string{"create", "-user", "1", "-date", "1999-01-01"}, ExitBadRequest, ""},
+		{[]string{"update", "-user", "1", "-eid", "1", "-msg", "renamed"}, ExitOK, "ok\n"},
+		{[]string{"update", "-user", "1", "-eid", "42", "-msg", "nope"}, ExitUnavailable, ""},
+		{[]string{"update", "-user", "1", "-eid", "1"}, ExitUsage, ""},
+		{[]string{"delete", "-user", "2", "-eid", "3"}, ExitOK, "ok\n"},
+		{[]string{"delete", "-user", "2", "-eid", "3"}, ExitUnavailable, ""},
+		{[]string{"-o", "json", "day", "-date", "2022-04-14"}, ExitOK, `[
+  {
+    "eid": 1,
+    "date": "2022-04-14T15:04:05Z",
+    "msg": "renamed"
+  }
+]
+`},
+		{[]string{"week", "-date", "2022-04-13", "-user", "1"}, ExitOK, `EID  DATE                  MSG
+1    2022-04-14T15:04:05Z  renamed
+2    2022-04-16T00:00:00Z  second
+`},
+		{[]string{"month", "-date", "2022-05-02"}, ExitOK, "EID  DATE  MSG\n"},
+		{[]string{"-o", "json", "delete", "-user", "1", "-eid", "2"}, ExitOK, "{\n  \"result\": \"ok\"\n}\n"},
+		{[]string{"unknown"}, ExitUsage, ""},
+		{[]string{}, ExitUsage, ""},
+		{[]string{"-o", "xml", "export"}, ExitUsage, ""},
+	}
+
+	for idx, test := range tests {
+		code, stdout, stderr := Exec(t, env, test.args...)
+		if code != test.exitCode {
+			t.Errorf("%d %v: expected exit(%d), got %d: %s", idx, test.args, test.exitCode, code, stderr)
+			continue
+		}
+		if stdout != test.stdout {
+			t.Errorf("%d %v: stdout mismatch\nexpected: %q\ngot: %q", idx, test.args, test.stdout, stdout)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	ts := NewTestServer(t)
+	env := map[string]string{"CALCTL_URL": ts.URL}
+
+	Exec(t, env, "create", "-user", "1", "-date", "2022-04-14", "-msg", "a")
+	Exec(t, env, "create", "-user", "2", "-date", "2022-04-15", "-msg", "b")
+
+	code, stdout, stderr := Exec(t, env, "export")
+	if code != ExitOK {
+		t.Fatalf("expected exit(0), got %d: %s", code, stderr)
+	}
+
+	var events []calendar.Event
+	if err := json.Unmarshal([]byte(stdout), &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Msg != "a" || events[1].Msg != "b" {
+		t.Errorf("unexpected export: %v", events)
+	}
+
+	code, stdout, _ = Exec(t, env, "export", "-user", "2")
+	if code != ExitOK || strings.Count(stdout, `"eid"`) != 1 {
+		t.Errorf("unexpected user export: %d %s", code, stdout)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	code, _, _ := Exec(t, map[string]string{"CALCTL_URL": ts.URL, "CALCTL_TOKEN": "secret"}, "export")
+	if code != ExitServerError {
+		t.Errorf("expected exit(%d) on 500, got %d", ExitServerError, code)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("token is not sent, got %q", auth)
+	}
+
+	url := ts.URL
+	ts.Close()
+	code, _, _ = Exec(t, map[string]string{"CALCTL_URL": url}, "export")
+	if code != ExitError {
+		t.Errorf("expected exit(%d) on connection error, got %d", ExitError, code)
+	}
+}
+
+func TestConfigPriority(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, ".calctl"), []byte("# calctl\nurl = http://file:1\ntoken=file-token\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := NewConfig([]string{"export"}, Env(map[string]string{"HOME": dir}), &bytes.Buffer{})
+	if err != nil || cfg.url != "http://file:1" || cfg.token != "file-token" {
+		t.Errorf("file config is not applied: %+v %v", cfg, err)
+	}
+
+	cfg, err = NewConfig([]string{"export"}, Env(map[string]string{"HOME": dir, "CALCTL_URL": "http://env:2"}), &bytes.Buffer{})
+	if err != nil || cfg.url != "http://env:2" || cfg.token != "file-token" {
+		t.Errorf("env config is not applied: %+v %v", cfg, err)
+	}
+
+	cfg, err = NewConfig([]string{"-url", "http://flag:3", "export"}, Env(map[string]string{"HOME": dir, "CALCTL_URL": "http://env:2"}), &bytes.Buffer{})
+	if err != nil || cfg.url != "http://flag:3" {
+		t.Errorf("flag config is not applied: %+v %v", cfg, err)
+	}
+
+	cfg, err = NewConfig([]string{"export"}, Env(map[string]string{}), &bytes.Buffer{})
+	if err != nil || cfg.url != defaultURL || cfg.token != "" {
+		t.Errorf("defaults are not applied: %+v %v", cfg, err)
+	}
+}
@@ -3,6 +3,7 @@ package calendar
 import (
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 )
 
@@ -37,45 +38,79 @@ func SortEvents(arr []Event) {
 }
 
 type Calendar struct {
+	mu      sync.RWMutex
 	storage map[int][]Event
 	lastId  int
+
+	offset   int
+	log      []Change
+	logLimit int
+	changed  chan struct{}
 }
 
 func NewCalendar() *Calendar {
 	return &Calendar{
-		storage: map[int][]Event{},
-		lastId:  0,
+		storage:  map[int][]Event{},
+		lastId:   0,
+		logLimit: DefaultLogLimit,
+		changed:  make(chan struct{}),
 	}
 }
 
 func (c *Calendar) Create(user int, event Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.lastId++
 	event.Eid = c.lastId
 	c.storage[user] = append(c.storage[user], event)
 	SortEvents(c.storage[user])
+	c.record(OpCreate, user, event)
 	return nil
 }
 
 func (c *Calendar) Update(user int, eid int, event Event) error {
-	for i, e := range c.storage[user] {
-		if e.Eid == eid {
-			c.storage[user][i].Update(event)
-			SortEvents(c.storage[user])
-			return nil
-		}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := c.find(user, eid)
+	if idx < 0 {
+		return fmt.Errorf("not found")
 	}
 
-	return fmt.Errorf("not found")
+	c.storage[user][idx].Update(event)
+	updated := c.storage[user][idx]
+	SortEvents(c.storage[user])
+	c.record(OpUpdate, user, updated)
+	return nil
 }
 
 func (c *Calendar) Delete(user int, eid int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := c.find(user, eid)
+	if idx < 0 {
+		return fmt.Errorf("not found")
+	}
+
+	deleted := c.storage[user][idx]
+	c.remove(user, idx)
+	c.record(OpDelete, user, deleted)
+	return nil
+}
+
+func (c *Calendar) find(user int, eid int) int {
 	for idx, e := range c.storage[user] {
 		if e.Eid == eid {
-			c.storage[user] = append(c.storage[user][:idx], c.storage[user][idx+1:]...)
-			return nil
+			return idx
 		}
 	}
-	return fmt.Errorf("not found")
+	return -1
+}
+
+func (c *Calendar) remove(user int, idx int) {
+	c.storage[user] = append(c.storage[user][:idx], c.storage[user][idx+1:]...)
 }
 
 type EventRange int64
@@ -119,6 +154,9 @@ func DayFilter(pivot time.Time) func(time.Time) bool {
 }
 
 func (c *Calendar) Query(q EventQuery) (result []Event) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	result = []Event{}
 
 	var userFilter func(user int) bool
@@ -0,0 +1,156 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultLogLimit - сколько последних изменений календарь хранит для догоняющих реплик.
+const DefaultLogLimit = 4096
+
+var ErrLogTruncated = errors.New("changelog truncated")
+
+type Op string
+
+const (
+	OpCreate Op = "create"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+// Change - запись журнала изменений. Для update хранится итоговое событие
+// целиком, поэтому применение записи не зависит от состояния реплики.
+type Change struct {
+	Offset int   `json:"offset"`
+	Op     Op    `json:"op"`
+	User   int   `json:"user"`
+	Event  Event `json:"event"`
+}
+
+// Snapshot - состояние календаря вместе с номером последнего примененного изменения.
+type Snapshot struct {
+	Offset int  `json:"offset"`
+	Dump   Dump `json:"dump"`
+}
+
+func (c *Calendar) SetLogLimit(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.logLimit = limit
+	c.trimLog()
+}
+
+func (c *Calendar) Offset() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.offset
+}
+
+// Changes возвращает изменения с номером больше from и канал, который закроется
+// при следующем изменении. Если часть журнала уже удалена, возвращает ErrLogTruncated.
+func (c *Calendar) Changes(from int) ([]Change, <-chan struct{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if from > c.offset {
+		return nil, nil, fmt.Errorf("offset %d is ahead of %d", from, c.offset)
+	}
+
+	start := c.offset - len(c.log)
+	if from < start {
+		return nil, nil, ErrLogTruncated
+	}
+
+	changes := append([]Change{}, c.log[from-start:]...)
+	return changes, c.changed, nil
+}
+
+func (c *Calendar) Snapshot() Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return Snapshot{Offset: c.offset, Dump: c.dump()}
+}
+
+// Load заменяет состояние снимком. Журнал начинается заново с s.Offset.
+func (c *Calendar) Load(s Snapshot) error {
+	storage, err := s.Dump.storage()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.storage = storage
+	c.lastId = s.Dump.LastID
+	c.offset = s.Offset
+	c.log = nil
+	c.notify()
+	return nil
+}
+
+// Apply применяет изменение, полученное от другого календаря. Изменения должны
+// идти строго по порядку.
+func (c *Calendar) Apply(ch Change) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ch.Offset != c.offset+1 {
+		return fmt.Errorf("change %d doesn't follow offset %d", ch.Offset, c.offset)
+	}
+
+	switch ch.Op {
+	case OpCreate:
+		c.storage[ch.User] = append(c.storage[ch.User], ch.Event)
+		SortEvents(c.storage[ch.User])
+		if ch.Event.Eid > c.lastId {
+			c.lastId = ch.Event.Eid
+		}
+	case OpUpdate, OpDelete:
+		idx := c.find(ch.User, ch.Event.Eid)
+		if idx < 0 {
+			return fmt.Errorf("change %d: event %d of user %d not found", ch.Offset, ch.Event.Eid, ch.User)
+		}
+
+		if ch.Op == OpUpdate {
+			c.storage[ch.User][idx] = ch.Event
+			SortEvents(c.storage[ch.User])
+		} else {
+			c.remove(ch.User, idx)
+		}
+	default:
+		return fmt.Errorf("change %d: unknown op %q", ch.Offset, ch.Op)
+	}
+
+	c.record(ch.Op, ch.User, ch.Event)
+	return nil
+}
+
+func (c *Calendar) record(op Op, user int, event Event) {
+	c.offset++
+	c.log = append(c.log, Change{Offset: c.offset, Op: op, User: user, Event: event})
+	c.trimLog()
+	c.notify()
+}
+
+// resetLog вызывается при замене всего состояния: номер увеличивается, а журнал
+// очищается, так что реплики обязаны заново загрузить снимок.
+func (c *Calendar) resetLog() {
+	c.offset++
+	c.log = nil
+	c.notify()
+}
+
+func (c *Calendar) trimLog() {
+	if len(c.log) > c.logLimit {
+		c.log = append([]Change{}, c.log[len(c.log)-c.logLimit:]...)
+	}
+}
+
+func (c *Calendar) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
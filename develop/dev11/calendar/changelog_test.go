@@ -0,0 +1,106 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChangelog(t *testing.T) {
+	t1 := time.UnixMilli(1649201584000)
+	t2 := time.UnixMilli(1649892784000)
+
+	leader := NewCalendar()
+	follower := NewCalendar()
+
+	changes, changed, err := leader.Changes(0)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("empty log: %v %v", changes, err)
+	}
+
+	leader.Create(1, Event{Date: t1, Msg: "hello"})
+	leader.Create(2, Event{Date: t2, Msg: "there"})
+	leader.Update(1, 1, Event{Msg: "updated"})
+	leader.Delete(2, 2)
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("changed channel is not closed after create")
+	}
+
+	changes, _, err = leader.Changes(0)
+	if err != nil || len(changes) != 4 {
+		t.Fatalf("expected 4 changes, got %v %v", changes, err)
+	}
+
+	if changes[2].Op != OpUpdate || changes[2].Event.Msg != "updated" || !changes[2].Event.Date.Equal(t1) {
+		t.Errorf("update must keep full event: %+v", changes[2])
+	}
+
+	for _, ch := range changes {
+		if err := follower.Apply(ch); err != nil {
+			t.Fatalf("apply %+v: %v", ch, err)
+		}
+	}
+
+	if err := TQuery(follower, EventQuery{}, []Event{{1, t1, "updated"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if follower.Offset() != 4 {
+		t.Errorf("expected follower offset 4, got %d", follower.Offset())
+	}
+
+	// повтор и пропуск изменений отклоняются
+	if err := follower.Apply(changes[3]); err == nil {
+		t.Error("expected error on duplicate change")
+	}
+	if err := follower.Apply(Change{Offset: 6, Op: OpCreate, User: 1}); err == nil {
+		t.Error("expected error on gap")
+	}
+
+	// eid на реплике продолжает нумерацию лидера
+	follower.Create(1, Event{Date: t2, Msg: "local"})
+	user := 1
+	if err := TQuery(follower, EventQuery{User: &user}, []Event{{1, t1, "updated"}, {3, t2, "local"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangelogTruncation(t *testing.T) {
+	c := NewCalendar()
+	c.SetLogLimit(2)
+
+	for i := 0; i < 5; i++ {
+		c.Create(1, Event{Msg: "event"})
+	}
+
+	if _, _, err := c.Changes(2); !errors.Is(err, ErrLogTruncated) {
+		t.Errorf("expected truncated log, got %v", err)
+	}
+
+	changes, _, err := c.Changes(3)
+	if err != nil || len(changes) != 2 || changes[0].Offset != 4 {
+		t.Errorf("expected changes 4..5, got %v %v", changes, err)
+	}
+
+	if _, _, err := c.Changes(6); err == nil {
+		t.Error("expected error for offset ahead of log")
+	}
+
+	snapshot := c.Snapshot()
+	replica := NewCalendar()
+	if err := replica.Load(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if replica.Offset() != 5 || len(replica.Dump().Users[0].Events) != 5 {
+		t.Errorf("snapshot is not loaded: %+v", replica.Dump())
+	}
+
+	// Restore сбрасывает журнал, реплики должны загрузить снимок заново
+	c.Restore(Dump{Version: DumpVersion})
+	if _, _, err := c.Changes(5); !errors.Is(err, ErrLogTruncated) {
+		t.Errorf("expected truncated log after restore, got %v", err)
+	}
+}
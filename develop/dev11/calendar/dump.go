@@ -0,0 +1,149 @@
+package calendar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DumpVersion - версия формата дампа. Restore принимает только её.
+const DumpVersion = 1
+
+type UserEvents struct {
+	User   int     `json:"user"`
+	Events []Event `json:"events"`
+}
+
+type Dump struct {
+	Version int          `json:"version"`
+	LastID  int          `json:"last_id"`
+	Users   []UserEvents `json:"users"`
+}
+
+func (c *Calendar) sortedUsers() []int {
+	users := []int{}
+	for user := range c.storage {
+		users = append(users, user)
+	}
+	sort.Ints(users)
+	return users
+}
+
+// Dump возвращает копию всего состояния календаря.
+func (c *Calendar) Dump() Dump {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.dump()
+}
+
+func (c *Calendar) dump() Dump {
+	dump := Dump{Version: DumpVersion, LastID: c.lastId, Users: []UserEvents{}}
+	for _, user := range c.sortedUsers() {
+		events := append([]Event{}, c.storage[user]...)
+		dump.Users = append(dump.Users, UserEvents{User: user, Events: events})
+	}
+	return dump
+}
+
+// WriteDump пишет дамп в w по одному пользователю, не собирая весь JSON в памяти.
+// Как и Snapshot, копирует состояние под блокировкой, а пишет уже без нее:
+// медленный клиент не задерживает запись в календарь.
+func (c *Calendar) WriteDump(w io.Writer) error {
+	dump := c.Dump()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `{"version":%d,"last_id":%d,"users":[`, dump.Version, dump.LastID)
+
+	enc := json.NewEncoder(bw)
+	for idx, ue := range dump.Users {
+		if idx > 0 {
+			bw.WriteByte(',')
+		}
+		if err := enc.Encode(ue); err != nil {
+			return err
+		}
+	}
+
+	bw.WriteString("]}\n")
+	return bw.Flush()
+}
+
+func (d *Dump) Validate() error {
+	if d.Version != DumpVersion {
+		return fmt.Errorf("unsupported dump version %d, expected %d", d.Version, DumpVersion)
+	}
+
+	if d.LastID < 0 {
+		return fmt.Errorf("negative last_id %d", d.LastID)
+	}
+
+	users := map[int]struct{}{}
+	eids := map[int]int{}
+	for _, ue := range d.Users {
+		if ue.User < 1 {
+			return fmt.Errorf("bad user id %d", ue.User)
+		}
+		if _, ok := users[ue.User]; ok {
+			return fmt.Errorf("duplicate user %d", ue.User)
+		}
+		users[ue.User] = struct{}{}
+
+		for _, e := range ue.Events {
+			if e.Eid < 1 || e.Eid > d.LastID {
+				return fmt.Errorf("user %d: eid %d out of range 1..%d", ue.User, e.Eid, d.LastID)
+			}
+			if owner, ok := eids[e.Eid]; ok {
+				return fmt.Errorf("user %d: eid %d already used by user %d", ue.User, e.Eid, owner)
+			}
+			eids[e.Eid] = ue.User
+		}
+	}
+
+	return nil
+}
+
+func (d *Dump) storage() (map[int][]Event, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	storage := map[int][]Event{}
+	for _, ue := range d.Users {
+		events := append([]Event{}, ue.Events...)
+		SortEvents(events)
+		storage[ue.User] = events
+	}
+	return storage, nil
+}
+
+// Restore заменяет состояние календаря дампом. Eid событий и lastId сохраняются,
+// поэтому внешние ссылки на события остаются валидными.
+func (c *Calendar) Restore(d Dump) error {
+	storage, err := d.storage()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.storage = storage
+	c.lastId = d.LastID
+	c.resetLog()
+	return nil
+}
+
+func (c *Calendar) ReadDump(r io.Reader) error {
+	var d Dump
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&d); err != nil {
+		return fmt.Errorf("bad dump: %w", err)
+	}
+
+	return c.Restore(d)
+}
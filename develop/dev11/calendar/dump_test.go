@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDumpRestore(t *testing.T) {
+	t1 := time.UnixMilli(1649201584000).UTC()
+	t2 := time.UnixMilli(1649892784000).UTC()
+
+	c := NewCalendar()
+	c.Create(2, Event{Date: t2, Msg: "second"})
+	c.Create(1, Event{Date: t1, Msg: "first"})
+	c.Create(2, Event{Date: t2, Msg: "third"})
+	c.Delete(2, 1)
+
+	buf := &bytes.Buffer{}
+	if err := c.WriteDump(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"version":1,"last_id":3,"users":[` +
+		`{"user":1,"events":[{"eid":2,"date":"2022-04-05T23:33:04Z","msg":"first"}]}` + "\n," +
+		`{"user":2,"events":[{"eid":3,"date":"2022-04-13T23:33:04Z","msg":"third"}]}` + "\n]}\n"
+	if buf.String() != expected {
+		t.Fatalf("dump mismatch\nexpected: %q\ngot: %q", expected, buf.String())
+	}
+
+	restored := NewCalendar()
+	if err := restored.ReadDump(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := TQuery(restored, EventQuery{}, []Event{{2, t1, "first"}, {3, t2, "third"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// счетчик восстановлен, новые eid не пересекаются со старыми
+	restored.Create(1, Event{Date: t2, Msg: "new"})
+	user := 1
+	if err := TQuery(restored, EventQuery{User: &user}, []Event{{2, t1, "first"}, {4, t2, "new"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	dump := restored.Dump()
+	if dump.Version != DumpVersion || dump.LastID != 4 || len(dump.Users) != 2 {
+		t.Fatalf("unexpected dump: %+v", dump)
+	}
+}
+
+func TestRestoreValidation(t *testing.T) {
+	tests := []string{
+		`{"version":2,"last_id":0,"users":[]}`,
+		`{"version":1,"last_id":-1,"users":[]}`,
+		`{"version":1,"last_id":1,"users":[{"user":0,"events":[]}]}`,
+		`{"version":1,"last_id":1,"users":[{"user":1,"events":[]},{"user":1,"events":[]}]}`,
+		`{"version":1,"last_id":1,"users":[{"user":1,"events":[{"eid":2,"date":"2022-04-05T23:33:04Z","msg":""}]}]}`,
+		`{"version":1,"last_id":2,"users":[{"user":1,"events":[{"eid":1,"date":"2022-04-05T23:33:04Z","msg":""}]},{"user":2,"events":[{"eid":1,"date":"2022-04-05T23:33:04Z","msg":""}]}]}`,
+		`{"version":1,"last_id":0,"users":[],"extra":1}`,
+		`{"version":1`,
+	}
+
+	for idx, test := range tests {
+		c := NewCalendar()
+		c.Create(1, Event{Msg: "keep"})
+
+		if err := c.ReadDump(strings.NewReader(test)); err == nil {
+			t.Errorf("%d: expected error for %s", idx, test)
+		}
+
+		// при ошибке состояние не меняется
+		if err := TQuery(c, EventQuery{}, []Event{{Eid: 1, Msg: "keep"}}); err != nil {
+			t.Errorf("%d: state changed: %v", idx, err)
+		}
+	}
+}
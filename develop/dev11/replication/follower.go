@@ -0,0 +1,164 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/routes"
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+// errResync - состояние реплики нельзя продолжить журналом, нужен снимок.
+var errResync = errors.New("resync required")
+
+// Follower поддерживает локальную копию календаря лидера и отдает по ней
+// только запросы чтения.
+type Follower struct {
+	cal    *calendar.Calendar
+	leader string
+	client *http.Client
+	epoch  string // эпоха лидера, из которой загружен снимок
+
+	Retry time.Duration
+	Token string // токен репликации лидера, отправляется в Authorization: Bearer
+}
+
+func NewFollower(cal *calendar.Calendar, leaderURL string) *Follower {
+	return &Follower{
+		cal:    cal,
+		leader: strings.TrimRight(leaderURL, "/"),
+		client: &http.Client{},
+		Retry:  time.Second,
+	}
+}
+
+// Run следит за журналом лидера до отмены ctx. Ошибки соединения не
+// прерывают работу: реплика переподключается через Retry и продолжает с
+// последнего примененного номера.
+func (f *Follower) Run(ctx context.Context) error {
+	for {
+		err := f.follow(ctx)
+		if errors.Is(err, errResync) {
+			err = f.LoadSnapshot(ctx)
+			if err == nil {
+				continue
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Printf("replication: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(f.Retry):
+		}
+	}
+}
+
+func (f *Follower) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.leader+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.Token)
+	}
+	return f.client.Do(req)
+}
+
+func (f *Follower) LoadSnapshot(ctx context.Context) error {
+	res, err := f.get(ctx, "/replication/snapshot")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("snapshot: leader responded %d", res.StatusCode)
+	}
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(res.Body).Decode(&snapshot); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+
+	if err := f.cal.Load(snapshot.Snapshot); err != nil {
+		return err
+	}
+	f.epoch = snapshot.Epoch
+	return nil
+}
+
+func (f *Follower) follow(ctx context.Context) error {
+	// номера без эпохи не с чем сверить, начинаем со снимка
+	if f.epoch == "" {
+		return errResync
+	}
+
+	res, err := f.get(ctx, "/replication/log?from="+strconv.Itoa(f.cal.Offset()))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusGone {
+		return errResync
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("log: leader responded %d", res.StatusCode)
+	}
+
+	// лидер перезапущен и считает изменения заново
+	if res.Header.Get(EpochHeader) != f.epoch {
+		return errResync
+	}
+
+	dec := json.NewDecoder(res.Body)
+	for {
+		var ch calendar.Change
+		if err := dec.Decode(&ch); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("log: stream closed by leader")
+			}
+			return fmt.Errorf("log: %w", err)
+		}
+
+		if err := f.cal.Apply(ch); err != nil {
+			return fmt.Errorf("%w: %v", errResync, err)
+		}
+	}
+}
+
+func (f *Follower) ReadOnly(ctx server.Context) {
+	ctx.SendJSON(http.StatusServiceUnavailable, server.H{
+		"error": fmt.Sprint("read-only replica, send writes to ", f.leader),
+	})
+}
+
+// Mount регистрирует методы чтения и отклоняет методы записи.
+func (f *Follower) Mount(srv *server.Server, mw ...server.Middleware) {
+	routes.NewRoutes(f.cal).MountQueries(srv, mw...)
+
+	for _, path := range []string{"/create_event", "/update_event", "/delete_event"} {
+		srv.Post(path, f.ReadOnly, mw...).Describe(server.Doc{
+			Summary: "Rejected on read-only replica",
+			Responses: map[int]server.Response{
+				http.StatusServiceUnavailable: {Description: "read-only replica", Schema: &routes.ErrorSchema},
+			},
+		})
+	}
+}
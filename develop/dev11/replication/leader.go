@@ -0,0 +1,161 @@
+package replication
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+/*
+Репликация leader/follower.
+
+Лидер принимает запись через Calendar и отдает:
+	GET /replication/snapshot       - снимок состояния с номером последнего изменения
+	GET /replication/log?from=N     - поток изменений с номером > N (NDJSON), соединение
+	                                  остается открытым и новые изменения дописываются в него
+
+Номера изменений живут, пока работает лидер: после перезапуска они снова
+начинаются с нуля. Поэтому у каждого запуска лидера своя эпоха, она есть в
+снимке и в заголовке Replication-Epoch журнала. Журнал другой эпохи реплика
+не применяет и заново загружает снимок.
+
+Если изменения после N уже удалены из журнала (или N больше текущего номера),
+лидер отвечает 410 и реплика заново загружает снимок.
+
+Снимок содержит все события, поэтому методы монтируются только с проверкой
+токена: Mount(srv, logger, server.BearerAuthMW(token)).
+*/
+
+// EpochHeader - заголовок ответа журнала с эпохой лидера.
+const EpochHeader = "Replication-Epoch"
+
+// Snapshot - снимок календаря с эпохой лидера, к которой относится его номер.
+type Snapshot struct {
+	Epoch string `json:"epoch"`
+	calendar.Snapshot
+}
+
+type Leader struct {
+	cal   *calendar.Calendar
+	epoch string
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewLeader(cal *calendar.Calendar) *Leader {
+	return &Leader{cal: cal, epoch: newEpoch(), done: make(chan struct{})}
+}
+
+func newEpoch() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Close завершает открытые потоки журнала.
+func (l *Leader) Close() {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+}
+
+func (l *Leader) Snapshot(ctx server.Context) {
+	ctx.Res.Header().Set("Content-Type", "application/json")
+	ctx.Res.WriteHeader(http.StatusOK)
+
+	snapshot := Snapshot{Epoch: l.epoch, Snapshot: l.cal.Snapshot()}
+	if err := json.NewEncoder(ctx.Res).Encode(snapshot); err != nil {
+		log.Printf("replication snapshot: %v", err)
+	}
+}
+
+func (l *Leader) Log(ctx server.Context) {
+	from, err := strconv.Atoi(ctx.Req.Form.Get("from"))
+	if err != nil || from < 0 {
+		ctx.SendJSON(http.StatusBadRequest, server.H{
+			"error": "from field: non-negative integer expected",
+		})
+		return
+	}
+
+	changes, changed, err := l.cal.Changes(from)
+	if err != nil {
+		ctx.SendJSON(http.StatusGone, server.H{
+			"error": fmt.Sprint("log:", err),
+		})
+		return
+	}
+
+	flusher, _ := ctx.Res.(http.Flusher)
+	ctx.Res.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.Res.Header().Set(EpochHeader, l.epoch)
+	ctx.Res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(ctx.Res)
+
+	for {
+		for _, ch := range changes {
+			if err := enc.Encode(ch); err != nil {
+				return
+			}
+			from = ch.Offset
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Req.Context().Done():
+			return
+		case <-l.done:
+			return
+		}
+
+		// журнал мог быть сброшен через Restore, тогда поток закрывается
+		// и реплика получит 410 при переподключении
+		changes, changed, err = l.cal.Changes(from)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (l *Leader) Mount(srv *server.Server, mw ...server.Middleware) {
+	srv.Get("/replication/snapshot", l.Snapshot, mw...).Describe(server.Doc{
+		Summary: "Calendar snapshot for replica bootstrap",
+		Responses: map[int]server.Response{
+			http.StatusOK:           {Description: "snapshot with leader epoch and offset", Schema: &server.Schema{Type: "object"}},
+			http.StatusUnauthorized: {Description: "missing or wrong replication token"},
+		},
+	})
+
+	srv.Get("/replication/log", l.Log, mw...).Describe(server.Doc{
+		Summary:     "Stream of calendar changes",
+		Description: "Newline delimited JSON changes with offset > from. The stream stays open. Offsets are valid only within the leader epoch sent in the Replication-Epoch header.",
+		Params: []server.Param{{
+			Name:        "from",
+			Description: "last applied offset",
+			Required:    true,
+			Schema:      server.Schema{Type: "integer"},
+		}},
+		Responses: map[int]server.Response{
+			http.StatusOK:           {Description: "change stream"},
+			http.StatusBadRequest:   {Description: "invalid from"},
+			http.StatusUnauthorized: {Description: "missing or wrong replication token"},
+			http.StatusGone:         {Description: "changes are no longer available, load snapshot"},
+		},
+	})
+}
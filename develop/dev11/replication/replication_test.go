@@ -0,0 +1,251 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/routes"
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+const token = "secret"
+
+type Node struct {
+	cal      *calendar.Calendar
+	ts       *httptest.Server
+	follower *Follower
+}
+
+func StartLeader(t *testing.T) (*Node, *Leader) {
+	cal := calendar.NewCalendar()
+	srv := server.New("")
+	routes.NewRoutes(cal).Mount(srv)
+	leader := NewLeader(cal)
+	leader.Mount(srv, server.BearerAuthMW(token))
+
+	ts := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		leader.Close()
+		ts.Close()
+	})
+	return &Node{cal: cal, ts: ts}, leader
+}
+
+func StartFollower(t *testing.T, leaderURL string) (*Node, context.CancelFunc) {
+	cal := calendar.NewCalendar()
+	srv := server.New("")
+	follower := NewFollower(cal, leaderURL)
+	follower.Retry = 10 * time.Millisecond
+	follower.Token = token
+	follower.Mount(srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		follower.Run(ctx)
+		close(done)
+	}()
+
+	ts := httptest.NewServer(srv)
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(func() {
+		stop()
+		ts.Close()
+	})
+	return &Node{cal: cal, ts: ts, follower: follower}, stop
+}
+
+func (n *Node) Post(t *testing.T, path string, form string) int {
+	t.Helper()
+	res, err := http.Post(n.ts.URL+path, "application/x-www-form-urlencoded", strings.NewReader(form))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func (n *Node) Events(t *testing.T, path string, query url.Values) []calendar.Event {
+	t.Helper()
+	res, err := http.Get(n.ts.URL + path + "?" + query.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		Result []calendar.Event `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Result
+}
+
+func WaitOffset(t *testing.T, node *Node, offset int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for node.cal.Offset() != offset {
+		if time.Now().After(deadline) {
+			t.Fatalf("follower stuck at offset %d, expected %d", node.cal.Offset(), offset)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func SameEvents(t *testing.T, leader *Node, follower *Node, path string, query url.Values) {
+	t.Helper()
+	expected, _ := json.Marshal(leader.Events(t, path, query))
+	got, _ := json.Marshal(follower.Events(t, path, query))
+	if string(expected) != string(got) {
+		t.Errorf("%s?%s mismatch\nleader:   %s\nfollower: %s", path, query.Encode(), expected, got)
+	}
+}
+
+func TestReplication(t *testing.T) {
+	leader, _ := StartLeader(t)
+
+	leader.Post(t, "/create_event", "user=1&date=2022-04-06T15:04:05Z&msg=first")
+
+	f1, _ := StartFollower(t, leader.ts.URL)
+	f2, _ := StartFollower(t, leader.ts.URL)
+
+	leader.Post(t, "/create_event", "user=2&date=2022-04-14T15:04:05Z&msg=second")
+	leader.Post(t, "/create_event", "user=1&date=2022-04-16T15:04:05Z&msg=third")
+	leader.Post(t, "/update_event", "user=2&eid=2&msg=renamed")
+	leader.Post(t, "/delete_event", "user=1&eid=1")
+
+	for _, f := range []*Node{f1, f2} {
+		WaitOffset(t, f, leader.cal.Offset())
+
+		SameEvents(t, leader, f, "/", url.Values{})
+		SameEvents(t, leader, f, "/events_for_week", url.Values{"date": {"2022-04-13T10:00:00Z"}})
+		SameEvents(t, leader, f, "/events_for_month", url.Values{"date": {"2022-04-01T10:00:00Z"}, "user": {"2"}})
+
+		if code := f.Post(t, "/create_event", "user=1&date=2022-04-16T15:04:05Z&msg=nope"); code != http.StatusServiceUnavailable {
+			t.Errorf("write on follower: expected 503, got %d", code)
+		}
+	}
+}
+
+func TestFollowerCatchUp(t *testing.T) {
+	leader, _ := StartLeader(t)
+	leader.cal.SetLogLimit(2)
+
+	// журнал уже обрезан, реплика начинает со снимка
+	for i := 0; i < 5; i++ {
+		leader.Post(t, "/create_event", "user=1&date=2022-04-06T15:04:05Z&msg=before")
+	}
+
+	f, stop := StartFollower(t, leader.ts.URL)
+	WaitOffset(t, f, 5)
+	SameEvents(t, leader, f, "/", url.Values{})
+
+	// реплика остановлена, лидер продолжает принимать запись
+	stop()
+	leader.Post(t, "/create_event", "user=2&date=2022-04-07T15:04:05Z&msg=offline")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.follower.Run(ctx)
+
+	// одного пропущенного изменения хватает журнала, снимок не нужен
+	WaitOffset(t, f, 6)
+	SameEvents(t, leader, f, "/", url.Values{})
+
+	// Restore на лидере сбрасывает журнал, реплика перезагружает снимок
+	err := leader.cal.Restore(calendar.Dump{
+		Version: calendar.DumpVersion,
+		LastID:  10,
+		Users: []calendar.UserEvents{{User: 3, Events: []calendar.Event{
+			{Eid: 10, Date: time.Date(2022, 4, 8, 0, 0, 0, 0, time.UTC), Msg: "restored"},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leader.Post(t, "/create_event", "user=3&date=2022-04-09T15:04:05Z&msg=after")
+
+	WaitOffset(t, f, leader.cal.Offset())
+	SameEvents(t, leader, f, "/", url.Values{})
+}
+
+func TestReplicationAuth(t *testing.T) {
+	leader, _ := StartLeader(t)
+	leader.Post(t, "/create_event", "user=1&date=2022-04-06T15:04:05Z&msg=secret")
+
+	for _, path := range []string{"/replication/snapshot", "/replication/log?from=0"} {
+		for _, auth := range []string{"", "Bearer wrong"} {
+			req, err := http.NewRequest("GET", leader.ts.URL+path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusUnauthorized {
+				t.Errorf("%s with %q: expected 401, got %d", path, auth, res.StatusCode)
+			}
+		}
+	}
+
+	// реплика без токена не получает ни снимка, ни журнала
+	f, _ := StartFollower(t, leader.ts.URL)
+	follower := NewFollower(calendar.NewCalendar(), leader.ts.URL)
+	if err := follower.LoadSnapshot(context.Background()); err == nil {
+		t.Errorf("expected snapshot error without token")
+	}
+	WaitOffset(t, f, leader.cal.Offset())
+}
+
+func TestLeaderRestart(t *testing.T) {
+	handler := atomic.Value{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.Load().(http.Handler).ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	start := func(msgs ...string) (*Node, *Leader) {
+		cal := calendar.NewCalendar()
+		srv := server.New("")
+		routes.NewRoutes(cal).Mount(srv)
+		leader := NewLeader(cal)
+		leader.Mount(srv, server.BearerAuthMW(token))
+		t.Cleanup(leader.Close)
+
+		handler.Store(http.Handler(srv))
+		node := &Node{cal: cal, ts: ts}
+		for _, msg := range msgs {
+			node.Post(t, "/create_event", "user=1&date=2022-04-06T15:04:05Z&msg="+msg)
+		}
+		return node, leader
+	}
+
+	_, first := start("a1", "a2")
+	f, _ := StartFollower(t, ts.URL)
+	WaitOffset(t, f, 2)
+
+	// перезапущенный лидер с тем же номером изменений, но другим состоянием
+	second, _ := start("b1", "b2")
+	first.Close()
+	second.Post(t, "/create_event", "user=1&date=2022-04-07T15:04:05Z&msg=b3")
+
+	WaitOffset(t, f, 3)
+	SameEvents(t, second, f, "/", url.Values{})
+}
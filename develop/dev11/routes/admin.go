@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+var DumpSchema = server.Schema{
+	Type: "object",
+	Properties: map[string]*server.Schema{
+		"version": {Type: "integer", Description: "dump format version"},
+		"last_id": {Type: "integer", Description: "last issued eid"},
+		"users": {
+			Type: "array",
+			Items: &server.Schema{
+				Type: "object",
+				Properties: map[string]*server.Schema{
+					"user":   {Type: "integer", Minimum: &minID},
+					"events": {Type: "array", Items: &EventSchema},
+				},
+				Required: []string{"user", "events"},
+			},
+		},
+	},
+	Required: []string{"version", "last_id", "users"},
+}
+
+func (r *Routes) Dump(ctx server.Context) {
+	ctx.Res.Header().Set("Content-Type", "application/json")
+	ctx.Res.WriteHeader(http.StatusOK)
+
+	// заголовок уже отправлен, поэтому ошибку можно только залогировать
+	if err := r.cal.WriteDump(ctx.Res); err != nil {
+		log.Printf("dump: %v", err)
+	}
+}
+
+func (r *Routes) Restore(ctx server.Context) {
+	if err := r.cal.ReadDump(ctx.Req.Body); err != nil {
+		ctx.SendJSON(http.StatusBadRequest, server.H{
+			"error": fmt.Sprint("restore:", err),
+		})
+		return
+	}
+
+	ctx.SendJSON(http.StatusOK, server.H{
+		"result": "restored",
+	})
+}
+
+// MountAdmin регистрирует /admin/dump и /admin/restore.
+func (r *Routes) MountAdmin(srv *server.Server, mw ...server.Middleware) {
+	srv.Get("/admin/dump", r.Dump, mw...).Describe(server.Doc{
+		Summary: "Dump complete calendar state",
+		Responses: map[int]server.Response{
+			http.StatusOK: {Description: "versioned calendar dump", Schema: &DumpSchema},
+		},
+	})
+
+	srv.Post("/admin/restore", r.Restore, mw...).Describe(server.Doc{
+		Summary:     "Replace calendar state with a dump",
+		Description: "Event ids and the eid counter are preserved.",
+		Body:        &DumpSchema,
+		Responses: map[int]server.Response{
+			http.StatusOK:         {Description: "state restored", Schema: ResultSchema(server.Schema{Type: "string"})},
+			http.StatusBadRequest: {Description: "malformed dump or unsupported version", Schema: &ErrorSchema},
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+func TestDumpRestore(t *testing.T) {
+	src := calendar.NewCalendar()
+	src.Create(1, calendar.NewEvent(time.Date(2022, 4, 14, 15, 4, 5, 0, time.UTC), "first"))
+	src.Create(2, calendar.NewEvent(time.Date(2022, 4, 16, 15, 4, 5, 0, time.UTC), "second"))
+
+	srcSrv := server.New("")
+	NewRoutes(src).MountAdmin(srcSrv, server.BearerAuthMW("secret"))
+
+	w := httptest.NewRecorder()
+	srcSrv.ServeHTTP(w, httptest.NewRequest("GET", "/admin/dump", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("dump without token: expected 401, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/admin/dump", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	srcSrv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dump: expected 200, got %d", w.Code)
+	}
+	dump := w.Body.Bytes()
+
+	dst := calendar.NewCalendar()
+	dst.Create(3, calendar.NewEvent(time.Now(), "overwritten"))
+	r := NewRoutes(dst)
+	dstSrv := server.New("")
+	r.MountAdmin(dstSrv)
+
+	w = httptest.NewRecorder()
+	dstSrv.ServeHTTP(w, httptest.NewRequest("POST", "/admin/restore", bytes.NewReader(dump)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	QueryAll(`[{"date":"2022-04-14T15:04:05Z","eid":1,"msg":"first"},{"date":"2022-04-16T15:04:05Z","eid":2,"msg":"second"}]`, r.QueryBuilder(calendar.All)).Test(t)
+
+	// неподдерживаемая версия отклоняется, состояние не меняется
+	w = httptest.NewRecorder()
+	body := strings.NewReader(`{"version":99,"last_id":0,"users":[]}`)
+	dstSrv.ServeHTTP(w, httptest.NewRequest("POST", "/admin/restore", body))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("restore bad version: expected 400, got %d", w.Code)
+	}
+
+	QueryAll(`[{"date":"2022-04-14T15:04:05Z","eid":1,"msg":"first"},{"date":"2022-04-16T15:04:05Z","eid":2,"msg":"second"}]`, r.QueryBuilder(calendar.All)).Test(t)
+}
@@ -0,0 +1,144 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+var minID = 1
+
+var (
+	EventSchema = server.Schema{
+		Type: "object",
+		Properties: map[string]*server.Schema{
+			"eid":  {Type: "integer", Minimum: &minID},
+			"date": {Type: "string", Format: "date-time"},
+			"msg":  {Type: "string"},
+		},
+		Required: []string{"eid", "date", "msg"},
+	}
+
+	ErrorSchema = server.Schema{
+		Type: "object",
+		Properties: map[string]*server.Schema{
+			"error": {Type: "string"},
+		},
+		Required: []string{"error"},
+	}
+)
+
+func ResultSchema(result server.Schema) *server.Schema {
+	return &server.Schema{
+		Type: "object",
+		Properties: map[string]*server.Schema{
+			"result": &result,
+		},
+		Required: []string{"result"},
+	}
+}
+
+func userParam(required bool) server.Param {
+	return server.Param{
+		Name:        "user",
+		Description: "user id",
+		Required:    required,
+		Schema:      server.Schema{Type: "integer", Minimum: &minID},
+	}
+}
+
+func dateParam(required bool) server.Param {
+	return server.Param{
+		Name:        "date",
+		Description: "RFC3339 date, not before 2000-01-01",
+		Required:    required,
+		Schema:      server.Schema{Type: "string", Format: "date-time"},
+	}
+}
+
+var (
+	eidParam = server.Param{
+		Name:        "eid",
+		Description: "event id",
+		Required:    true,
+		Schema:      server.Schema{Type: "integer", Minimum: &minID},
+	}
+
+	msgParam = server.Param{
+		Name:        "msg",
+		Description: "event message",
+		Schema:      server.Schema{Type: "string"},
+	}
+
+	badRequest = server.Response{Description: "invalid parameters", Schema: &ErrorSchema}
+	notFound   = server.Response{Description: "event not found", Schema: &ErrorSchema}
+	events     = server.Response{Description: "events sorted by date", Schema: ResultSchema(server.Schema{Type: "array", Items: &EventSchema})}
+)
+
+func queryDoc(summary string, erange calendar.EventRange) server.Doc {
+	params := []server.Param{userParam(false)}
+	if erange != calendar.All {
+		params = append(params, dateParam(true))
+	}
+
+	return server.Doc{
+		Summary: summary,
+		Params:  params,
+		Responses: map[int]server.Response{
+			http.StatusOK:         events,
+			http.StatusBadRequest: badRequest,
+		},
+	}
+}
+
+// Mount регистрирует все обработчики вместе с их описанием для OpenAPI.
+func (r *Routes) Mount(srv *server.Server, mw ...server.Middleware) {
+	r.MountQueries(srv, mw...)
+	r.MountWrites(srv, mw...)
+}
+
+// MountQueries регистрирует только методы чтения /events_for_*.
+func (r *Routes) MountQueries(srv *server.Server, mw ...server.Middleware) {
+	srv.Get("/", r.QueryBuilder(calendar.All), mw...).
+		Describe(queryDoc("All events, optionally filtered by user", calendar.All))
+	srv.Get("/events_for_day", r.QueryBuilder(calendar.DayRange), mw...).
+		Describe(queryDoc("Events for the day of date", calendar.DayRange))
+	srv.Get("/events_for_week", r.QueryBuilder(calendar.WeekRange), mw...).
+		Describe(queryDoc("Events for the week of date", calendar.WeekRange))
+	srv.Get("/events_for_month", r.QueryBuilder(calendar.MonthRange), mw...).
+		Describe(queryDoc("Events for the month of date", calendar.MonthRange))
+}
+
+func (r *Routes) MountWrites(srv *server.Server, mw ...server.Middleware) {
+	srv.Post("/create_event", r.CreateEvent, mw...).Describe(server.Doc{
+		Summary: "Create event",
+		Params:  []server.Param{userParam(true), dateParam(true), msgParam},
+		Responses: map[int]server.Response{
+			http.StatusCreated:            {Description: "event created", Schema: ResultSchema(server.Schema{Type: "string"})},
+			http.StatusBadRequest:         badRequest,
+			http.StatusServiceUnavailable: {Description: "calendar error", Schema: &ErrorSchema},
+		},
+	})
+
+	srv.Post("/update_event", r.UpdateEvent, mw...).Describe(server.Doc{
+		Summary:     "Update event",
+		Description: "At least one of date or msg must be set.",
+		Params:      []server.Param{userParam(true), eidParam, dateParam(false), msgParam},
+		Responses: map[int]server.Response{
+			http.StatusOK:                 {Description: "event updated", Schema: ResultSchema(server.Schema{Type: "string"})},
+			http.StatusBadRequest:         badRequest,
+			http.StatusServiceUnavailable: notFound,
+		},
+	})
+
+	srv.Post("/delete_event", r.DeleteEvent, mw...).Describe(server.Doc{
+		Summary: "Delete event",
+		Params:  []server.Param{userParam(true), eidParam},
+		Responses: map[int]server.Response{
+			http.StatusOK:                 {Description: "event deleted", Schema: ResultSchema(server.Schema{Type: "string"})},
+			http.StatusBadRequest:         badRequest,
+			http.StatusServiceUnavailable: notFound,
+		},
+	})
+}
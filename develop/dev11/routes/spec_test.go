@@ -0,0 +1,255 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/server"
+)
+
+// Проверяем, что описание маршрутов в OpenAPI соответствует поведению обработчиков:
+//  - запрос со всеми валидными параметрами не получает 400;
+//  - без любого обязательного параметра - 400;
+//  - с невалидным значением любого параметра - 400;
+//  - статус и тело ответа объявлены в описании маршрута.
+
+func ValidValue(schema server.Schema) string {
+	switch {
+	case schema.Type == "integer" && schema.Minimum != nil:
+		return strconv.Itoa(*schema.Minimum)
+	case schema.Type == "integer":
+		return "1"
+	case schema.Format == "date-time":
+		return "2022-04-14T15:04:05Z"
+	default:
+		return "text"
+	}
+}
+
+func InvalidValues(schema server.Schema) []string {
+	switch {
+	case schema.Type == "integer" && schema.Minimum != nil:
+		return []string{"abc", strconv.Itoa(*schema.Minimum - 1)}
+	case schema.Type == "integer":
+		return []string{"abc"}
+	case schema.Format == "date-time":
+		return []string{"14.04.2022"}
+	default:
+		return nil
+	}
+}
+
+func ValidateSchema(schema *server.Schema, value interface{}) error {
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object, got %v", value)
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("missing required property %q in %v", name, value)
+			}
+		}
+		for name, item := range obj {
+			prop, ok := schema.Properties[name]
+			if !ok {
+				if len(schema.Properties) > 0 {
+					return fmt.Errorf("undeclared property %q", name)
+				}
+				continue
+			}
+			if err := ValidateSchema(prop, item); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected array, got %v", value)
+		}
+		for idx, item := range arr {
+			if err := ValidateSchema(schema.Items, item); err != nil {
+				return fmt.Errorf("[%d]: %w", idx, err)
+			}
+		}
+	case "integer":
+		num, ok := value.(float64)
+		if !ok || num != math.Trunc(num) {
+			return fmt.Errorf("expected integer, got %v", value)
+		}
+		if schema.Minimum != nil && num < float64(*schema.Minimum) {
+			return fmt.Errorf("expected >= %d, got %v", *schema.Minimum, num)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %v", value)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidBody строит минимальное JSON тело по схеме: обязательные свойства
+// с допустимыми значениями, массивы пустые.
+func ValidBody(schema *server.Schema) interface{} {
+	switch schema.Type {
+	case "object":
+		obj := map[string]interface{}{}
+		for _, name := range schema.Required {
+			obj[name] = ValidBody(schema.Properties[name])
+		}
+		return obj
+	case "array":
+		return []interface{}{}
+	case "integer":
+		value, _ := strconv.Atoi(ValidValue(*schema))
+		return value
+	default:
+		return ValidValue(*schema)
+	}
+}
+
+func SendBody(srv *server.Server, route *server.Route, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(route.Method, route.Path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func Send(srv *server.Server, route *server.Route, params url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if route.Method == "GET" {
+		req = httptest.NewRequest(route.Method, route.Path+"?"+params.Encode(), nil)
+	} else {
+		req = httptest.NewRequest(route.Method, route.Path, strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func CheckResponse(t *testing.T, route *server.Route, w *httptest.ResponseRecorder, params url.Values) {
+	t.Helper()
+
+	res, ok := route.Doc.Responses[w.Code]
+	if !ok {
+		t.Errorf("%v: undeclared status %d: %s", params, w.Code, w.Body.String())
+		return
+	}
+
+	if res.Schema == nil {
+		return
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Errorf("%v: cant unmarshal response: %v", params, err)
+		return
+	}
+
+	if err := ValidateSchema(res.Schema, body); err != nil {
+		t.Errorf("%v: response doesn't match schema of %d: %v", params, w.Code, err)
+	}
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	cal := calendar.NewCalendar()
+	srv := server.New("")
+	routes := NewRoutes(cal)
+	routes.Mount(srv)
+	routes.MountAdmin(srv)
+
+	// чтобы update/delete могли найти событие
+	cal.Create(1, calendar.NewEvent(time.Date(2022, 4, 14, 0, 0, 0, 0, time.UTC), "seed"))
+	seed := cal.Dump()
+
+	for _, route := range srv.Routes() {
+		route := route
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			// /admin/restore заменяет состояние, каждый маршрут начинает с seed
+			if err := cal.Restore(seed); err != nil {
+				t.Fatal(err)
+			}
+
+			if route.Doc.Summary == "" {
+				t.Error("route has no summary")
+			}
+
+			if route.Doc.Body != nil {
+				w := SendBody(srv, route, "not json")
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("with malformed body expected 400, got %d", w.Code)
+				}
+				CheckResponse(t, route, w, nil)
+
+				body, _ := json.Marshal(ValidBody(route.Doc.Body))
+				w = SendBody(srv, route, string(body))
+				if w.Code == http.StatusBadRequest {
+					t.Errorf("valid body %s rejected: %s", body, w.Body.String())
+				}
+				CheckResponse(t, route, w, nil)
+				return
+			}
+
+			valid := url.Values{}
+			for _, p := range route.Doc.Params {
+				valid.Set(p.Name, ValidValue(p.Schema))
+			}
+
+			for _, p := range route.Doc.Params {
+				if p.Required {
+					params := url.Values{}
+					for k, v := range valid {
+						params[k] = v
+					}
+					params.Del(p.Name)
+
+					w := Send(srv, route, params)
+					if w.Code != http.StatusBadRequest {
+						t.Errorf("without required %q expected 400, got %d", p.Name, w.Code)
+					}
+					CheckResponse(t, route, w, params)
+				}
+
+				for _, value := range InvalidValues(p.Schema) {
+					params := url.Values{}
+					for k, v := range valid {
+						params[k] = v
+					}
+					params.Set(p.Name, value)
+
+					w := Send(srv, route, params)
+					if w.Code != http.StatusBadRequest {
+						t.Errorf("with %s=%q expected 400, got %d", p.Name, value, w.Code)
+					}
+					CheckResponse(t, route, w, params)
+				}
+			}
+
+			w := Send(srv, route, valid)
+			if w.Code == http.StatusBadRequest {
+				t.Errorf("valid request %v rejected: %s", valid, w.Body.String())
+			}
+			CheckResponse(t, route, w, valid)
+		})
+	}
+}
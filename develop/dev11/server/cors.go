@@ -0,0 +1,175 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORS struct {
+	AllowedOrigins   []string // точные origin, "*" или шаблоны вида https://*.example.com
+	AllowedMethods   []string // пусто - все методы, зарегистрированные для пути
+	AllowedHeaders   []string // "*" - любые запрошенные заголовки
+	AllowCredentials bool     // не действует для origin, разрешенных только через "*"
+	MaxAge           time.Duration
+}
+
+// UseCORS включает CORS для всех зарегистрированных путей, в том числе ответы на
+// preflight запросы OPTIONS, для которых отдельные обработчики не нужны.
+func (s *Server) UseCORS(cors CORS) {
+	s.cors = &cors
+}
+
+// matchWildcard сопоставляет строку с шаблоном, где * - любая последовательность символов.
+func matchWildcard(pattern string, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+
+	return len(value) >= len(last) && strings.HasSuffix(value, last)
+}
+
+// SplitOrigins разбирает список origin через запятую, пробелы вокруг
+// элементов и пустые элементы отбрасываются.
+func SplitOrigins(value string) []string {
+	origins := []string{}
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// allowOrigin проверяет origin. star - origin разрешен только через "*":
+// тогда ответ - буквальная "*" без credentials, браузер не отправит cookie
+// на любой сайт.
+func (c *CORS) allowOrigin(origin string) (ok bool, star bool) {
+	for _, pattern := range c.AllowedOrigins {
+		if pattern == "*" {
+			star = true
+		} else if matchWildcard(strings.ToLower(pattern), strings.ToLower(origin)) {
+			return true, false
+		}
+	}
+	return star, star
+}
+
+func (c *CORS) allowHeader(header string) bool {
+	for _, allowed := range c.AllowedHeaders {
+		if allowed == "*" || strings.EqualFold(allowed, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// methods возвращает методы пути, разрешенные конфигурацией.
+func (c *CORS) methods(routes map[string]*Route) []string {
+	result := []string{}
+	for method := range routes {
+		if len(c.AllowedMethods) == 0 {
+			result = append(result, method)
+			continue
+		}
+
+		for _, allowed := range c.AllowedMethods {
+			if strings.EqualFold(allowed, method) {
+				result = append(result, method)
+				break
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// handle дописывает CORS заголовки и возвращает true, если запрос был
+// preflight и ответ уже отправлен.
+func (c *CORS) handle(ctx Context, routes map[string]*Route) bool {
+	origin := ctx.Req.Header.Get("Origin")
+	header := ctx.Res.Header()
+	header.Add("Vary", "Origin")
+
+	requestMethod := ctx.Req.Header.Get("Access-Control-Request-Method")
+	preflight := ctx.Req.Method == http.MethodOptions && requestMethod != ""
+
+	if origin == "" {
+		return false
+	}
+
+	ok, star := c.allowOrigin(origin)
+	if !ok {
+		if preflight {
+			ctx.SendError(http.StatusForbidden)
+		}
+		return preflight
+	}
+
+	if star {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+		if c.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	if !preflight {
+		return false
+	}
+
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	methods := c.methods(routes)
+	allowed := false
+	for _, method := range methods {
+		if method == requestMethod {
+			allowed = true
+		}
+	}
+
+	requestHeaders := []string{}
+	for _, value := range ctx.Req.Header.Values("Access-Control-Request-Headers") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				requestHeaders = append(requestHeaders, name)
+				allowed = allowed && c.allowHeader(name)
+			}
+		}
+	}
+
+	if !allowed {
+		ctx.SendError(http.StatusForbidden)
+		return true
+	}
+
+	header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(requestHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+	}
+	if c.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+
+	ctx.SendError(http.StatusNoContent)
+	return true
+}
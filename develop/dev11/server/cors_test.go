@@ -0,0 +1,224 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		match   bool
+	}{
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://evil-example.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.net", false},
+		{"http://localhost:*", "http://localhost:3000", true},
+		{"a*b*b", "ab", false},
+		{"a*b*b", "abb", true},
+	}
+
+	for _, test := range tests {
+		if matchWildcard(test.pattern, test.value) != test.match {
+			t.Errorf("matchWildcard(%q, %q) expected %v", test.pattern, test.value, test.match)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	srv := New("")
+	srv.Get("/events", func(ctx Context) { ctx.SendJSON(http.StatusOK, H{"result": "ok"}) })
+	srv.Post("/events", func(ctx Context) { ctx.SendJSON(http.StatusOK, H{"result": "ok"}) })
+	srv.Post("/write", func(ctx Context) { ctx.SendJSON(http.StatusOK, H{"result": "ok"}) })
+	srv.UseCORS(CORS{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           time.Minute,
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+
+		status  int
+		expect  map[string]string
+		missing []string
+	}{
+		{
+			name:   "preflight",
+			method: "OPTIONS", path: "/events",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			status: http.StatusNoContent,
+			expect: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "content-type",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "60",
+			},
+		},
+		{
+			name:   "preflight uses route table",
+			method: "OPTIONS", path: "/write",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			status: http.StatusNoContent,
+			expect: map[string]string{"Access-Control-Allow-Methods": "POST"},
+		},
+		{
+			name:   "preflight for unregistered method",
+			method: "OPTIONS", path: "/write",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "preflight with disallowed header",
+			method: "OPTIONS", path: "/events",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Secret",
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "preflight from other origin",
+			method: "OPTIONS", path: "/events",
+			headers: map[string]string{
+				"Origin":                        "https://evil.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			status:  http.StatusForbidden,
+			missing: []string{"Access-Control-Allow-Origin"},
+		},
+		{
+			name:   "preflight for unknown path",
+			method: "OPTIONS", path: "/unknown",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "simple request",
+			method: "GET", path: "/events",
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			status:  http.StatusOK,
+			expect:  map[string]string{"Access-Control-Allow-Origin": "https://app.example.com"},
+			missing: []string{"Access-Control-Allow-Methods"},
+		},
+		{
+			name:   "simple request from other origin",
+			method: "GET", path: "/events",
+			headers: map[string]string{"Origin": "https://evil.com"},
+			status:  http.StatusOK,
+			missing: []string{"Access-Control-Allow-Origin"},
+		},
+		{
+			name:   "same origin request",
+			method: "GET", path: "/events",
+			status:  http.StatusOK,
+			missing: []string{"Access-Control-Allow-Origin"},
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, w.Code)
+			continue
+		}
+
+		for k, v := range test.expect {
+			if got := w.Header().Get(k); got != v {
+				t.Errorf("%s: header %s expected %q, got %q", test.name, k, v, got)
+			}
+		}
+
+		for _, k := range test.missing {
+			if got := w.Header().Get(k); got != "" {
+				t.Errorf("%s: header %s must be unset, got %q", test.name, k, got)
+			}
+		}
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	srv := New("")
+	srv.Get("/events", func(ctx Context) { ctx.SendJSON(http.StatusOK, H{"result": "ok"}) })
+	srv.UseCORS(CORS{
+		AllowedOrigins:   SplitOrigins(" https://app.example.com , ,*,"),
+		AllowCredentials: true,
+	})
+
+	tests := []struct {
+		origin      string
+		allow       string
+		credentials string
+	}{
+		{"https://app.example.com", "https://app.example.com", "true"},
+		// "*" с credentials открыл бы cookie пользователя любому сайту
+		{"https://evil.com", "*", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/events", nil)
+		req.Header.Set("Origin", test.origin)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != test.allow {
+			t.Errorf("%s: expected Allow-Origin %q, got %q", test.origin, test.allow, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != test.credentials {
+			t.Errorf("%s: expected Allow-Credentials %q, got %q", test.origin, test.credentials, got)
+		}
+	}
+}
+
+func TestSplitOrigins(t *testing.T) {
+	tests := []struct {
+		value   string
+		origins []string
+	}{
+		{"https://a.com", []string{"https://a.com"}},
+		{"https://a.com, https://b.com", []string{"https://a.com", "https://b.com"}},
+		{" https://a.com ,, https://*.b.com ,", []string{"https://a.com", "https://*.b.com"}},
+		{" , ", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := SplitOrigins(test.value); !reflect.DeepEqual(got, test.origins) {
+			t.Errorf("%q: expected %q, got %q", test.value, test.origins, got)
+		}
+	}
+}
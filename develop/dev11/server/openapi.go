@@ -0,0 +1,173 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema - подмножество JSON Schema, достаточное для описания параметров и ответов API.
+type Schema struct {
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Minimum     *int               `json:"minimum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+}
+
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      Schema
+}
+
+type Response struct {
+	Description string
+	Schema      *Schema
+}
+
+type Doc struct {
+	Summary     string
+	Description string
+	Params      []Param
+	Body        *Schema // JSON тело запроса, вместо параметров формы
+	Responses   map[int]Response
+}
+
+type Info struct {
+	Title       string
+	Description string
+	Version     string
+}
+
+func (r *Route) Describe(doc Doc) *Route {
+	r.Doc = doc
+	return r
+}
+
+// OpenAPI собирает документ OpenAPI 3 из таблицы маршрутов.
+// Для GET параметры описываются как query, для остальных методов - как тело формы.
+func (s *Server) OpenAPI(info Info) H {
+	paths := H{}
+
+	for _, route := range s.Routes() {
+		item, ok := paths[route.Path].(H)
+		if !ok {
+			item = H{}
+			paths[route.Path] = item
+		}
+
+		item[strings.ToLower(route.Method)] = route.operation()
+	}
+
+	return H{
+		"openapi": "3.0.3",
+		"info": H{
+			"title":       info.Title,
+			"description": info.Description,
+			"version":     info.Version,
+		},
+		"paths": paths,
+	}
+}
+
+func (s *Server) ServeOpenAPI(path string, info Info, mw ...Middleware) *Route {
+	return s.Get(path, func(ctx Context) {
+		ctx.SendJSON(http.StatusOK, s.OpenAPI(info))
+	}, mw...).Describe(Doc{
+		Summary: "OpenAPI specification of this server",
+		Responses: map[int]Response{
+			http.StatusOK: {Description: "OpenAPI 3 document", Schema: &Schema{Type: "object"}},
+		},
+	})
+}
+
+func (r *Route) operation() H {
+	op := H{}
+
+	if r.Doc.Summary != "" {
+		op["summary"] = r.Doc.Summary
+	}
+
+	if r.Doc.Description != "" {
+		op["description"] = r.Doc.Description
+	}
+
+	if r.Doc.Body != nil {
+		op["requestBody"] = H{
+			"required": true,
+			"content": H{
+				"application/json": H{"schema": r.Doc.Body},
+			},
+		}
+	} else if len(r.Doc.Params) > 0 {
+		if r.Method == "GET" {
+			params := []H{}
+			for _, p := range r.Doc.Params {
+				params = append(params, H{
+					"name":        p.Name,
+					"in":          "query",
+					"description": p.Description,
+					"required":    p.Required,
+					"schema":      p.Schema,
+				})
+			}
+			op["parameters"] = params
+		} else {
+			op["requestBody"] = H{
+				"required": true,
+				"content": H{
+					"application/x-www-form-urlencoded": H{
+						"schema": r.Doc.FormSchema(),
+					},
+				},
+			}
+		}
+	}
+
+	codes := []int{}
+	for code := range r.Doc.Responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	responses := H{}
+	for _, code := range codes {
+		res := r.Doc.Responses[code]
+		item := H{"description": res.Description}
+		if res.Schema != nil {
+			item["content"] = H{
+				"application/json": H{"schema": res.Schema},
+			}
+		}
+		responses[strconv.Itoa(code)] = item
+	}
+
+	if len(responses) == 0 {
+		responses["default"] = H{"description": "no description"}
+	}
+	op["responses"] = responses
+
+	return op
+}
+
+// FormSchema описывает параметры как один объект, как это требуется для requestBody.
+func (d *Doc) FormSchema() *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, p := range d.Params {
+		prop := p.Schema
+		if prop.Description == "" {
+			prop.Description = p.Description
+		}
+		schema.Properties[p.Name] = &prop
+
+		if p.Required {
+			schema.Required = append(schema.Required, p.Name)
+		}
+	}
+	return schema
+}
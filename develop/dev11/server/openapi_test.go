@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	srv := New("")
+	one := 1
+
+	srv.Get("/items", func(ctx Context) {}).Describe(Doc{
+		Summary: "list items",
+		Params: []Param{
+			{Name: "id", Required: true, Schema: Schema{Type: "integer", Minimum: &one}},
+		},
+		Responses: map[int]Response{
+			http.StatusOK: {Description: "ok", Schema: &Schema{Type: "object"}},
+		},
+	})
+	srv.Post("/items", func(ctx Context) {}).Describe(Doc{
+		Summary: "add item",
+		Params: []Param{
+			{Name: "name", Description: "item name", Required: true, Schema: Schema{Type: "string"}},
+			{Name: "note", Schema: Schema{Type: "string"}},
+		},
+	})
+	srv.ServeOpenAPI("/openapi.json", Info{Title: "test", Version: "0.1.0"})
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title string `json:"title"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Parameters []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+				Schema   Schema `json:"schema"`
+			} `json:"parameters"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema Schema `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]interface{} `json:"responses"`
+		} `json:"paths"`
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.OpenAPI != "3.0.3" || doc.Info.Title != "test" {
+		t.Errorf("bad header: %s", w.Body.String())
+	}
+
+	get := doc.Paths["/items"]["get"]
+	if get.Summary != "list items" || len(get.Parameters) != 1 {
+		t.Fatalf("bad get operation: %+v", get)
+	}
+	if p := get.Parameters[0]; p.Name != "id" || p.In != "query" || !p.Required || p.Schema.Type != "integer" || *p.Schema.Minimum != 1 {
+		t.Errorf("bad get parameter: %+v", p)
+	}
+	if _, ok := get.Responses["200"]; !ok {
+		t.Errorf("missing 200 response: %v", get.Responses)
+	}
+
+	post := doc.Paths["/items"]["post"]
+	form := post.RequestBody.Content["application/x-www-form-urlencoded"].Schema
+	if form.Type != "object" || len(form.Properties) != 2 || len(form.Required) != 1 || form.Required[0] != "name" {
+		t.Errorf("bad post form schema: %+v", form)
+	}
+	if form.Properties["name"].Description != "item name" {
+		t.Errorf("param description is lost: %+v", form.Properties["name"])
+	}
+
+	if _, ok := doc.Paths["/openapi.json"]["get"]; !ok {
+		t.Errorf("spec route is not described")
+	}
+}
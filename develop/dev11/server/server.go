@@ -2,10 +2,12 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 )
 
 type Context struct {
@@ -22,7 +24,7 @@ func (ctx *Context) SendJSON(statusCode int, data H) {
 	ctx.Res.Header().Set("Content-Type", "application/json")
 	bytes, err := json.Marshal(data)
 	if err != nil {
-		log.Println("json marshal err. Err: %s, for request %v", err, ctx.Req.RequestURI)
+		log.Printf("json marshal err. Err: %s, for request %v", err, ctx.Req.RequestURI)
 	}
 	ctx.Res.Write(bytes)
 }
@@ -38,10 +40,33 @@ func LoggerMW(next func(Context)) func(Context) {
 	}
 }
 
+type Route struct {
+	Method  string
+	Path    string
+	Handler Handler
+	Doc     Doc
+}
+
+// BearerAuthMW пропускает только запросы с заголовком Authorization: Bearer <token>.
+func BearerAuthMW(token string) Middleware {
+	expected := []byte("Bearer " + token)
+	return func(next Handler) Handler {
+		return func(ctx Context) {
+			got := []byte(ctx.Req.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(got, expected) != 1 {
+				ctx.SendJSON(http.StatusUnauthorized, H{"error": "unauthorized"})
+				return
+			}
+			next(ctx)
+		}
+	}
+}
+
 type Server struct {
 	http  *http.Server
 	mux   *http.ServeMux
-	paths map[string]map[string]Handler // exactpath/method/handler
+	paths map[string]map[string]*Route // exactpath/method/route
+	cors  *CORS
 }
 
 func New(address string) *Server {
@@ -52,7 +77,7 @@ func New(address string) *Server {
 	srv := &Server{
 		http:  h,
 		mux:   mux,
-		paths: map[string]map[string]Handler{},
+		paths: map[string]map[string]*Route{},
 	}
 
 	mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
@@ -71,6 +96,16 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return s.http.Shutdown(ctx)
 }
 
+// OnShutdown регистрирует функцию, вызываемую при Shutdown. Нужна обработчикам
+// с долгими соединениями, которые Shutdown сам не прерывает.
+func (s *Server) OnShutdown(f func()) {
+	s.http.RegisterOnShutdown(f)
+}
+
+func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(rw, r)
+}
+
 func (s *Server) matchPath(ctx Context) {
 	handlers, ok := s.paths[ctx.Req.URL.Path]
 	if !ok {
@@ -78,7 +113,11 @@ func (s *Server) matchPath(ctx Context) {
 		return
 	}
 
-	handler, ok := handlers[ctx.Req.Method]
+	if s.cors != nil && s.cors.handle(ctx, handlers) {
+		return
+	}
+
+	route, ok := handlers[ctx.Req.Method]
 	if !ok {
 		ctx.SendError(http.StatusNotFound)
 		return
@@ -90,27 +129,46 @@ func (s *Server) matchPath(ctx Context) {
 		return
 	}
 
-	handler(ctx)
+	route.Handler(ctx)
 }
 
-func (s *Server) Get(path string, end Handler, mw ...Middleware) {
+func (s *Server) Get(path string, end Handler, mw ...Middleware) *Route {
+	return s.handle("GET", path, end, mw)
+}
+
+func (s *Server) Post(path string, end Handler, mw ...Middleware) *Route {
+	return s.handle("POST", path, end, mw)
+}
+
+func (s *Server) handle(method string, path string, end Handler, mw []Middleware) *Route {
 	for idx := len(mw) - 1; idx >= 0; idx-- {
 		end = mw[idx](end)
 	}
 
 	if s.paths[path] == nil {
-		s.paths[path] = map[string]Handler{}
+		s.paths[path] = map[string]*Route{}
 	}
-	s.paths[path]["GET"] = end
+
+	route := &Route{Method: method, Path: path, Handler: end}
+	s.paths[path][method] = route
+	return route
 }
 
-func (s *Server) Post(path string, end Handler, mw ...Middleware) {
-	for idx := len(mw) - 1; idx >= 0; idx-- {
-		end = mw[idx](end)
+// Routes возвращает зарегистрированные маршруты, отсортированные по пути и методу.
+func (s *Server) Routes() []*Route {
+	result := []*Route{}
+	for _, methods := range s.paths {
+		for _, route := range methods {
+			result = append(result, route)
+		}
 	}
 
-	if s.paths[path] == nil {
-		s.paths[path] = map[string]Handler{}
-	}
-	s.paths[path]["POST"] = end
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Method < result[j].Method
+	})
+
+	return result
 }
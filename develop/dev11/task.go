@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/pgeowng/wb-l2/develop/dev11/calendar"
+	"github.com/pgeowng/wb-l2/develop/dev11/replication"
 	"github.com/pgeowng/wb-l2/develop/dev11/routes"
 	"github.com/pgeowng/wb-l2/develop/dev11/server"
 )
@@ -35,6 +36,66 @@ import (
 	4. Код должен проходить проверки go vet и golint.
 */
 
+// NewServer собирает сервер календаря по переменным окружения из getenv.
+// stop останавливает реплику, если сервер ею работает.
+func NewServer(addr string, getenv func(string) string) (srv *server.Server, stop func()) {
+	srv = server.New(addr)
+
+	cal := calendar.NewCalendar()
+	routes := routes.NewRoutes(cal)
+
+	logger := server.LoggerMW
+
+	// при заданном LEADER_URL сервер работает как read-only реплика
+	runCtx, stop := context.WithCancel(context.Background())
+
+	// REPLICATION_TOKEN (по умолчанию ADMIN_TOKEN) защищает методы репликации:
+	// снимок отдает все события, поэтому без токена они не монтируются
+	replicationToken := getenv("REPLICATION_TOKEN")
+	if replicationToken == "" {
+		replicationToken = getenv("ADMIN_TOKEN")
+	}
+
+	if leaderURL := getenv("LEADER_URL"); leaderURL != "" {
+		follower := replication.NewFollower(cal, leaderURL)
+		follower.Token = replicationToken
+		follower.Mount(srv, logger)
+		go follower.Run(runCtx)
+	} else {
+		routes.Mount(srv, logger)
+
+		if replicationToken != "" {
+			leader := replication.NewLeader(cal)
+			leader.Mount(srv, logger, server.BearerAuthMW(replicationToken))
+			srv.OnShutdown(leader.Close)
+		} else {
+			fmt.Println("srv: replication is disabled, set REPLICATION_TOKEN or ADMIN_TOKEN to enable it")
+		}
+
+		// админские методы доступны только при заданном ADMIN_TOKEN
+		if token := getenv("ADMIN_TOKEN"); token != "" {
+			routes.MountAdmin(srv, logger, server.BearerAuthMW(token))
+		}
+	}
+
+	// CORS_ORIGINS - список origin через запятую, допускаются шаблоны https://*.example.com
+	if origins := getenv("CORS_ORIGINS"); origins != "" {
+		srv.UseCORS(server.CORS{
+			AllowedOrigins: server.SplitOrigins(origins),
+			AllowedHeaders: []string{"Content-Type", "Authorization"},
+			MaxAge:         10 * time.Minute,
+		})
+	}
+
+	srv.ServeOpenAPI("/openapi.json", server.Info{
+		Title:       "calendar",
+		Description: "HTTP API for the calendar",
+		Version:     "1.0.0",
+	}, logger)
+
+	return srv, stop
+}
+
 func main() {
 	PORT := os.Getenv("PORT")
 
@@ -43,21 +104,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	srv := server.New(":" + PORT)
-
-	cal := calendar.NewCalendar()
-	routes := routes.NewRoutes(cal)
-
-	logger := server.LoggerMW
-
-	srv.Get("/", routes.QueryBuilder(calendar.All), logger)
-	srv.Get("/events_for_day", routes.QueryBuilder(calendar.DayRange), logger)
-	srv.Get("/events_for_week", routes.QueryBuilder(calendar.WeekRange), logger)
-	srv.Get("/events_for_month", routes.QueryBuilder(calendar.MonthRange), logger)
-
-	srv.Post("/create_event", routes.CreateEvent, logger)
-	srv.Post("/update_event", routes.UpdateEvent, logger)
-	srv.Post("/delete_event", routes.DeleteEvent, logger)
+	srv, stopFollower := NewServer(":"+PORT, os.Getenv)
+	defer stopFollower()
 
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
@@ -65,6 +113,7 @@ func main() {
 	go func() {
 		<-interrupt
 		signal.Stop(interrupt)
+		stopFollower()
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		err := srv.Shutdown(ctx)
@@ -1 +1,54 @@
 package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNewServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		snapshot int
+	}{
+		// без токена календарь работает, только без репликации
+		{"no token", map[string]string{}, http.StatusNotFound},
+		{"replication token", map[string]string{"REPLICATION_TOKEN": "secret"}, http.StatusUnauthorized},
+		{"admin token", map[string]string{"ADMIN_TOKEN": "secret"}, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		srv, stop := NewServer("", func(name string) string { return test.env[name] })
+		ts := httptest.NewServer(srv)
+
+		res, err := http.PostForm(ts.URL+"/create_event", url.Values{
+			"user": {"1"}, "date": {"2022-04-14T15:04:05Z"}, "msg": {"first"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusCreated {
+			t.Errorf("%s: create_event: expected 201, got %d", test.name, res.StatusCode)
+		}
+
+		for path, status := range map[string]int{
+			"/events_for_day?user=1&date=2022-04-14T00:00:00Z": http.StatusOK,
+			"/replication/snapshot":                            test.snapshot,
+		} {
+			res, err := http.Get(ts.URL + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != status {
+				t.Errorf("%s: %s: expected %d, got %d", test.name, path, status, res.StatusCode)
+			}
+		}
+
+		ts.Close()
+		stop()
+	}
+}